@@ -5,6 +5,7 @@ import (
 	"GoReconX/internal/config"
 	"GoReconX/internal/database"
 	"GoReconX/internal/logging"
+	"GoReconX/internal/modules"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
@@ -28,6 +29,11 @@ func main() {
 		logger.WithError(err).Fatal("Failed to load configuration")
 	}
 
+	// Register external plugin modules
+	if _, err := modules.LoadPlugins(cfg.Plugins.Directory, logger); err != nil {
+		logger.WithError(err).Warn("Failed to load plugins")
+	}
+
 	// Initialize database
 	db, err := database.InitDB()
 	if err != nil {
@@ -4,13 +4,23 @@ import (
 	"GoReconX/internal/ai"
 	"GoReconX/internal/config"
 	"GoReconX/internal/database"
+	"GoReconX/internal/engine"
 	"GoReconX/internal/logging"
 	"GoReconX/internal/modules"
+	"GoReconX/internal/pipeline"
 	"GoReconX/internal/reports"
+	"GoReconX/internal/scope"
+	"GoReconX/internal/targets"
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -25,6 +35,11 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Register external plugin modules
+	if _, err := modules.LoadPlugins(cfg.Plugins.Directory, logger); err != nil {
+		logger.WithError(err).Warn("Failed to load plugins")
+	}
+
 	// Initialize database
 	db, err := database.InitDB()
 	if err != nil {
@@ -36,34 +51,109 @@ func main() {
 	moduleManager := modules.NewModuleManager(db, cfg, logger)
 	defer moduleManager.Close()
 
-	// Example target
-	target := "example.com"
-	if len(os.Args) > 1 {
-		target = os.Args[1]
+	// Build command line flags from the module option schemas, e.g.
+	// -port_scanning.ports=1-65535
+	subdomainFlags := bindModuleFlags(moduleManager, "subdomain_enumeration")
+	portFlags := bindModuleFlags(moduleManager, "port_scanning")
+	listModules := flag.Bool("list-modules", false, "List the available modules and exit")
+	scopeFile := flag.String("scope", "", "YAML file with the engagement scope to enforce")
+	pipelineFile := flag.String("pipeline", "", "YAML pipeline to run instead of the built-in scans")
+	resumeScan := flag.Int("resume", 0, "Resume the interrupted scan with this ID instead of running new scans")
+	listInterrupted := flag.Bool("interrupted", false, "List the interrupted scans that can be resumed and exit")
+	flag.Parse()
+
+	if *listModules {
+		demonstrateModules(moduleManager)
+		return
 	}
 
-	fmt.Printf("🎯 GoReconX CLI Demo - Scanning target: %s\n\n", target)
+	if *listInterrupted {
+		listResumableScans(moduleManager)
+		return
+	}
 
-	// Run subdomain enumeration
-	fmt.Println("🔍 Running Subdomain Enumeration...")
-	subdomainResult, err := runSubdomainEnum(moduleManager, target)
+	// Enforce the engagement scope given on the command line, or the one
+	// stored for the project
+	if *scopeFile != "" {
+		engagement, err := scope.Load(*scopeFile)
+		if err != nil {
+			log.Fatalf("Failed to load scope: %v", err)
+		}
+		if err := moduleManager.SetScope(engagement); err != nil {
+			logger.WithError(err).Warn("Failed to store scope")
+		}
+	} else if err := moduleManager.LoadScope(); err != nil {
+		logger.WithError(err).Warn("Failed to load scope")
+	}
+
+	// Example target. Arguments may also be comma lists, CIDR and IP
+	// ranges, @file or - to read targets from stdin.
+	specs := flag.Args()
+	if len(specs) == 0 {
+		specs = []string{"example.com"}
+	}
+	targetList, err := targets.List(specs, os.Stdin)
 	if err != nil {
-		logger.WithError(err).Error("Subdomain enumeration failed")
-	} else {
-		fmt.Printf("✅ Found %d subdomains\n", len(subdomainResult.Results))
+		log.Fatalf("Invalid targets: %v", err)
 	}
+	target := strings.Join(specs, ", ")
+
+	// Stop running scans on Ctrl-C and keep their partial results
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Run port scanning on discovered subdomains
-	fmt.Println("\n🔌 Running Port Scanning...")
-	portResults := runPortScanning(moduleManager, target, logger)
-	fmt.Printf("✅ Completed port scans on %d targets\n", len(portResults))
+	// Show findings and progress as they happen
+	ctx = modules.WithEventHandler(ctx, printEvent)
+
+	fmt.Printf("🎯 GoReconX CLI Demo - Scanning target: %s\n\n", target)
 
-	// Collect all results
 	var allResults []*modules.ScanResult
-	if subdomainResult != nil {
-		allResults = append(allResults, subdomainResult)
+	if *resumeScan != 0 {
+		// Continue an interrupted scan where it stopped
+		fmt.Printf("⏯️  Resuming scan %d...\n", *resumeScan)
+		result, err := moduleManager.ResumeScan(ctx, *resumeScan)
+		if result == nil {
+			log.Fatalf("Failed to resume scan: %v", err)
+		}
+		if err != nil {
+			logger.WithError(err).Error("Resumed scan failed")
+		}
+		fmt.Printf("✅ %s: %d findings (%s)\n", result.Target, len(result.Results), result.Status)
+		target = result.Target
+		allResults = append(allResults, result)
+	} else if *pipelineFile != "" {
+		// Run the stages of a pipeline
+		allResults, err = runPipeline(ctx, moduleManager, *pipelineFile, targetList)
+		if err != nil {
+			logger.WithError(err).Error("Pipeline failed")
+		}
+	} else {
+		// Run subdomain enumeration
+		fmt.Println("🔍 Running Subdomain Enumeration...")
+		subdomainResults, err := runSubdomainEnum(ctx, moduleManager, targetList, subdomainFlags())
+		if err != nil {
+			logger.WithError(err).Error("Subdomain enumeration failed")
+		}
+		for _, result := range subdomainResults {
+			fmt.Printf("✅ %s: found %d subdomains (%s)\n", result.Target, len(result.Results), result.Status)
+		}
+
+		// Run port scanning on the targets
+		fmt.Println("\n🔌 Running Port Scanning...")
+		portResults := runPortScanning(ctx, moduleManager, targetList, portFlags(), logger)
+		fmt.Printf("✅ Completed port scans on %d targets\n", len(portResults))
+
+		// Collect all results
+		allResults = append(allResults, subdomainResults...)
+		allResults = append(allResults, portResults...)
+	}
+
+	// Tell how to continue scans that were interrupted
+	for _, result := range allResults {
+		if result.Status == modules.StatusCancelled && result.ScanID != 0 {
+			fmt.Printf("⏸️  %s on %s was interrupted - continue it with -resume %d\n", result.ModuleName, result.Target, result.ScanID)
+		}
 	}
-	allResults = append(allResults, portResults...)
 
 	// Initialize AI client for analysis
 	var aiClient *ai.GeminiClient
@@ -125,67 +215,114 @@ func main() {
 	fmt.Println("💡 Tip: Use the GUI for a more interactive experience with real-time updates")
 }
 
-func runSubdomainEnum(moduleManager *modules.ModuleManager, target string) (*modules.ScanResult, error) {
+// listResumableScans prints the interrupted scans of the project
+func listResumableScans(moduleManager *modules.ModuleManager) {
+	scans, err := moduleManager.DB.GetResumableScans(moduleManager.ProjectID)
+	if err != nil {
+		log.Fatalf("Failed to list scans: %v", err)
+	}
+	if len(scans) == 0 {
+		fmt.Println("No interrupted scans")
+		return
+	}
+
+	fmt.Println("⏸️  Interrupted scans (resume with -resume ID):")
+	for _, scan := range scans {
+		fmt.Printf("   %-6d %-24s %-30s %-10s %s\n", scan.ID, scan.ScanType, scan.Target, scan.Status, scan.StartedAt)
+	}
+}
+
+// bindModuleFlags registers a flag for every option of the module,
+// including the rate limit overrides every module accepts
+func bindModuleFlags(moduleManager *modules.ModuleManager, moduleID string) func() map[string]interface{} {
+	schema := moduleManager.OptionSchema(moduleID)
+	if schema == nil {
+		return func() map[string]interface{} { return nil }
+	}
+	return schema.BindFlags(flag.CommandLine, moduleID+".")
+}
+
+func runSubdomainEnum(ctx context.Context, moduleManager *modules.ModuleManager, targetList []string, overrides map[string]interface{}) ([]*modules.ScanResult, error) {
 	options := map[string]interface{}{
 		"threads":     20,
 		"timeout":     3,
 		"resolve_ips": true,
 	}
+	for name, value := range overrides {
+		options[name] = value
+	}
+
+	results, err := moduleManager.ExecuteTargets(ctx, "subdomain_enumeration", engine.Slice(targetList), options)
+	return results.Ordered(), err
+}
 
-	result, err := moduleManager.ExecuteModule("subdomain_enumeration", target, options)
+func runPortScanning(ctx context.Context, moduleManager *modules.ModuleManager, targetList []string, overrides map[string]interface{}, logger *logrus.Logger) []*modules.ScanResult {
+	// Scan common ports on every target
+	options := map[string]interface{}{
+		"ports":    "22,80,443,8080,8443",
+		"threads":  50,
+		"timeout":  2,
+		"scan_tcp": true,
+	}
+	for name, value := range overrides {
+		options[name] = value
+	}
+
+	results, err := moduleManager.ExecuteTargets(ctx, "port_scanning", engine.Slice(targetList), options)
 	if err != nil {
-		return nil, err
+		logger.WithError(err).Warn("Port scanning stopped early")
 	}
 
-	// Display some results
-	if len(result.Results) > 0 {
-		fmt.Printf("   Sample subdomains found:\n")
-		for i, item := range result.Results {
-			if i >= 5 { // Show only first 5
-				break
-			}
-			if subdomain, ok := item.(map[string]interface{}); ok {
-				if name, exists := subdomain["subdomain"]; exists {
-					fmt.Printf("   - %s\n", name)
-				}
-			}
+	for _, result := range results.Ordered() {
+		if result.Status == modules.StatusFailed {
+			logger.WithField("target", result.Target).Warn("Port scan failed: " + result.ErrorMessage)
+			continue
 		}
-		if len(result.Results) > 5 {
-			fmt.Printf("   ... and %d more\n", len(result.Results)-5)
+
+		// Display results
+		if len(result.Results) > 0 {
+			fmt.Printf("   %s: %d open ports\n", result.Target, len(result.Results))
 		}
 	}
+	if stats := results.Discovery; stats != nil {
+		fmt.Printf("   Host discovery: %d probed, %d up, %d down\n", stats.Probed, stats.Up, stats.Down)
+	}
 
-	return result, nil
+	return results.Ordered()
 }
 
-func runPortScanning(moduleManager *modules.ModuleManager, target string, logger *log.Logger) []*modules.ScanResult {
-	var results []*modules.ScanResult
-
-	// Scan common ports on the main target
-	targets := []string{target}
+// runPipeline runs the pipeline in file against the targets and returns the
+// results of all its scans
+func runPipeline(ctx context.Context, moduleManager *modules.ModuleManager, file string, targetList []string) ([]*modules.ScanResult, error) {
+	p, err := pipeline.Load(file)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, scanTarget := range targets {
-		options := map[string]interface{}{
-			"ports":    "22,80,443,8080,8443",
-			"threads":  50,
-			"timeout":  2,
-			"scan_tcp": true,
-		}
+	fmt.Printf("🔗 Running pipeline %s...\n", p.Name)
+	result, err := pipeline.Run(ctx, moduleManager, p, targetList)
+	if result == nil {
+		return nil, err
+	}
 
-		result, err := moduleManager.ExecuteModule("port_scanning", scanTarget, options)
-		if err != nil {
-			continue // Skip failed scans
-		}
+	for _, stage := range result.Stages {
+		fmt.Printf("   %s: %d targets, %d findings\n", stage.Name, len(stage.Targets), stage.Findings)
+	}
+	fmt.Printf("✅ Pipeline %s (job %d)\n", result.Status, result.JobID)
 
-		results = append(results, result)
+	return result.ScanResults(), err
+}
 
-		// Display results
-		if len(result.Results) > 0 {
-			fmt.Printf("   %s: %d open ports\n", scanTarget, len(result.Results))
-		}
+// printEvent shows live findings and a progress line with an ETA
+func printEvent(event modules.Event) {
+	switch event.Type {
+	case modules.EventProgress:
+		fmt.Printf("\r\033[K   [%d/%d] ETA %s", event.Done, event.Total, event.ETA().Round(time.Second))
+	case modules.EventFinding:
+		fmt.Printf("\r\033[K   + %v\n", event.Finding)
+	case modules.EventFinished:
+		fmt.Print("\r\033[K")
 	}
-
-	return results
 }
 
 func truncateString(s string, length int) string {
@@ -196,64 +333,23 @@ func truncateString(s string, length int) string {
 }
 
 // demonstrateModules shows available modules and their capabilities
-func demonstrateModules() {
+func demonstrateModules(moduleManager *modules.ModuleManager) {
 	fmt.Println("🧩 Available GoReconX Modules:")
 	fmt.Println("")
 
-	modules := []struct {
-		name        string
-		description string
-		category    string
-		features    []string
-	}{
-		{
-			name:        "Subdomain Enumeration",
-			description: "Advanced DNS-based subdomain discovery",
-			category:    "Passive OSINT",
-			features:    []string{"Wordlist-based discovery", "DNS resolution", "Concurrent scanning", "IP resolution"},
-		},
-		{
-			name:        "Port Scanner",
-			description: "Fast TCP/UDP port scanning with service detection",
-			category:    "Active Reconnaissance",
-			features:    []string{"TCP/UDP scanning", "Service detection", "Banner grabbing", "Custom port ranges"},
-		},
-		{
-			name:        "Email Harvester",
-			description: "Collect email addresses from various sources",
-			category:    "Passive OSINT",
-			features:    []string{"Search engine scraping", "Social media lookup", "WHOIS extraction", "DNS enumeration"},
-		},
-		{
-			name:        "Web Analyzer",
-			description: "Analyze web technologies and content",
-			category:    "Passive OSINT",
-			features:    []string{"Technology detection", "Header analysis", "Content extraction", "Vulnerability hints"},
-		},
-		{
-			name:        "Directory Enumerator",
-			description: "Discover hidden directories and files",
-			category:    "Active Reconnaissance",
-			features:    []string{"Wordlist-based discovery", "Response analysis", "Recursive scanning", "Custom headers"},
-		},
-		{
-			name:        "IP Geolocator",
-			description: "Determine geographical location and ASN info",
-			category:    "Passive OSINT",
-			features:    []string{"GeoIP lookup", "ASN information", "ISP details", "Country/region data"},
-		},
-		{
-			name:        "GitHub Reconnaissance",
-			description: "Search for sensitive information in repositories",
-			category:    "Passive OSINT",
-			features:    []string{"Repository scanning", "Sensitive file detection", "Commit history analysis", "API integration"},
-		},
+	categories := map[modules.ModuleCategory]string{
+		modules.CategoryPassive: "Passive OSINT",
+		modules.CategoryActive:  "Active Reconnaissance",
 	}
 
-	for _, module := range modules {
-		fmt.Printf("📦 %s (%s)\n", module.name, module.category)
-		fmt.Printf("   %s\n", module.description)
-		fmt.Printf("   Features: %s\n", fmt.Sprintf("%v", module.features))
+	available := moduleManager.GetAvailableModules()
+	for _, info := range moduleManager.ListModules("") {
+		fmt.Printf("📦 %s [%s] (%s)\n", info.Name, info.ID, categories[info.Category])
+		fmt.Printf("   %s\n", info.Description)
+		fmt.Printf("   Inputs: %s\n", strings.Join(info.RequiredInputs, ", "))
+		for _, spec := range available[info.ID].GetOptionSchema() {
+			fmt.Printf("   -%s.%s (%s, default %v): %s\n", info.ID, spec.Name, spec.Type, spec.Default, spec.Description)
+		}
 		fmt.Println()
 	}
 }
@@ -293,8 +389,12 @@ func main() {
         "resolve_ips": true,
     }
     
-    // Execute subdomain enumeration
+    // Execute subdomain enumeration, giving up after ten minutes
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+    defer cancel()
+
     result, err := moduleManager.ExecuteModule(
+        ctx,
         "subdomain_enumeration", 
         "example.com", 
         options,
@@ -27,6 +27,12 @@ type Config struct {
 		Retries    int    `yaml:"retries"`
 		ProxyURL   string `yaml:"proxy_url"`
 		UserAgent  string `yaml:"user_agent"`
+		RateLimit  RateLimitConfig `yaml:"rate_limit"`
+		// MaxParallelTargets caps how many targets are scanned at once
+		MaxParallelTargets int `yaml:"max_parallel_targets"`
+		// Resolvers is a file listing the DNS resolvers to query, one per
+		// line. Without it names are resolved by the system resolver.
+		Resolvers string `yaml:"resolvers"`
 	} `yaml:"network"`
 	
 	Wordlists struct {
@@ -34,12 +40,56 @@ type Config struct {
 		Directories  string `yaml:"directories"`
 		Files        string `yaml:"files"`
 		Ports        string `yaml:"ports"`
+		Permutations string `yaml:"permutations"`
+		Recursive    string `yaml:"recursive"`
 	} `yaml:"wordlists"`
 	
 	Output struct {
 		DefaultFormat string `yaml:"default_format"`
 		OutputDir     string `yaml:"output_dir"`
 	} `yaml:"output"`
+	
+	Plugins struct {
+		Directory string `yaml:"directory"`
+		Timeout   int    `yaml:"timeout"`
+	} `yaml:"plugins"`
+
+	Takeover struct {
+		// Fingerprints is the YAML file describing the services whose
+		// dangling records can be taken over
+		Fingerprints string `yaml:"fingerprints"`
+	} `yaml:"takeover"`
+
+	Services struct {
+		// Probes is the service probe file, in the format of nmap's
+		// nmap-service-probes
+		Probes string `yaml:"probes"`
+	} `yaml:"services"`
+
+	// Sources are the web services asked for the subdomains they know of
+	Sources []SourceConfig `yaml:"sources"`
+}
+
+// RateLimitConfig controls how fast modules send requests. Rates are in
+// requests per second and a rate of zero means no limit. Every request is
+// additionally delayed by a random time between MinDelay and MaxDelay
+// milliseconds.
+type RateLimitConfig struct {
+	GlobalRPS      float64 `yaml:"global_rps" json:"global_rps"`
+	PerHostRPS     float64 `yaml:"per_host_rps" json:"per_host_rps"`
+	PerResolverRPS float64 `yaml:"per_resolver_rps" json:"per_resolver_rps"`
+	Burst          int     `yaml:"burst" json:"burst"`
+	MinDelay       int     `yaml:"min_delay_ms" json:"min_delay_ms"`
+	MaxDelay       int     `yaml:"max_delay_ms" json:"max_delay_ms"`
+}
+
+// SourceConfig describes a web service that lists the known subdomains of
+// a domain. {domain} in the URL is replaced by the domain looked up, and
+// environment variables such as $VT_API_KEY in header values are expanded.
+type SourceConfig struct {
+	Name    string            `yaml:"name" json:"name"`
+	URL     string            `yaml:"url" json:"url"`
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
 }
 
 // DefaultConfig returns a configuration with default values
@@ -55,21 +105,32 @@ func DefaultConfig() *Config {
 			Retries    int    `yaml:"retries"`
 			ProxyURL   string `yaml:"proxy_url"`
 			UserAgent  string `yaml:"user_agent"`
+			RateLimit  RateLimitConfig `yaml:"rate_limit"`
+			MaxParallelTargets int `yaml:"max_parallel_targets"`
+			Resolvers string `yaml:"resolvers"`
 		}{
 			Timeout:   30,
 			Retries:   3,
 			UserAgent: "GoReconX/1.0 (OSINT Tool)",
+			RateLimit: RateLimitConfig{
+				Burst: 1,
+			},
+			MaxParallelTargets: 10,
 		},
 		Wordlists: struct {
 			Subdomains   string `yaml:"subdomains"`
 			Directories  string `yaml:"directories"`
 			Files        string `yaml:"files"`
 			Ports        string `yaml:"ports"`
+			Permutations string `yaml:"permutations"`
+			Recursive    string `yaml:"recursive"`
 		}{
-			Subdomains:  "wordlists/subdomains.txt",
-			Directories: "wordlists/directories.txt",
-			Files:       "wordlists/files.txt",
-			Ports:       "wordlists/ports.txt",
+			Subdomains:   "wordlists/subdomains.txt",
+			Directories:  "wordlists/directories.txt",
+			Files:        "wordlists/files.txt",
+			Ports:        "wordlists/ports.txt",
+			Permutations: "wordlists/permutations.txt",
+			Recursive:    "wordlists/recursive.txt",
 		},
 		Output: struct {
 			DefaultFormat string `yaml:"default_format"`
@@ -78,6 +139,30 @@ func DefaultConfig() *Config {
 			DefaultFormat: "json",
 			OutputDir:     "output",
 		},
+		Plugins: struct {
+			Directory string `yaml:"directory"`
+			Timeout   int    `yaml:"timeout"`
+		}{
+			Directory: "plugins",
+			Timeout:   600,
+		},
+		Takeover: struct {
+			Fingerprints string `yaml:"fingerprints"`
+		}{
+			Fingerprints: "fingerprints/takeover.yaml",
+		},
+		Services: struct {
+			Probes string `yaml:"probes"`
+		}{
+			Probes: "fingerprints/service-probes",
+		},
+		Sources: []SourceConfig{
+			{Name: "crtsh", URL: "https://crt.sh/?q=%25.{domain}&output=json"},
+			{Name: "hackertarget", URL: "https://api.hackertarget.com/hostsearch/?q={domain}"},
+			{Name: "alienvault", URL: "https://otx.alienvault.com/api/v1/indicators/domain/{domain}/passive_dns"},
+			{Name: "anubis", URL: "https://jldc.me/anubis/subdomains/{domain}"},
+			{Name: "wayback", URL: "https://web.archive.org/cdx/search/cdx?url=*.{domain}/*&output=txt&fl=original&collapse=urlkey"},
+		},
 	}
 }
 
@@ -105,7 +190,9 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 	
-	cfg := &Config{}
+	// Start from the defaults so settings missing from older config files
+	// keep sensible values
+	cfg := DefaultConfig()
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, err
 	}
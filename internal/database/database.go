@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -65,6 +66,25 @@ func (db *DB) createTables() error {
 			started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			completed_at DATETIME,
 			error_message TEXT,
+			job_id INTEGER,
+			options TEXT,
+			checkpoint TEXT,
+			FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+		)`,
+
+		// Jobs table, grouping the scans of a pipeline run
+		`CREATE TABLE IF NOT EXISTS jobs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			project_id INTEGER NOT NULL,
+			job_type TEXT NOT NULL,
+			name TEXT NOT NULL,
+			definition TEXT NOT NULL,
+			targets TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			results TEXT,
+			started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			completed_at DATETIME,
+			error_message TEXT,
 			FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
 		)`,
 		
@@ -104,6 +124,14 @@ func (db *DB) createTables() error {
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (scan_id) REFERENCES scans (id) ON DELETE CASCADE
 		)`,
+
+		// Engagement scope of each project
+		`CREATE TABLE IF NOT EXISTS scopes (
+			project_id INTEGER PRIMARY KEY,
+			definition TEXT NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+		)`,
 	}
 
 	for _, query := range queries {
@@ -112,9 +140,53 @@ func (db *DB) createTables() error {
 		}
 	}
 
+	// Columns added after their table was first released
+	columns := []struct {
+		table, column, definition string
+	}{
+		{"scans", "job_id", "INTEGER"},
+		{"scans", "options", "TEXT"},
+		{"scans", "checkpoint", "TEXT"},
+	}
+	for _, c := range columns {
+		if err := db.addColumn(c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// addColumn adds a column to a table created by an older version, unless
+// the table already has it
+func (db *DB) addColumn(table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
 // Project represents a reconnaissance project
 type Project struct {
 	ID          int    `json:"id"`
@@ -136,6 +208,10 @@ type Scan struct {
 	StartedAt    string `json:"started_at"`
 	CompletedAt  string `json:"completed_at"`
 	ErrorMessage string `json:"error_message"`
+	// Options the scan was started with, as JSON
+	Options string `json:"options"`
+	// Checkpoint of an unfinished scan's progress, as written by its module
+	Checkpoint string `json:"checkpoint,omitempty"`
 }
 
 // StoreEncryptedAPIKey stores an API key in encrypted form
@@ -195,9 +271,9 @@ func (db *DB) GetProjects() ([]*Project, error) {
 }
 
 // CreateScan creates a new scan record
-func (db *DB) CreateScan(projectID int, scanType, target string) (*Scan, error) {
-	query := `INSERT INTO scans (project_id, scan_type, target) VALUES (?, ?, ?)`
-	result, err := db.Exec(query, projectID, scanType, target)
+func (db *DB) CreateScan(projectID int, scanType, target, options string) (*Scan, error) {
+	query := `INSERT INTO scans (project_id, scan_type, target, options) VALUES (?, ?, ?, ?)`
+	result, err := db.Exec(query, projectID, scanType, target, options)
 	if err != nil {
 		return nil, err
 	}
@@ -213,14 +289,204 @@ func (db *DB) CreateScan(projectID int, scanType, target string) (*Scan, error)
 		ScanType:  scanType,
 		Target:    target,
 		Status:    "pending",
+		Options:   options,
 	}, nil
 }
 
 // UpdateScanStatus updates the status of a scan
 func (db *DB) UpdateScanStatus(scanID int, status string, results string, errorMessage string) error {
 	query := `UPDATE scans SET status = ?, results = ?, error_message = ?, 
-			  completed_at = CASE WHEN ? IN ('completed', 'failed') THEN CURRENT_TIMESTAMP ELSE completed_at END 
+			  completed_at = CASE WHEN ? IN ('completed', 'failed', 'cancelled', 'skipped') THEN CURRENT_TIMESTAMP ELSE completed_at END 
 			  WHERE id = ?`
 	_, err := db.Exec(query, status, results, errorMessage, status, scanID)
 	return err
 }
+
+// SetScanJob records that a scan was run as part of a job
+func (db *DB) SetScanJob(scanID, jobID int) error {
+	_, err := db.Exec(`UPDATE scans SET job_id = ? WHERE id = ?`, jobID, scanID)
+	return err
+}
+
+// SaveCheckpoint stores the progress of a running scan so that it can be
+// resumed after an interruption
+func (db *DB) SaveCheckpoint(scanID int, checkpoint string) error {
+	_, err := db.Exec(`UPDATE scans SET checkpoint = ? WHERE id = ?`, checkpoint, scanID)
+	return err
+}
+
+// scanColumns are the columns read into a Scan
+const scanColumns = `id, project_id, scan_type, status, target, COALESCE(results, ''), started_at,
+			  COALESCE(completed_at, ''), COALESCE(error_message, ''), COALESCE(options, ''), COALESCE(checkpoint, '')`
+
+// rowScanner is implemented by sql.Row and sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanScan reads a row of scanColumns
+func readScan(row rowScanner) (*Scan, error) {
+	s := &Scan{}
+	err := row.Scan(&s.ID, &s.ProjectID, &s.ScanType, &s.Status, &s.Target, &s.Results, &s.StartedAt,
+		&s.CompletedAt, &s.ErrorMessage, &s.Options, &s.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetScan returns a scan by ID
+func (db *DB) GetScan(scanID int) (*Scan, error) {
+	return readScan(db.QueryRow(`SELECT `+scanColumns+` FROM scans WHERE id = ?`, scanID))
+}
+
+// GetResumableScans returns the scans of a project that were interrupted
+// with a checkpoint to continue from, most recent first
+func (db *DB) GetResumableScans(projectID int) ([]*Scan, error) {
+	query := `SELECT ` + scanColumns + ` FROM scans
+			  WHERE project_id = ? AND status != 'completed' AND COALESCE(checkpoint, '') != ''
+			  ORDER BY id DESC`
+	rows, err := db.Query(query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scans []*Scan
+	for rows.Next() {
+		scan, err := readScan(rows)
+		if err != nil {
+			return nil, err
+		}
+		scans = append(scans, scan)
+	}
+	return scans, rows.Err()
+}
+
+// DeleteResults removes the stored findings of a scan, before the scan is
+// resumed and stores all of them again
+func (db *DB) DeleteResults(scanID int) error {
+	_, err := db.Exec(`DELETE FROM results WHERE scan_id = ?`, scanID)
+	return err
+}
+
+// Job represents a tracked multi-scan operation such as a pipeline run
+type Job struct {
+	ID           int    `json:"id"`
+	ProjectID    int    `json:"project_id"`
+	JobType      string `json:"job_type"`
+	Name         string `json:"name"`
+	Definition   string `json:"definition"`
+	Targets      string `json:"targets"`
+	Status       string `json:"status"`
+	Results      string `json:"results"`
+	StartedAt    string `json:"started_at"`
+	CompletedAt  string `json:"completed_at"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// CreateJob creates a new job record
+func (db *DB) CreateJob(projectID int, jobType, name, definition, targets string) (*Job, error) {
+	query := `INSERT INTO jobs (project_id, job_type, name, definition, targets) VALUES (?, ?, ?, ?, ?)`
+	result, err := db.Exec(query, projectID, jobType, name, definition, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Job{
+		ID:         int(id),
+		ProjectID:  projectID,
+		JobType:    jobType,
+		Name:       name,
+		Definition: definition,
+		Targets:    targets,
+		Status:     "pending",
+	}, nil
+}
+
+// UpdateJobStatus updates the status of a job
+func (db *DB) UpdateJobStatus(jobID int, status string, results string, errorMessage string) error {
+	query := `UPDATE jobs SET status = ?, results = ?, error_message = ?,
+			  completed_at = CASE WHEN ? IN ('completed', 'failed', 'cancelled') THEN CURRENT_TIMESTAMP ELSE completed_at END
+			  WHERE id = ?`
+	_, err := db.Exec(query, status, results, errorMessage, status, jobID)
+	return err
+}
+
+// GetJob returns a job by ID
+func (db *DB) GetJob(jobID int) (*Job, error) {
+	query := `SELECT id, project_id, job_type, name, definition, targets, status,
+			  COALESCE(results, ''), started_at, COALESCE(completed_at, ''), COALESCE(error_message, '')
+			  FROM jobs WHERE id = ?`
+	j := &Job{}
+	err := db.QueryRow(query, jobID).Scan(&j.ID, &j.ProjectID, &j.JobType, &j.Name, &j.Definition, &j.Targets,
+		&j.Status, &j.Results, &j.StartedAt, &j.CompletedAt, &j.ErrorMessage)
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Result represents a single structured finding of a scan
+type Result struct {
+	ID         int    `json:"id"`
+	ScanID     int    `json:"scan_id"`
+	ResultType string `json:"result_type"`
+	Data       string `json:"data"`
+	Metadata   string `json:"metadata"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// SaveResult stores a single finding of a scan
+func (db *DB) SaveResult(scanID int, resultType, data, metadata string) error {
+	query := `INSERT INTO results (scan_id, result_type, data, metadata) VALUES (?, ?, ?, ?)`
+	_, err := db.Exec(query, scanID, resultType, data, metadata)
+	return err
+}
+
+// GetResults returns the findings of a scan
+func (db *DB) GetResults(scanID int) ([]*Result, error) {
+	query := `SELECT id, scan_id, result_type, data, COALESCE(metadata, ''), created_at
+			  FROM results WHERE scan_id = ? ORDER BY id`
+	rows, err := db.Query(query, scanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*Result
+	for rows.Next() {
+		r := &Result{}
+		if err := rows.Scan(&r.ID, &r.ScanID, &r.ResultType, &r.Data, &r.Metadata, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+// SaveScope stores the scope definition of a project, replacing any
+// previous one
+func (db *DB) SaveScope(projectID int, definition string) error {
+	query := `INSERT OR REPLACE INTO scopes (project_id, definition, updated_at)
+			  VALUES (?, ?, CURRENT_TIMESTAMP)`
+	_, err := db.Exec(query, projectID, definition)
+	return err
+}
+
+// GetScope returns the scope definition of a project, or an empty string if
+// the project has none
+func (db *DB) GetScope(projectID int) (string, error) {
+	var definition string
+	err := db.QueryRow(`SELECT definition FROM scopes WHERE project_id = ?`, projectID).Scan(&definition)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return definition, err
+}
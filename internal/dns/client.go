@@ -0,0 +1,207 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// udpBufferSize is the largest UDP answer advertised with EDNS0. Larger
+// answers are truncated by the server and fetched again over TCP.
+const udpBufferSize = 1232
+
+// NewQuery builds a recursive query for name with a random ID
+func NewQuery(name string, qtype dnsmessage.Type) (dnsmessage.Message, error) {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return dnsmessage.Message{}, fmt.Errorf("invalid name %s: %v", name, err)
+	}
+
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(udpBufferSize, dnsmessage.RCodeSuccess, false); err != nil {
+		return dnsmessage.Message{}, err
+	}
+
+	return dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               uint16(rand.Intn(1 << 16)),
+			RecursionDesired: true,
+		},
+		Questions: []dnsmessage.Question{{
+			Name:  qname,
+			Type:  qtype,
+			Class: dnsmessage.ClassINET,
+		}},
+		Additionals: []dnsmessage.Resource{{
+			Header: opt,
+			Body:   &dnsmessage.OPTResource{},
+		}},
+	}, nil
+}
+
+// NewDNSSECQuery builds a query like NewQuery that also asks for the DNSSEC
+// records of the answer, such as the NSEC records proving that a name does
+// not exist
+func NewDNSSECQuery(name string, qtype dnsmessage.Type) (dnsmessage.Message, error) {
+	query, err := NewQuery(name, qtype)
+	if err != nil {
+		return query, err
+	}
+	err = query.Additionals[0].Header.SetEDNS0(udpBufferSize, dnsmessage.RCodeSuccess, true)
+	return query, err
+}
+
+// Exchange sends query to server, an address such as 8.8.8.8:53, and
+// returns its answer. Queries go over UDP and are repeated over TCP when
+// the answer is truncated. ctx bounds the whole exchange.
+func Exchange(ctx context.Context, server string, query dnsmessage.Message) (*dnsmessage.Message, error) {
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	answer, err := exchangeUDP(ctx, server, query, packed)
+	if err != nil {
+		return nil, err
+	}
+	if answer.Truncated {
+		return exchangeTCP(ctx, server, query, packed)
+	}
+	return answer, nil
+}
+
+// exchangeUDP sends a packed query over UDP. Datagrams that do not answer
+// the query, e.g. late answers to earlier queries or spoofed ones, are
+// ignored.
+func exchangeUDP(ctx context.Context, server string, query dnsmessage.Message, packed []byte) (*dnsmessage.Message, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := abortOnDone(ctx, conn)
+	defer stop()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(packed); err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	buffer := make([]byte, udpBufferSize)
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return nil, contextError(ctx, err)
+		}
+		var answer dnsmessage.Message
+		if err := answer.Unpack(buffer[:n]); err != nil {
+			continue
+		}
+		if answers(query, &answer) {
+			return &answer, nil
+		}
+	}
+}
+
+// exchangeTCP sends a packed query over TCP, prefixed with its length
+func exchangeTCP(ctx context.Context, server string, query dnsmessage.Message, packed []byte) (*dnsmessage.Message, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := abortOnDone(ctx, conn)
+	defer stop()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if err := writeTCPMessage(conn, packed); err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	answer, err := readTCPMessage(conn)
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	if !answers(query, answer) {
+		return nil, fmt.Errorf("mismatched answer from %s", server)
+	}
+	return answer, nil
+}
+
+// writeTCPMessage writes a packed message prefixed with its length to conn
+func writeTCPMessage(conn net.Conn, packed []byte) error {
+	message := make([]byte, 2+len(packed))
+	binary.BigEndian.PutUint16(message, uint16(len(packed)))
+	copy(message[2:], packed)
+	_, err := conn.Write(message)
+	return err
+}
+
+// readTCPMessage reads a message prefixed with its length from conn
+func readTCPMessage(conn net.Conn) (*dnsmessage.Message, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	buffer := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, buffer); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	var message dnsmessage.Message
+	if err := message.Unpack(buffer); err != nil {
+		return nil, fmt.Errorf("invalid answer: %v", err)
+	}
+	return &message, nil
+}
+
+// answers reports whether answer is the response to query
+func answers(query dnsmessage.Message, answer *dnsmessage.Message) bool {
+	if !answer.Response || answer.ID != query.ID || len(answer.Questions) != 1 {
+		return false
+	}
+	q, a := query.Questions[0], answer.Questions[0]
+	return q.Type == a.Type && q.Class == a.Class && strings.EqualFold(q.Name.String(), a.Name.String())
+}
+
+// abortOnDone aborts blocked reads and writes on conn when ctx is
+// cancelled. The returned function stops watching ctx.
+func abortOnDone(ctx context.Context, conn net.Conn) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// contextError prefers the context's error over the network error it caused
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
@@ -0,0 +1,245 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// handler answers a query received by a stand-in server, over TCP if tcp
+// is set. A nil answer is dropped.
+type handler func(query *dnsmessage.Message, tcp bool) *dnsmessage.Message
+
+// streamHandler answers a query with any number of messages, which are
+// sent one after another as zone transfers are
+type streamHandler func(query *dnsmessage.Message, tcp bool) []*dnsmessage.Message
+
+// standIn is a local DNS server listening on UDP and TCP on the same port
+type standIn struct {
+	addr       string
+	udpQueries atomic.Int32
+	tcpQueries atomic.Int32
+}
+
+// startStandIn starts a stand-in server answering with h until the test
+// ends
+func startStandIn(t *testing.T, h handler) *standIn {
+	t.Helper()
+	return startStreamingStandIn(t, func(query *dnsmessage.Message, tcp bool) []*dnsmessage.Message {
+		if answer := h(query, tcp); answer != nil {
+			return []*dnsmessage.Message{answer}
+		}
+		return nil
+	})
+}
+
+// startStreamingStandIn starts a stand-in server answering with h until
+// the test ends
+func startStreamingStandIn(t *testing.T, h streamHandler) *standIn {
+	t.Helper()
+
+	// The TCP port picked may be taken on UDP, so try a few
+	var udp net.PacketConn
+	var tcp net.Listener
+	for attempt := 0; attempt < 10 && udp == nil; attempt++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen tcp: %v", err)
+		}
+		pc, err := net.ListenPacket("udp", l.Addr().String())
+		if err != nil {
+			l.Close()
+			continue
+		}
+		tcp, udp = l, pc
+	}
+	if udp == nil {
+		t.Fatal("no port free on both UDP and TCP")
+	}
+	t.Cleanup(func() {
+		udp.Close()
+		tcp.Close()
+	})
+
+	s := &standIn{addr: tcp.Addr().String()}
+	go func() {
+		buffer := make([]byte, 65535)
+		for {
+			n, peer, err := udp.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			s.udpQueries.Add(1)
+			var query dnsmessage.Message
+			if query.Unpack(buffer[:n]) != nil {
+				continue
+			}
+			for _, answer := range h(&query, false) {
+				packed, err := answer.Pack()
+				if err != nil {
+					t.Errorf("pack answer: %v", err)
+					break
+				}
+				udp.WriteTo(packed, peer)
+			}
+		}
+	}()
+	go func() {
+		for {
+			conn, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				query, err := readTCPMessage(conn)
+				if err != nil {
+					return
+				}
+				s.tcpQueries.Add(1)
+				for _, answer := range h(query, true) {
+					packed, err := answer.Pack()
+					if err != nil {
+						t.Errorf("pack answer: %v", err)
+						return
+					}
+					if writeTCPMessage(conn, packed) != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return s
+}
+
+// reply builds the answer to query with rcode and A records for addresses
+func reply(query *dnsmessage.Message, rcode dnsmessage.RCode, addresses ...string) *dnsmessage.Message {
+	answer := &dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 query.ID,
+			Response:           true,
+			RecursionAvailable: true,
+			RCode:              rcode,
+		},
+		Questions: query.Questions,
+	}
+	for _, address := range addresses {
+		var a [4]byte
+		copy(a[:], net.ParseIP(address).To4())
+		answer.Answers = append(answer.Answers, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{
+				Name:  query.Questions[0].Name,
+				Type:  dnsmessage.TypeA,
+				Class: dnsmessage.ClassINET,
+				TTL:   60,
+			},
+			Body: &dnsmessage.AResource{A: a},
+		})
+	}
+	return answer
+}
+
+// addressesOf returns the A records of an answer
+func addressesOf(answer *dnsmessage.Message) []string {
+	var addresses []string
+	for _, rr := range answer.Answers {
+		if a, ok := rr.Body.(*dnsmessage.AResource); ok {
+			addresses = append(addresses, net.IP(a.A[:]).String())
+		}
+	}
+	return addresses
+}
+
+// isTimeout reports whether err is the context's deadline or the
+// connection deadline set from it, whichever was noticed first
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func exchange(t *testing.T, server, name string) (*dnsmessage.Message, error) {
+	t.Helper()
+	query, err := NewQuery(name, dnsmessage.TypeA)
+	if err != nil {
+		t.Fatalf("NewQuery: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return Exchange(ctx, server, query)
+}
+
+func TestExchangeUDP(t *testing.T) {
+	server := startStandIn(t, func(query *dnsmessage.Message, tcp bool) *dnsmessage.Message {
+		return reply(query, dnsmessage.RCodeSuccess, "192.0.2.1")
+	})
+
+	answer, err := exchange(t, server.addr, "www.example.com")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if got := addressesOf(answer); len(got) != 1 || got[0] != "192.0.2.1" {
+		t.Errorf("addresses = %v, want [192.0.2.1]", got)
+	}
+	if server.tcpQueries.Load() != 0 {
+		t.Errorf("sent %d queries over TCP, want none", server.tcpQueries.Load())
+	}
+}
+
+func TestExchangeFallsBackToTCP(t *testing.T) {
+	server := startStandIn(t, func(query *dnsmessage.Message, tcp bool) *dnsmessage.Message {
+		if !tcp {
+			answer := reply(query, dnsmessage.RCodeSuccess)
+			answer.Truncated = true
+			return answer
+		}
+		return reply(query, dnsmessage.RCodeSuccess, "192.0.2.1", "192.0.2.2")
+	})
+
+	answer, err := exchange(t, server.addr, "big.example.com")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if answer.Truncated {
+		t.Error("answer is still truncated")
+	}
+	if got := addressesOf(answer); len(got) != 2 {
+		t.Errorf("addresses = %v, want the 2 sent over TCP", got)
+	}
+	if server.udpQueries.Load() != 1 || server.tcpQueries.Load() != 1 {
+		t.Errorf("queries: %d over UDP, %d over TCP, want 1 each", server.udpQueries.Load(), server.tcpQueries.Load())
+	}
+}
+
+func TestExchangeIgnoresMismatchedAnswers(t *testing.T) {
+	server := startStandIn(t, func(query *dnsmessage.Message, tcp bool) *dnsmessage.Message {
+		answer := reply(query, dnsmessage.RCodeSuccess, "192.0.2.66")
+		answer.ID++
+		return answer
+	})
+
+	_, err := exchange(t, server.addr, "www.example.com")
+	if !isTimeout(err) {
+		t.Errorf("err = %v, want the deadline to pass without an answer", err)
+	}
+}
+
+func TestExchangeTimeout(t *testing.T) {
+	server := startStandIn(t, func(query *dnsmessage.Message, tcp bool) *dnsmessage.Message {
+		return nil
+	})
+
+	start := time.Now()
+	_, err := exchange(t, server.addr, "www.example.com")
+	if !isTimeout(err) {
+		t.Errorf("err = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("took %v, want about the 1s deadline", elapsed)
+	}
+}
@@ -0,0 +1,317 @@
+package dns
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNSSEC record types, which dnsmessage does not define
+const (
+	TypeDS         dnsmessage.Type = 43
+	TypeRRSIG      dnsmessage.Type = 46
+	TypeNSEC       dnsmessage.Type = 47
+	TypeDNSKEY     dnsmessage.Type = 48
+	TypeNSEC3      dnsmessage.Type = 50
+	TypeNSEC3PARAM dnsmessage.Type = 51
+)
+
+// NSEC3SHA1 is the only NSEC3 hash algorithm defined
+const NSEC3SHA1 = 1
+
+// base32Hex encodes NSEC3 hashes as they appear in owner names
+var base32Hex = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// NSEC proves that no name exists between its owner and the next name of
+// the zone in canonical order, and lists the types its owner has. Names
+// are lower case and without a trailing dot.
+type NSEC struct {
+	Owner string
+	Next  string
+	Types []string
+}
+
+// Covers reports whether name falls between the owner and the next name
+func (n *NSEC) Covers(name string) bool {
+	if CompareNames(n.Owner, name) >= 0 {
+		return false
+	}
+	// The last record of a zone points back at its apex
+	return CompareNames(name, n.Next) < 0 || CompareNames(n.Next, n.Owner) <= 0
+}
+
+// String formats the record data, e.g. "www.example.com A AAAA RRSIG NSEC"
+func (n *NSEC) String() string {
+	return strings.TrimSpace(n.Next + " " + strings.Join(n.Types, " "))
+}
+
+// NSEC3 is the hashed form of NSEC: it proves that no name whose hash lies
+// between its own hash and the next one exists
+type NSEC3 struct {
+	// Owner is the full owner name, the hash followed by the zone
+	Owner string
+	// Hash and Next are base32hex encoded in lower case, as in owner names
+	Hash       string
+	Next       string
+	Algorithm  uint8
+	OptOut     bool
+	Iterations uint16
+	Salt       []byte
+	Types      []string
+}
+
+// Zone returns the zone the record belongs to
+func (n *NSEC3) Zone() string {
+	_, zone, _ := strings.Cut(n.Owner, ".")
+	return zone
+}
+
+// SameParameters reports whether both records hash names the same way
+func (n *NSEC3) SameParameters(other *NSEC3) bool {
+	return n.Algorithm == other.Algorithm && n.Iterations == other.Iterations && string(n.Salt) == string(other.Salt)
+}
+
+// HashName hashes name with the record's parameters
+func (n *NSEC3) HashName(name string) string {
+	return HashName(name, n.Salt, n.Iterations)
+}
+
+// Covers reports whether a hash falls between the record's hash and the
+// next one
+func (n *NSEC3) Covers(hash string) bool {
+	// The last record of a zone wraps around to the first hash
+	if n.Next <= n.Hash {
+		return hash > n.Hash || hash < n.Next
+	}
+	return hash > n.Hash && hash < n.Next
+}
+
+// String formats the record data like a zone file, e.g.
+// "1 0 10 aabbccdd 2vptu5timamqttgl4luu9kg21e0aor3s A RRSIG"
+func (n *NSEC3) String() string {
+	salt := "-"
+	if len(n.Salt) > 0 {
+		salt = hex.EncodeToString(n.Salt)
+	}
+	flags := 0
+	if n.OptOut {
+		flags = 1
+	}
+	value := fmt.Sprintf("%d %d %d %s %s", n.Algorithm, flags, n.Iterations, salt, n.Next)
+	return strings.TrimSpace(value + " " + strings.Join(n.Types, " "))
+}
+
+// HashName returns the NSEC3 hash of name: SHA-1 over the name in
+// canonical wire form and the salt, repeated over the hash and the salt
+// iterations more times, base32hex encoded in lower case
+func HashName(name string, salt []byte, iterations uint16) string {
+	wire := wireName(strings.ToLower(strings.TrimSuffix(name, ".")))
+	h := sha1.New()
+	h.Write(wire)
+	h.Write(salt)
+	digest := h.Sum(nil)
+	for i := 0; i < int(iterations); i++ {
+		h.Reset()
+		h.Write(digest)
+		h.Write(salt)
+		digest = h.Sum(digest[:0])
+	}
+	return strings.ToLower(base32Hex.EncodeToString(digest))
+}
+
+// wireName returns name in uncompressed wire form
+func wireName(name string) []byte {
+	var wire []byte
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			wire = append(wire, byte(len(label)))
+			wire = append(wire, label...)
+		}
+	}
+	return append(wire, 0)
+}
+
+// CompareNames orders names canonically, comparing their labels from the
+// right as lower case bytes, so example.com < a.example.com < b.example.com
+// < a.b.example.com. It returns -1, 0 or 1.
+func CompareNames(a, b string) int {
+	la := splitLabels(a)
+	lb := splitLabels(b)
+	for i := 1; i <= len(la) && i <= len(lb); i++ {
+		if c := strings.Compare(la[len(la)-i], lb[len(lb)-i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(la) < len(lb):
+		return -1
+	case len(la) > len(lb):
+		return 1
+	}
+	return 0
+}
+
+// splitLabels returns the lower case labels of name
+func splitLabels(name string) []string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if name == "" {
+		return nil
+	}
+	return strings.Split(name, ".")
+}
+
+// Denial sends a DNSSEC query for name and returns the NSEC and NSEC3
+// records in its answer and authority sections, which prove what does not
+// exist in the zone
+func (p *Pool) Denial(ctx context.Context, name string, qtype dnsmessage.Type) ([]*NSEC, []*NSEC3, error) {
+	query, err := NewDNSSECQuery(name, qtype)
+	if err != nil {
+		return nil, nil, err
+	}
+	answer, err := p.ExchangeQuery(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var nsecs []*NSEC
+	var nsec3s []*NSEC3
+	for _, rr := range append(answer.Answers, answer.Authorities...) {
+		body, ok := rr.Body.(*dnsmessage.UnknownResource)
+		if !ok {
+			continue
+		}
+		owner := strings.ToLower(trimName(rr.Header.Name))
+		switch rr.Header.Type {
+		case TypeNSEC:
+			if nsec, err := parseNSEC(owner, body.Data); err == nil {
+				nsecs = append(nsecs, nsec)
+			}
+		case TypeNSEC3:
+			if nsec3, err := parseNSEC3(owner, body.Data); err == nil {
+				nsec3s = append(nsec3s, nsec3)
+			}
+		}
+	}
+	return nsecs, nsec3s, nil
+}
+
+// NextNSEC returns the NSEC record of name, whose next name follows name in
+// its zone. For names without a record of their own, such as empty
+// non-terminals, it returns the record covering the first name that could
+// exist below name.
+func (p *Pool) NextNSEC(ctx context.Context, name string) (*NSEC, error) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	nsecs, _, err := p.Denial(ctx, name, TypeNSEC)
+	if err != nil {
+		return nil, err
+	}
+	for _, nsec := range nsecs {
+		if nsec.Owner == name {
+			return nsec, nil
+		}
+	}
+
+	// \000 is the first label in canonical order
+	probe := "\x00." + name
+	nsecs, _, err = p.Denial(ctx, probe, dnsmessage.TypeA)
+	if err != nil {
+		return nil, err
+	}
+	for _, nsec := range nsecs {
+		if nsec.Owner == name || nsec.Covers(probe) {
+			return nsec, nil
+		}
+	}
+	return nil, fmt.Errorf("no NSEC record for %s", name)
+}
+
+// parseNSEC parses NSEC record data
+func parseNSEC(owner string, data []byte) (*NSEC, error) {
+	next, n, err := readWireName(data)
+	if err != nil {
+		return nil, err
+	}
+	types, err := parseTypeBitmap(data[n:])
+	if err != nil {
+		return nil, err
+	}
+	return &NSEC{Owner: owner, Next: strings.ToLower(next), Types: types}, nil
+}
+
+// parseNSEC3 parses NSEC3 record data
+func parseNSEC3(owner string, data []byte) (*NSEC3, error) {
+	if len(data) < 5 {
+		return nil, fmt.Errorf("short NSEC3 record")
+	}
+	record := &NSEC3{
+		Owner:      owner,
+		Algorithm:  data[0],
+		OptOut:     data[1]&1 == 1,
+		Iterations: binary.BigEndian.Uint16(data[2:4]),
+	}
+	hash, _, _ := strings.Cut(owner, ".")
+	record.Hash = hash
+
+	saltEnd := 5 + int(data[4])
+	if len(data) < saltEnd+1 {
+		return nil, fmt.Errorf("short NSEC3 record")
+	}
+	record.Salt = append([]byte(nil), data[5:saltEnd]...)
+	hashEnd := saltEnd + 1 + int(data[saltEnd])
+	if len(data) < hashEnd {
+		return nil, fmt.Errorf("short NSEC3 record")
+	}
+	record.Next = strings.ToLower(base32Hex.EncodeToString(data[saltEnd+1 : hashEnd]))
+
+	types, err := parseTypeBitmap(data[hashEnd:])
+	if err != nil {
+		return nil, err
+	}
+	record.Types = types
+	return record, nil
+}
+
+// readWireName reads an uncompressed name in wire form and returns it with
+// the number of bytes it took
+func readWireName(data []byte) (string, int, error) {
+	var labels []string
+	for offset := 0; offset < len(data); {
+		length := int(data[offset])
+		offset++
+		if length == 0 {
+			return strings.Join(labels, "."), offset, nil
+		}
+		if length > 63 || offset+length > len(data) {
+			return "", 0, fmt.Errorf("invalid name")
+		}
+		labels = append(labels, string(data[offset:offset+length]))
+		offset += length
+	}
+	return "", 0, fmt.Errorf("unterminated name")
+}
+
+// parseTypeBitmap returns the names of the types in an NSEC type bitmap
+func parseTypeBitmap(data []byte) ([]string, error) {
+	var types []string
+	for len(data) > 0 {
+		if len(data) < 2 || int(data[1]) > 32 || len(data) < 2+int(data[1]) {
+			return nil, fmt.Errorf("invalid type bitmap")
+		}
+		window, bitmap := int(data[0]), data[2:2+data[1]]
+		for i, bits := range bitmap {
+			for bit := 0; bit < 8; bit++ {
+				if bits&(0x80>>bit) != 0 {
+					types = append(types, TypeName(dnsmessage.Type(window*256+i*8+bit)))
+				}
+			}
+		}
+		data = data[2+len(bitmap):]
+	}
+	return types, nil
+}
@@ -0,0 +1,264 @@
+package dns
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestHashName(t *testing.T) {
+	// RFC 5155 Appendix A, hashed with salt aabbccdd and 12 iterations
+	salt := []byte{0xaa, 0xbb, 0xcc, 0xdd}
+	tests := map[string]string{
+		"example":        "0p9mhaveqvm6t7vbl5lop2u3t2rp3tom",
+		"a.example":      "35mthgpgcu1qg68fab165klnsnk3dpvl",
+		"ai.example":     "gjeqe526plbf1g8mklp59enfd789njgi",
+		"ns1.example":    "2t7b4g4vsa5smi47k61mv5bv1a22bojr",
+		"ns2.example":    "q04jkcevqvmu85r014c7dkba38o0ji5r",
+		"w.example":      "k8udemvp1j2f7eg6jebps17vp3n8i58h",
+		"*.w.example":    "r53bq7cc2uvmubfu5ocmm6pers9tk9en",
+		"x.w.example":    "b4um86eghhds6nea196smvmlo4ors995",
+		"y.w.example":    "ji6neoaepv8b5o6k4ev33abha8ht9fgc",
+		"x.y.w.example":  "2vptu5timamqttgl4luu9kg21e0aor3s",
+		"xx.example":     "t644ebqk9bibcna874givr6joj62mlhv",
+		"X.Y.W.Example.": "2vptu5timamqttgl4luu9kg21e0aor3s",
+	}
+	for name, want := range tests {
+		if got := HashName(name, salt, 12); got != want {
+			t.Errorf("HashName(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestCompareNames(t *testing.T) {
+	// RFC 4034 section 6.1, in canonical order
+	ordered := []string{
+		"example",
+		"a.example",
+		"yljkjljk.a.example",
+		"Z.a.example",
+		"zABC.a.EXAMPLE",
+		"z.example",
+		"\x01.z.example",
+		"*.z.example",
+		"\x80.z.example",
+	}
+	for i, a := range ordered {
+		for j, b := range ordered {
+			want := 0
+			switch {
+			case i < j:
+				want = -1
+			case i > j:
+				want = 1
+			}
+			if got := CompareNames(a, b); got != want {
+				t.Errorf("CompareNames(%q, %q) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+	if got := CompareNames("Example.", "example"); got != 0 {
+		t.Errorf("CompareNames ignoring case and the trailing dot = %d, want 0", got)
+	}
+}
+
+func TestNSECCovers(t *testing.T) {
+	middle := &NSEC{Owner: "a.example.com", Next: "m.example.com"}
+	last := &NSEC{Owner: "x.example.com", Next: "example.com"}
+	tests := []struct {
+		record *NSEC
+		name   string
+		want   bool
+	}{
+		{middle, "b.example.com", true},
+		{middle, "z.a.example.com", true},
+		{middle, "a.example.com", false},
+		{middle, "m.example.com", false},
+		{middle, "n.example.com", false},
+		{last, "y.example.com", true},
+		{last, "a.x.example.com", true},
+		{last, "b.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := tt.record.Covers(tt.name); got != tt.want {
+			t.Errorf("%s -> %s covers %s = %v, want %v", tt.record.Owner, tt.record.Next, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNSEC3Covers(t *testing.T) {
+	middle := &NSEC3{Hash: "2vptu5timamqttgl4luu9kg21e0aor3s", Next: "35mthgpgcu1qg68fab165klnsnk3dpvl"}
+	last := &NSEC3{Hash: "t644ebqk9bibcna874givr6joj62mlhv", Next: "0p9mhaveqvm6t7vbl5lop2u3t2rp3tom"}
+	only := &NSEC3{Hash: "k8udemvp1j2f7eg6jebps17vp3n8i58h", Next: "k8udemvp1j2f7eg6jebps17vp3n8i58h"}
+	tests := []struct {
+		record *NSEC3
+		hash   string
+		want   bool
+	}{
+		{middle, "30000000000000000000000000000000", true},
+		{middle, middle.Hash, false},
+		{middle, middle.Next, false},
+		{middle, "40000000000000000000000000000000", false},
+		{last, "v0000000000000000000000000000000", true},
+		{last, "00000000000000000000000000000000", true},
+		{last, last.Next, false},
+		{last, "k8udemvp1j2f7eg6jebps17vp3n8i58h", false},
+		{only, "00000000000000000000000000000000", true},
+		{only, only.Hash, false},
+	}
+	for _, tt := range tests {
+		if got := tt.record.Covers(tt.hash); got != tt.want {
+			t.Errorf("%s -> %s covers %s = %v, want %v", tt.record.Hash, tt.record.Next, tt.hash, got, tt.want)
+		}
+	}
+}
+
+func TestParseTypeBitmap(t *testing.T) {
+	tests := []struct {
+		name    string
+		bitmap  []byte
+		want    []string
+		wantErr bool
+	}{
+		{
+			// RFC 4034 section 4.3
+			name: "two windows",
+			bitmap: append([]byte{0x00, 0x06, 0x40, 0x01, 0x00, 0x00, 0x00, 0x03, 0x04, 0x1b},
+				append(make([]byte, 26), 0x20)...),
+			want: []string{"A", "MX", "RRSIG", "NSEC", "TYPE1234"},
+		},
+		{name: "empty", bitmap: nil},
+		{name: "missing length", bitmap: []byte{0x00}, wantErr: true},
+		{name: "window too long", bitmap: append([]byte{0x00, 33}, make([]byte, 33)...), wantErr: true},
+		{name: "truncated window", bitmap: []byte{0x00, 0x06, 0x40}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTypeBitmap(tt.bitmap)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parsed %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTypeBitmap: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("types = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// aRRSIGBitmap is the type bitmap of A RRSIG
+var aRRSIGBitmap = []byte{0x00, 0x06, 0x40, 0x00, 0x00, 0x00, 0x00, 0x02}
+
+func TestParseNSEC3(t *testing.T) {
+	// The record of x.y.w.example in RFC 5155 Appendix A
+	next, err := base32Hex.DecodeString(strings.ToUpper("35mthgpgcu1qg68fab165klnsnk3dpvl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte{NSEC3SHA1, 1, 0, 12, 4, 0xaa, 0xbb, 0xcc, 0xdd, byte(len(next))}
+	data = append(data, next...)
+	data = append(data, aRRSIGBitmap...)
+
+	record, err := parseNSEC3("2vptu5timamqttgl4luu9kg21e0aor3s.example", data)
+	if err != nil {
+		t.Fatalf("parseNSEC3: %v", err)
+	}
+	want := &NSEC3{
+		Owner:      "2vptu5timamqttgl4luu9kg21e0aor3s.example",
+		Hash:       "2vptu5timamqttgl4luu9kg21e0aor3s",
+		Next:       "35mthgpgcu1qg68fab165klnsnk3dpvl",
+		Algorithm:  NSEC3SHA1,
+		OptOut:     true,
+		Iterations: 12,
+		Salt:       []byte{0xaa, 0xbb, 0xcc, 0xdd},
+		Types:      []string{"A", "RRSIG"},
+	}
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("record = %+v, want %+v", record, want)
+	}
+	if record.Zone() != "example" {
+		t.Errorf("zone = %q, want example", record.Zone())
+	}
+	if got := record.HashName("x.y.w.example"); got != record.Hash {
+		t.Errorf("HashName with the record's parameters = %s, want %s", got, record.Hash)
+	}
+	if !record.Covers("30000000000000000000000000000000") || record.Covers("40000000000000000000000000000000") {
+		t.Error("record covers the wrong hashes")
+	}
+
+	// Every cut short of the full data is rejected
+	for _, end := range []int{4, 8, 9, 20} {
+		if _, err := parseNSEC3(record.Owner, data[:end]); err == nil {
+			t.Errorf("parsed %d bytes of %d, want an error", end, len(data))
+		}
+	}
+}
+
+// signedZone answers from the NSEC chain of a zone: a query for a name
+// with a record of its own gets that record, other names the record
+// covering them in an NXDOMAIN answer
+func signedZone(chain []*NSEC) handler {
+	return func(query *dnsmessage.Message, tcp bool) *dnsmessage.Message {
+		question := query.Questions[0]
+		name := strings.TrimSuffix(question.Name.String(), ".")
+		for _, record := range chain {
+			if record.Owner == name || record.Covers(name) {
+				rr := dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(record.Owner + "."), Type: TypeNSEC, Class: dnsmessage.ClassINET, TTL: 60},
+					Body:   &dnsmessage.UnknownResource{Type: TypeNSEC, Data: append(wireName(record.Next), aRRSIGBitmap...)},
+				}
+				if record.Owner == name {
+					answer := reply(query, dnsmessage.RCodeSuccess)
+					answer.Answers = append(answer.Answers, rr)
+					return answer
+				}
+				answer := reply(query, dnsmessage.RCodeNameError)
+				answer.Authorities = append(answer.Authorities, rr)
+				return answer
+			}
+		}
+		return reply(query, dnsmessage.RCodeNameError)
+	}
+}
+
+func TestNextNSEC(t *testing.T) {
+	server := startStandIn(t, signedZone([]*NSEC{
+		{Owner: "example.com", Next: "a.example.com"},
+		{Owner: "a.example.com", Next: "x.b.example.com"},
+		{Owner: "x.b.example.com", Next: "example.com"},
+	}))
+	pool := newTestPool(t, 0, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	record, err := pool.NextNSEC(ctx, "A.example.com.")
+	if err != nil {
+		t.Fatalf("NextNSEC: %v", err)
+	}
+	if record.Owner != "a.example.com" || record.Next != "x.b.example.com" || !reflect.DeepEqual(record.Types, []string{"A", "RRSIG"}) {
+		t.Errorf("record = %+v, want the one of a.example.com", record)
+	}
+
+	// b.example.com only exists as the parent of x.b.example.com, so the
+	// record covering the first name below it leads on
+	record, err = pool.NextNSEC(ctx, "b.example.com")
+	if err != nil {
+		t.Fatalf("NextNSEC of an empty non-terminal: %v", err)
+	}
+	if record.Next != "x.b.example.com" {
+		t.Errorf("record = %+v, want one leading to x.b.example.com", record)
+	}
+	if got := server.udpQueries.Load(); got != 3 {
+		t.Errorf("sent %d queries, want 3", got)
+	}
+}
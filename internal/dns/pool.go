@@ -0,0 +1,379 @@
+package dns
+
+import (
+	"GoReconX/internal/engine"
+	"GoReconX/internal/ratelimit"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// DefaultTimeout bounds a single query sent to one resolver
+	DefaultTimeout = 2 * time.Second
+
+	// maxFailures is the number of failed queries in a row after which a
+	// resolver is evicted from the pool
+	maxFailures = 5
+
+	// evictionCooldown is how long a resolver evicted for failing stays out
+	// of the pool before it is tried again
+	evictionCooldown = 30 * time.Second
+)
+
+// ErrNoResolvers is returned when every resolver of a pool is evicted
+var ErrNoResolvers = errors.New("no usable DNS resolvers")
+
+// Pool spreads DNS queries round-robin over a set of resolvers. Failed
+// queries are retried on the next resolver. Resolvers that stop answering
+// or keep failing are evicted for evictionCooldown, those that answer for
+// names that do not exist for good. The last resolver in use is never
+// evicted.
+type Pool struct {
+	// Timeout bounds each query sent to a single resolver
+	Timeout time.Duration
+	// Retries is the number of further resolvers a failed query is sent to
+	Retries int
+
+	logger    *logrus.Logger
+	resolvers []*resolver
+	next      atomic.Uint64
+	// evicting serializes evictions, so that two at once cannot leave the
+	// pool empty
+	evicting sync.Mutex
+	// checked is closed once the health check is done, nil if the pool is
+	// not checked
+	checked chan struct{}
+}
+
+// resolver is a member of a pool
+type resolver struct {
+	address  string
+	failures atomic.Int32
+	// evictedUntil is the time in Unix nanoseconds when an evicted resolver
+	// is tried again: 0 while in use, evictedForGood if never
+	evictedUntil atomic.Int64
+}
+
+const evictedForGood = math.MaxInt64
+
+// usable reports whether r is in use, or its eviction ended before now
+func (r *resolver) usable(now int64) bool {
+	return now >= r.evictedUntil.Load()
+}
+
+type contextKey struct{}
+
+// NewContext returns a context whose DNS lookups go through pool
+func NewContext(ctx context.Context, pool *Pool) context.Context {
+	return context.WithValue(ctx, contextKey{}, pool)
+}
+
+// FromContext returns the pool to resolve names with, or nil if lookups
+// should go through the system resolver
+func FromContext(ctx context.Context) *Pool {
+	pool, _ := ctx.Value(contextKey{}).(*Pool)
+	return pool
+}
+
+// NewPool creates a pool of the resolvers at servers, given as IP addresses
+// with an optional port, e.g. "8.8.8.8" or "127.0.0.1:5353"
+func NewPool(servers []string, retries int, logger *logrus.Logger) (*Pool, error) {
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no DNS resolvers given")
+	}
+
+	p := &Pool{
+		Timeout: DefaultTimeout,
+		Retries: retries,
+		logger:  logger,
+	}
+	seen := make(map[string]bool)
+	for _, server := range servers {
+		address, err := resolverAddress(server)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[address] {
+			seen[address] = true
+			p.resolvers = append(p.resolvers, &resolver{address: address})
+		}
+	}
+	return p, nil
+}
+
+// LoadPool creates a pool of the resolvers listed in a file, one per line,
+// and starts its health check
+func LoadPool(path string, retries int, logger *logrus.Logger) (*Pool, error) {
+	var servers []string
+	err := engine.Lines(path)(context.Background(), func(line string) bool {
+		servers = append(servers, line)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resolvers: %v", err)
+	}
+	p, err := NewPool(servers, retries, logger)
+	if err != nil {
+		return nil, err
+	}
+	p.startHealthCheck()
+	return p, nil
+}
+
+// resolverAddress adds the default port to a resolver's address
+func resolverAddress(server string) (string, error) {
+	if ip := net.ParseIP(server); ip != nil {
+		return net.JoinHostPort(ip.String(), "53"), nil
+	}
+	host, port, err := net.SplitHostPort(server)
+	if err != nil || net.ParseIP(host) == nil {
+		return "", fmt.Errorf("invalid resolver address %s", server)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// Resolvers returns the addresses of the resolvers that are not evicted
+func (p *Pool) Resolvers() []string {
+	now := time.Now().UnixNano()
+	var addresses []string
+	for _, r := range p.resolvers {
+		if r.usable(now) {
+			addresses = append(addresses, r.address)
+		}
+	}
+	return addresses
+}
+
+// startHealthCheck runs the health check in the background, on a context
+// of its own so that no query's deadline cuts it short. Queries wait for
+// it to finish.
+func (p *Pool) startHealthCheck() {
+	p.checked = make(chan struct{})
+	go func() {
+		defer close(p.checked)
+		// Each resolver gets two attempts
+		ctx, cancel := context.WithTimeout(context.Background(), 2*p.Timeout+time.Second)
+		defer cancel()
+		p.HealthCheck(ctx)
+	}()
+}
+
+// HealthCheck queries every resolver for a random name that does not
+// exist. Those that return addresses for it, as resolvers that hijack
+// failed lookups do, are evicted for good; those that do not answer or
+// refuse are evicted until the cooldown ends.
+func (p *Pool) HealthCheck(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, r := range p.resolvers {
+		wg.Add(1)
+		go func(r *resolver) {
+			defer wg.Done()
+			if reason, lying := p.check(ctx, r); reason != "" && ctx.Err() == nil {
+				p.evict(r, reason, lying)
+			}
+		}(r)
+	}
+	wg.Wait()
+
+	healthy := p.Resolvers()
+	p.logger.WithFields(logrus.Fields{
+		"healthy": len(healthy),
+		"evicted": len(p.resolvers) - len(healthy),
+	}).Info("Checked DNS resolvers")
+}
+
+// check returns why r is unusable, or an empty string if it is healthy,
+// and whether it lies about names that do not exist
+func (p *Pool) check(ctx context.Context, r *resolver) (string, bool) {
+	query, err := NewQuery(randomName(), dnsmessage.TypeA)
+	if err != nil {
+		return err.Error(), false
+	}
+
+	// Give slow resolvers a second chance before evicting them
+	var answer *dnsmessage.Message
+	for attempt := 0; attempt < 2; attempt++ {
+		queryCtx, cancel := context.WithTimeout(ctx, p.Timeout)
+		answer, err = Exchange(queryCtx, r.address, query)
+		cancel()
+		if err == nil {
+			break
+		}
+	}
+
+	switch {
+	case err != nil:
+		return fmt.Sprintf("not answering: %v", err), false
+	case answer.RCode == dnsmessage.RCodeRefused || answer.RCode == dnsmessage.RCodeServerFailure:
+		return fmt.Sprintf("answered %v", answer.RCode), false
+	case answer.RCode == dnsmessage.RCodeSuccess && len(answer.Answers) > 0:
+		return "answers for names that do not exist", true
+	}
+	return "", false
+}
+
+// Exchange sends a query for name to the next resolver, retrying on other
+// resolvers when it fails. Answers with any code but a server failure or
+// refusal are returned, including NXDOMAIN.
+func (p *Pool) Exchange(ctx context.Context, name string, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	query, err := NewQuery(name, qtype)
+	if err != nil {
+		return nil, err
+	}
+	return p.ExchangeQuery(ctx, query)
+}
+
+// ExchangeQuery sends a query built by the caller like Exchange does. Its
+// ID is replaced for every attempt.
+func (p *Pool) ExchangeQuery(ctx context.Context, query dnsmessage.Message) (*dnsmessage.Message, error) {
+	if p.checked != nil {
+		select {
+		case <-p.checked:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	limiter := ratelimit.FromContext(ctx)
+
+	lastErr := ErrNoResolvers
+	for attempt := 0; attempt <= p.Retries; attempt++ {
+		r := p.pick()
+		if r == nil {
+			break
+		}
+		if err := limiter.WaitResolver(ctx, r.address); err != nil {
+			return nil, err
+		}
+
+		query.ID = uint16(rand.Intn(1 << 16))
+		queryCtx, cancel := context.WithTimeout(ctx, p.Timeout)
+		answer, err := Exchange(queryCtx, r.address, query)
+		cancel()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if err == nil && answer.RCode != dnsmessage.RCodeServerFailure && answer.RCode != dnsmessage.RCodeRefused {
+			r.failures.Store(0)
+			return answer, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("%s answered %v", r.address, answer.RCode)
+		}
+		lastErr = err
+		if r.failures.Add(1) >= maxFailures {
+			p.evict(r, fmt.Sprintf("%d failed queries in a row", maxFailures), false)
+		}
+	}
+	return nil, lastErr
+}
+
+// LookupIPAddr returns the IPv4 and IPv6 addresses of name
+func (p *Pool) LookupIPAddr(ctx context.Context, name string) ([]net.IPAddr, error) {
+	var addrs []net.IPAddr
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		answer, err := p.Exchange(ctx, name, qtype)
+		if errors.Is(err, ErrNoResolvers) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, &net.DNSError{Err: err.Error(), Name: name, IsTimeout: ctx.Err() == context.DeadlineExceeded}
+		}
+		if answer.RCode == dnsmessage.RCodeNameError {
+			break
+		}
+
+		for _, rr := range answer.Answers {
+			switch body := rr.Body.(type) {
+			case *dnsmessage.AResource:
+				ip := body.A
+				addrs = append(addrs, net.IPAddr{IP: net.IP(ip[:])})
+			case *dnsmessage.AAAAResource:
+				ip := body.AAAA
+				addrs = append(addrs, net.IPAddr{IP: net.IP(ip[:])})
+			}
+		}
+	}
+
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return addrs, nil
+}
+
+// pick returns the next resolver in use, or nil if none is left. A
+// resolver whose cooldown has ended is put back on probation: a single
+// further failure evicts it again.
+func (p *Pool) pick() *resolver {
+	now := time.Now().UnixNano()
+	for range p.resolvers {
+		r := p.resolvers[(p.next.Add(1)-1)%uint64(len(p.resolvers))]
+		until := r.evictedUntil.Load()
+		if until == 0 {
+			return r
+		}
+		if now >= until && r.evictedUntil.CompareAndSwap(until, 0) {
+			r.failures.Store(maxFailures - 1)
+			p.logger.WithField("resolver", r.address).Info("Trying evicted DNS resolver again")
+			return r
+		}
+	}
+	return nil
+}
+
+// evict takes r out of the pool, for good if forGood is set and otherwise
+// for evictionCooldown, unless it is the last resolver in use
+func (p *Pool) evict(r *resolver, reason string, forGood bool) {
+	p.evicting.Lock()
+	defer p.evicting.Unlock()
+
+	now := time.Now().UnixNano()
+	if !r.usable(now) {
+		return
+	}
+	others := 0
+	for _, other := range p.resolvers {
+		if other != r && other.usable(now) {
+			others++
+		}
+	}
+	if others == 0 {
+		p.logger.WithFields(logrus.Fields{
+			"resolver": r.address,
+			"reason":   reason,
+		}).Warn("Keeping the last DNS resolver in use despite failures")
+		return
+	}
+
+	until := now + int64(evictionCooldown)
+	if forGood {
+		until = evictedForGood
+	}
+	r.evictedUntil.Store(until)
+	p.logger.WithFields(logrus.Fields{
+		"resolver": r.address,
+		"reason":   reason,
+		"for_good": forGood,
+	}).Warn("Evicted DNS resolver")
+}
+
+// randomName returns a name that is practically certain not to exist
+func randomName() string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	label := make([]byte, 20)
+	for i := range label {
+		label[i] = alphabet[rand.Intn(len(alphabet))]
+	}
+	return string(label) + ".com"
+}
@@ -0,0 +1,246 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func quietLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// honest answers www.example.com and NXDOMAIN for everything else
+func honest(query *dnsmessage.Message, tcp bool) *dnsmessage.Message {
+	if strings.EqualFold(query.Questions[0].Name.String(), "www.example.com.") {
+		return reply(query, dnsmessage.RCodeSuccess, "192.0.2.1")
+	}
+	return reply(query, dnsmessage.RCodeNameError)
+}
+
+// lying answers every name, as resolvers hijacking failed lookups do
+func lying(query *dnsmessage.Message, tcp bool) *dnsmessage.Message {
+	return reply(query, dnsmessage.RCodeSuccess, "198.51.100.1")
+}
+
+func failing(query *dnsmessage.Message, tcp bool) *dnsmessage.Message {
+	return reply(query, dnsmessage.RCodeServerFailure)
+}
+
+func silent(query *dnsmessage.Message, tcp bool) *dnsmessage.Message {
+	return nil
+}
+
+func newTestPool(t *testing.T, retries int, servers ...*standIn) *Pool {
+	t.Helper()
+	var addresses []string
+	for _, server := range servers {
+		addresses = append(addresses, server.addr)
+	}
+	pool, err := NewPool(addresses, retries, quietLogger())
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	pool.Timeout = 200 * time.Millisecond
+	return pool
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	servers := []*standIn{startStandIn(t, honest), startStandIn(t, honest), startStandIn(t, honest)}
+	pool := newTestPool(t, 0, servers...)
+
+	for i := 0; i < 30; i++ {
+		if _, err := pool.Exchange(context.Background(), "www.example.com", dnsmessage.TypeA); err != nil {
+			t.Fatalf("Exchange: %v", err)
+		}
+	}
+	for i, server := range servers {
+		if got := server.udpQueries.Load(); got != 10 {
+			t.Errorf("resolver %d got %d queries, want 10", i, got)
+		}
+	}
+}
+
+func TestPoolRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		retries int
+		wantErr bool
+	}{
+		{"no retries", 0, true},
+		{"retried on the next resolver", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bad, good := startStandIn(t, failing), startStandIn(t, honest)
+			pool := newTestPool(t, tt.retries, bad, good)
+
+			answer, err := pool.Exchange(context.Background(), "www.example.com", dnsmessage.TypeA)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && len(addressesOf(answer)) != 1 {
+				t.Errorf("answer = %v, want the good resolver's", addressesOf(answer))
+			}
+			if bad.udpQueries.Load() != 1 {
+				t.Errorf("failing resolver got %d queries, want 1", bad.udpQueries.Load())
+			}
+		})
+	}
+}
+
+func TestPoolRetriesSilentResolver(t *testing.T) {
+	pool := newTestPool(t, 1, startStandIn(t, silent), startStandIn(t, honest))
+
+	answer, err := pool.Exchange(context.Background(), "www.example.com", dnsmessage.TypeA)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if len(addressesOf(answer)) != 1 {
+		t.Errorf("answer = %v, want the good resolver's", addressesOf(answer))
+	}
+}
+
+func TestPoolEvictsFailingResolver(t *testing.T) {
+	bad, good := startStandIn(t, failing), startStandIn(t, honest)
+	pool := newTestPool(t, 1, bad, good)
+
+	for i := 0; i < 2*maxFailures+2; i++ {
+		if _, err := pool.Exchange(context.Background(), "www.example.com", dnsmessage.TypeA); err != nil {
+			t.Fatalf("Exchange: %v", err)
+		}
+	}
+	if got := pool.Resolvers(); len(got) != 1 || got[0] != good.addr {
+		t.Errorf("resolvers = %v, want only %s", got, good.addr)
+	}
+	if got := bad.udpQueries.Load(); got != maxFailures {
+		t.Errorf("failing resolver got %d queries, want %d before eviction", got, maxFailures)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	good := startStandIn(t, honest)
+	refusing := startStandIn(t, func(query *dnsmessage.Message, tcp bool) *dnsmessage.Message {
+		return reply(query, dnsmessage.RCodeRefused)
+	})
+	pool := newTestPool(t, 0, good, startStandIn(t, lying), refusing, startStandIn(t, silent))
+
+	pool.HealthCheck(context.Background())
+	if got := pool.Resolvers(); len(got) != 1 || got[0] != good.addr {
+		t.Errorf("resolvers = %v, want only %s", got, good.addr)
+	}
+}
+
+func TestLoadPoolChecksOnItsOwn(t *testing.T) {
+	good, liar := startStandIn(t, honest), startStandIn(t, lying)
+	path := filepath.Join(t.TempDir(), "resolvers.txt")
+	if err := os.WriteFile(path, []byte(good.addr+"\n"+liar.addr+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pool, err := LoadPool(path, 1, quietLogger())
+	if err != nil {
+		t.Fatalf("LoadPool: %v", err)
+	}
+
+	// A first query with a deadline too short for the check must not
+	// keep the check from evicting the liar
+	ctx, cancel := context.WithTimeout(context.Background(), time.Microsecond)
+	defer cancel()
+	pool.Exchange(ctx, "www.example.com", dnsmessage.TypeA)
+
+	for i := 0; i < 4; i++ {
+		answer, err := pool.Exchange(context.Background(), "www.example.com", dnsmessage.TypeA)
+		if err != nil {
+			t.Fatalf("Exchange: %v", err)
+		}
+		if got := addressesOf(answer); len(got) != 1 || got[0] != "192.0.2.1" {
+			t.Errorf("answer = %v, want only the honest resolver's", got)
+		}
+	}
+	if got := pool.Resolvers(); len(got) != 1 || got[0] != good.addr {
+		t.Errorf("resolvers = %v, want only %s", got, good.addr)
+	}
+}
+
+func TestPoolKeepsLastResolver(t *testing.T) {
+	pool := newTestPool(t, 1, startStandIn(t, failing), startStandIn(t, failing))
+
+	for i := 0; i < 4*maxFailures; i++ {
+		if _, err := pool.Exchange(context.Background(), "www.example.com", dnsmessage.TypeA); errors.Is(err, ErrNoResolvers) {
+			t.Fatalf("query %d: %v, want the last resolver kept", i, err)
+		}
+	}
+	if got := pool.Resolvers(); len(got) != 1 {
+		t.Errorf("resolvers = %v, want the last one kept", got)
+	}
+}
+
+func TestPoolRestoresEvictedResolver(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	flaky := startStandIn(t, func(query *dnsmessage.Message, tcp bool) *dnsmessage.Message {
+		if down.Load() {
+			return failing(query, tcp)
+		}
+		return honest(query, tcp)
+	})
+	pool := newTestPool(t, 1, flaky, startStandIn(t, honest))
+
+	exchange := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			if _, err := pool.Exchange(context.Background(), "www.example.com", dnsmessage.TypeA); err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+		}
+	}
+	endCooldown := func() {
+		for _, r := range pool.resolvers {
+			if until := r.evictedUntil.Load(); until != 0 {
+				if until == evictedForGood {
+					t.Fatalf("%s evicted for good, want a cooldown", r.address)
+				}
+				r.evictedUntil.Store(time.Now().Add(-time.Second).UnixNano())
+			}
+		}
+	}
+
+	exchange(2 * maxFailures)
+	if got := pool.Resolvers(); len(got) != 1 {
+		t.Fatalf("resolvers = %v, want the failing one evicted", got)
+	}
+
+	// Once the cooldown ends, a single failure evicts it again
+	endCooldown()
+	before := flaky.udpQueries.Load()
+	exchange(4)
+	if got := flaky.udpQueries.Load() - before; got != 1 {
+		t.Errorf("failing resolver got %d queries after its cooldown, want 1", got)
+	}
+	if got := pool.Resolvers(); len(got) != 1 {
+		t.Errorf("resolvers = %v, want the failing one evicted again", got)
+	}
+
+	// A resolver that recovered stays
+	down.Store(false)
+	endCooldown()
+	before = flaky.udpQueries.Load()
+	exchange(4 * maxFailures)
+	if got := pool.Resolvers(); len(got) != 2 {
+		t.Errorf("resolvers = %v, want both", got)
+	}
+	if got := flaky.udpQueries.Load() - before; got != 2*maxFailures {
+		t.Errorf("recovered resolver got %d queries, want its %d", got, 2*maxFailures)
+	}
+}
@@ -0,0 +1,260 @@
+package dns
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// TypeCAA is the CAA record type, which dnsmessage does not define
+const TypeCAA dnsmessage.Type = 257
+
+// RecordTypes are the record types collected by default
+var RecordTypes = []dnsmessage.Type{
+	dnsmessage.TypeA,
+	dnsmessage.TypeAAAA,
+	dnsmessage.TypeCNAME,
+	dnsmessage.TypeMX,
+	dnsmessage.TypeNS,
+	dnsmessage.TypeTXT,
+	dnsmessage.TypeSOA,
+	dnsmessage.TypeSRV,
+	TypeCAA,
+	dnsmessage.TypePTR,
+}
+
+// typeNames maps the names of the supported record types to their types
+var typeNames = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"NS":    dnsmessage.TypeNS,
+	"TXT":   dnsmessage.TypeTXT,
+	"SOA":   dnsmessage.TypeSOA,
+	"SRV":   dnsmessage.TypeSRV,
+	"CAA":   TypeCAA,
+	"PTR":   dnsmessage.TypePTR,
+}
+
+// maxChain bounds the CNAME chains followed, which may contain loops
+const maxChain = 16
+
+// Record is a DNS resource record in presentation form, e.g. an MX record
+// with the value "10 mail.example.com"
+type Record struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	TTL   uint32 `json:"ttl"`
+	Value string `json:"value"`
+}
+
+// String formats the record like a zone file line
+func (r Record) String() string {
+	return fmt.Sprintf("%s %d %s %s", r.Name, r.TTL, r.Type, r.Value)
+}
+
+// ParseType returns the record type with the given name, e.g. "MX"
+func ParseType(name string) (dnsmessage.Type, error) {
+	qtype, ok := typeNames[strings.ToUpper(strings.TrimSpace(name))]
+	if !ok {
+		return 0, fmt.Errorf("unsupported record type %s", name)
+	}
+	return qtype, nil
+}
+
+// TypeName returns the name of a record type, e.g. "MX"
+func TypeName(qtype dnsmessage.Type) string {
+	for name, t := range typeNames {
+		if t == qtype {
+			return name
+		}
+	}
+	// Transfers and DNSSEC types are not collected as records but still
+	// named in results
+	switch qtype {
+	case dnsmessage.TypeAXFR:
+		return "AXFR"
+	case TypeIXFR:
+		return "IXFR"
+	case TypeDS:
+		return "DS"
+	case TypeRRSIG:
+		return "RRSIG"
+	case TypeNSEC:
+		return "NSEC"
+	case TypeDNSKEY:
+		return "DNSKEY"
+	case TypeNSEC3:
+		return "NSEC3"
+	case TypeNSEC3PARAM:
+		return "NSEC3PARAM"
+	}
+	return fmt.Sprintf("TYPE%d", qtype)
+}
+
+// Records converts the answer section of a message to records
+func Records(msg *dnsmessage.Message) []Record {
+	records := make([]Record, 0, len(msg.Answers))
+	for _, rr := range msg.Answers {
+		records = append(records, newRecord(rr))
+	}
+	return records
+}
+
+// newRecord converts a resource to a record
+func newRecord(rr dnsmessage.Resource) Record {
+	return Record{
+		Name:  trimName(rr.Header.Name),
+		Type:  TypeName(rr.Header.Type),
+		TTL:   rr.Header.TTL,
+		Value: formatBody(rr.Body),
+	}
+}
+
+// formatBody returns the presentation form of a record's data
+func formatBody(body dnsmessage.ResourceBody) string {
+	switch b := body.(type) {
+	case *dnsmessage.AResource:
+		return net.IP(b.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		return net.IP(b.AAAA[:]).String()
+	case *dnsmessage.CNAMEResource:
+		return trimName(b.CNAME)
+	case *dnsmessage.NSResource:
+		return trimName(b.NS)
+	case *dnsmessage.PTRResource:
+		return trimName(b.PTR)
+	case *dnsmessage.MXResource:
+		return fmt.Sprintf("%d %s", b.Pref, trimName(b.MX))
+	case *dnsmessage.TXTResource:
+		quoted := make([]string, len(b.TXT))
+		for i, txt := range b.TXT {
+			quoted[i] = strconv.Quote(txt)
+		}
+		return strings.Join(quoted, " ")
+	case *dnsmessage.SOAResource:
+		return fmt.Sprintf("%s %s %d %d %d %d %d", trimName(b.NS), trimName(b.MBox),
+			b.Serial, b.Refresh, b.Retry, b.Expire, b.MinTTL)
+	case *dnsmessage.SRVResource:
+		return fmt.Sprintf("%d %d %d %s", b.Priority, b.Weight, b.Port, trimName(b.Target))
+	case *dnsmessage.UnknownResource:
+		switch b.Type {
+		case TypeCAA:
+			if value, ok := formatCAA(b.Data); ok {
+				return value
+			}
+		case TypeNSEC:
+			if nsec, err := parseNSEC("", b.Data); err == nil {
+				return nsec.String()
+			}
+		case TypeNSEC3:
+			if nsec3, err := parseNSEC3("", b.Data); err == nil {
+				return nsec3.String()
+			}
+		}
+		return hex.EncodeToString(b.Data)
+	}
+	return ""
+}
+
+// formatCAA formats CAA record data as e.g. `0 issue "letsencrypt.org"`
+func formatCAA(data []byte) (string, bool) {
+	if len(data) < 2 || len(data) < 2+int(data[1]) {
+		return "", false
+	}
+	flags, tag, value := data[0], data[2:2+data[1]], data[2+data[1]:]
+	return fmt.Sprintf("%d %s %s", flags, tag, strconv.Quote(string(value))), true
+}
+
+// trimName returns a name without its trailing dot
+func trimName(name dnsmessage.Name) string {
+	return strings.TrimSuffix(name.String(), ".")
+}
+
+// Lookup returns the records of type qtype for name. The answer may hold
+// further records, e.g. the CNAME chain leading to name's addresses. Names
+// that do not exist have no records but may still have a CNAME chain.
+func (p *Pool) Lookup(ctx context.Context, name string, qtype dnsmessage.Type) ([]Record, error) {
+	answer, err := p.Exchange(ctx, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+	return Records(answer), nil
+}
+
+// Chain returns the names the CNAME records among records lead name to, in
+// order, e.g. [www.example.com.cdn.net edge.cdn.net]
+func Chain(records []Record, name string) []string {
+	targets := make(map[string]string)
+	for _, r := range records {
+		if r.Type == "CNAME" {
+			targets[strings.ToLower(r.Name)] = r.Value
+		}
+	}
+
+	var chain []string
+	current := strings.ToLower(strings.TrimSuffix(name, "."))
+	for len(chain) < maxChain {
+		target, ok := targets[current]
+		if !ok {
+			break
+		}
+		chain = append(chain, target)
+		current = strings.ToLower(target)
+	}
+	return chain
+}
+
+// ReverseName returns the name to look up the PTR records of ip with, e.g.
+// 4.3.2.1.in-addr.arpa for 1.2.3.4
+func ReverseName(ip net.IP) (string, error) {
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa", ip4[3], ip4[2], ip4[1], ip4[0]), nil
+	}
+	ip16 := ip.To16()
+	if ip16 == nil {
+		return "", fmt.Errorf("invalid IP address %v", ip)
+	}
+	var name strings.Builder
+	for i := len(ip16) - 1; i >= 0; i-- {
+		fmt.Fprintf(&name, "%x.%x.", ip16[i]&0x0f, ip16[i]>>4)
+	}
+	name.WriteString("ip6.arpa")
+	return name.String(), nil
+}
+
+// SystemPool returns a pool of the name servers in /etc/resolv.conf, for
+// queries the system resolver cannot make such as SOA or CAA lookups. The
+// system's name servers are trusted and not health-checked.
+func SystemPool(retries int, logger *logrus.Logger) (*Pool, error) {
+	file, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read system resolvers: %v", err)
+	}
+	defer file.Close()
+
+	var servers []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			// Drop IPv6 zones such as fe80::1%eth0
+			server, _, _ := strings.Cut(fields[1], "%")
+			servers = append(servers, server)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read system resolvers: %v", err)
+	}
+
+	return NewPool(servers, retries, logger)
+}
@@ -0,0 +1,128 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// TypeIXFR is the incremental zone transfer type, which dnsmessage does not
+// define
+const TypeIXFR dnsmessage.Type = 251
+
+// Transfer requests the whole of zone from server, a nameserver address
+// such as 192.0.2.1:53, over TCP and returns its records, starting and
+// ending with the zone's SOA record. qtype is dnsmessage.TypeAXFR or
+// TypeIXFR; an IXFR query claims serial 0, which servers that keep no
+// history that old answer with the full zone, and servers that refuse AXFR
+// sometimes allow.
+func Transfer(ctx context.Context, server, zone string, qtype dnsmessage.Type) ([]Record, error) {
+	query, err := NewQuery(zone, qtype)
+	if err != nil {
+		return nil, err
+	}
+	query.RecursionDesired = false
+	query.Additionals = nil
+	if qtype == TypeIXFR {
+		root := dnsmessage.MustNewName(".")
+		query.Authorities = []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{Name: query.Questions[0].Name, Type: dnsmessage.TypeSOA, Class: dnsmessage.ClassINET},
+			Body:   &dnsmessage.SOAResource{NS: root, MBox: root},
+		}}
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := abortOnDone(ctx, conn)
+	defer stop()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if err := writeTCPMessage(conn, packed); err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	var records []Record
+	var transfer zoneTransfer
+	for first := true; ; first = false {
+		answer, err := readTCPMessage(conn)
+		if err == io.EOF && qtype == TypeIXFR && len(records) == 1 && records[0].Type == "SOA" {
+			// The zone has not changed since the serial
+			return records, nil
+		}
+		if err == io.EOF && len(records) > 0 {
+			return records, fmt.Errorf("transfer from %s ended after %d records", server, len(records))
+		}
+		if err != nil {
+			return records, contextError(ctx, err)
+		}
+
+		// Only the first message has to repeat the question
+		if !answer.Response || answer.ID != query.ID || (first && !answers(query, answer)) {
+			return records, fmt.Errorf("mismatched answer from %s", server)
+		}
+		if answer.RCode != dnsmessage.RCodeSuccess {
+			return records, fmt.Errorf("%s refused the transfer: %v", server, answer.RCode)
+		}
+		if first && len(answer.Answers) == 0 {
+			return nil, fmt.Errorf("%s refused the transfer: empty answer", server)
+		}
+
+		for _, rr := range answer.Answers {
+			records = append(records, newRecord(rr))
+			if transfer.add(rr.Body) {
+				return records, nil
+			}
+		}
+	}
+}
+
+// zoneTransfer tracks the SOA records of a transfer to find its end. A full
+// transfer ends with the second copy of its SOA record. An incremental one,
+// whose second record is an older SOA, also repeats the new SOA ahead of
+// its last additions and ends with the third copy.
+type zoneTransfer struct {
+	serial      uint32
+	records     int
+	copies      int
+	incremental bool
+}
+
+// add records the body of the next record and reports whether it ends the
+// transfer
+func (zt *zoneTransfer) add(body dnsmessage.ResourceBody) bool {
+	zt.records++
+	soa, ok := body.(*dnsmessage.SOAResource)
+	if !ok {
+		return false
+	}
+	if zt.records == 1 {
+		zt.serial = soa.Serial
+		zt.copies = 1
+		return false
+	}
+	if zt.records == 2 && soa.Serial != zt.serial {
+		zt.incremental = true
+	}
+	if soa.Serial != zt.serial {
+		return false
+	}
+	zt.copies++
+	if zt.incremental {
+		return zt.copies == 3
+	}
+	return zt.copies == 2
+}
@@ -0,0 +1,213 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// soaRecord is the SOA record of example.com with serial
+func soaRecord(serial uint32) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("example.com."), Type: dnsmessage.TypeSOA, Class: dnsmessage.ClassINET, TTL: 3600},
+		Body: &dnsmessage.SOAResource{
+			NS:     dnsmessage.MustNewName("ns1.example.com."),
+			MBox:   dnsmessage.MustNewName("hostmaster.example.com."),
+			Serial: serial, Refresh: 7200, Retry: 900, Expire: 1209600, MinTTL: 300,
+		},
+	}
+}
+
+// aRecord is an A record of name in example.com
+func aRecord(name, address string) dnsmessage.Resource {
+	var a [4]byte
+	copy(a[:], net.ParseIP(address).To4())
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name + ".example.com."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 300},
+		Body:   &dnsmessage.AResource{A: a},
+	}
+}
+
+// transferMessage is a message of a transfer answering query. Only the
+// first message of a transfer repeats the question.
+func transferMessage(query *dnsmessage.Message, first bool, rcode dnsmessage.RCode, records ...dnsmessage.Resource) *dnsmessage.Message {
+	answer := &dnsmessage.Message{
+		Header:  dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true, RCode: rcode},
+		Answers: records,
+	}
+	if first {
+		answer.Questions = query.Questions
+	}
+	return answer
+}
+
+// recordNames lists the names and types of records, e.g. "www A"
+func recordNames(records []Record) []string {
+	var names []string
+	for _, record := range records {
+		names = append(names, strings.TrimSuffix(record.Name, ".example.com")+" "+record.Type)
+	}
+	return names
+}
+
+func transfer(t *testing.T, h streamHandler, qtype dnsmessage.Type) ([]Record, error) {
+	t.Helper()
+	s := startStreamingStandIn(t, h)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	records, err := Transfer(ctx, s.addr, "example.com", qtype)
+	if s.udpQueries.Load() != 0 {
+		t.Errorf("%d queries over UDP, want the transfer over TCP only", s.udpQueries.Load())
+	}
+	return records, err
+}
+
+func TestTransferAcrossMessages(t *testing.T) {
+	records, err := transfer(t, func(query *dnsmessage.Message, tcp bool) []*dnsmessage.Message {
+		if query.Questions[0].Type != dnsmessage.TypeAXFR || query.RecursionDesired {
+			t.Errorf("query %v, want a plain AXFR", query.Questions[0].Type)
+		}
+		return []*dnsmessage.Message{
+			transferMessage(query, true, dnsmessage.RCodeSuccess, soaRecord(2024010101), aRecord("www", "192.0.2.1")),
+			transferMessage(query, false, dnsmessage.RCodeSuccess, aRecord("mail", "192.0.2.2")),
+			transferMessage(query, false, dnsmessage.RCodeSuccess, aRecord("vpn", "192.0.2.3"), soaRecord(2024010101)),
+			// Anything after the closing SOA is not read
+			transferMessage(query, false, dnsmessage.RCodeSuccess, aRecord("late", "192.0.2.4")),
+		}
+	}, dnsmessage.TypeAXFR)
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	want := []string{"example.com SOA", "www A", "mail A", "vpn A", "example.com SOA"}
+	if got := recordNames(records); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("records = %q, want %q", got, want)
+	}
+	if records[2].Value != "192.0.2.2" {
+		t.Errorf("mail = %s, want 192.0.2.2", records[2].Value)
+	}
+}
+
+func TestTransferCutShort(t *testing.T) {
+	records, err := transfer(t, func(query *dnsmessage.Message, tcp bool) []*dnsmessage.Message {
+		return []*dnsmessage.Message{
+			transferMessage(query, true, dnsmessage.RCodeSuccess, soaRecord(7), aRecord("www", "192.0.2.1")),
+		}
+	}, dnsmessage.TypeAXFR)
+	if err == nil || !strings.Contains(err.Error(), "ended after 2 records") {
+		t.Errorf("err = %v, want the transfer reported incomplete", err)
+	}
+	if len(records) != 2 {
+		t.Errorf("%d records, want the 2 received", len(records))
+	}
+}
+
+func TestTransferRefused(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  func(query *dnsmessage.Message) *dnsmessage.Message
+		wantErr string
+	}{
+		{
+			name: "REFUSED",
+			answer: func(query *dnsmessage.Message) *dnsmessage.Message {
+				return transferMessage(query, true, dnsmessage.RCodeRefused)
+			},
+			wantErr: "refused the transfer: RCodeRefused",
+		},
+		{
+			name: "empty answer",
+			answer: func(query *dnsmessage.Message) *dnsmessage.Message {
+				return transferMessage(query, true, dnsmessage.RCodeSuccess)
+			},
+			wantErr: "refused the transfer: empty answer",
+		},
+		{
+			name: "other question",
+			answer: func(query *dnsmessage.Message) *dnsmessage.Message {
+				answer := transferMessage(query, true, dnsmessage.RCodeSuccess, soaRecord(7))
+				answer.Questions = []dnsmessage.Question{{Name: dnsmessage.MustNewName("example.net."), Type: dnsmessage.TypeAXFR, Class: dnsmessage.ClassINET}}
+				return answer
+			},
+			wantErr: "mismatched answer",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := startStandIn(t, func(query *dnsmessage.Message, tcp bool) *dnsmessage.Message {
+				return tt.answer(query)
+			})
+			records, err := Transfer(context.Background(), s.addr, "example.com", dnsmessage.TypeAXFR)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+			if len(records) != 0 {
+				t.Errorf("%d records from a refused transfer", len(records))
+			}
+		})
+	}
+}
+
+func TestTransferIXFR(t *testing.T) {
+	checkQuery := func(query *dnsmessage.Message) {
+		if query.Questions[0].Type != TypeIXFR || len(query.Authorities) != 1 {
+			t.Errorf("query %v with %d authorities, want IXFR with our SOA", query.Questions[0].Type, len(query.Authorities))
+			return
+		}
+		if soa, ok := query.Authorities[0].Body.(*dnsmessage.SOAResource); !ok || soa.Serial != 0 {
+			t.Errorf("IXFR claims %v, want serial 0", query.Authorities[0].Body)
+		}
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		// A server with nothing newer answers with its SOA alone and closes
+		// the connection; the module takes that for a zone without records
+		s := startStandIn(t, func(query *dnsmessage.Message, tcp bool) *dnsmessage.Message {
+			checkQuery(query)
+			return transferMessage(query, true, dnsmessage.RCodeSuccess, soaRecord(7))
+		})
+		records, err := Transfer(context.Background(), s.addr, "example.com", TypeIXFR)
+		if err != nil {
+			t.Fatalf("Transfer: %v", err)
+		}
+		if len(records) != 1 || records[0].Type != "SOA" {
+			t.Errorf("records = %v, want the SOA alone", records)
+		}
+	})
+
+	t.Run("full zone", func(t *testing.T) {
+		records, err := transfer(t, func(query *dnsmessage.Message, tcp bool) []*dnsmessage.Message {
+			checkQuery(query)
+			return []*dnsmessage.Message{
+				transferMessage(query, true, dnsmessage.RCodeSuccess, soaRecord(9), aRecord("www", "192.0.2.1"), soaRecord(9)),
+			}
+		}, TypeIXFR)
+		if err != nil {
+			t.Fatalf("Transfer: %v", err)
+		}
+		if len(records) != 3 {
+			t.Errorf("records = %v, want the zone between two SOA records", records)
+		}
+	})
+
+	t.Run("incremental", func(t *testing.T) {
+		// New SOA, old SOA and deletions, new SOA and additions, new SOA
+		records, err := transfer(t, func(query *dnsmessage.Message, tcp bool) []*dnsmessage.Message {
+			checkQuery(query)
+			return []*dnsmessage.Message{
+				transferMessage(query, true, dnsmessage.RCodeSuccess, soaRecord(9), soaRecord(8), aRecord("old", "192.0.2.1")),
+				transferMessage(query, false, dnsmessage.RCodeSuccess, soaRecord(9), aRecord("new", "192.0.2.2"), soaRecord(9)),
+			}
+		}, TypeIXFR)
+		if err != nil {
+			t.Fatalf("Transfer: %v", err)
+		}
+		if len(records) != 6 {
+			t.Errorf("records = %v, want all 6 up to the third copy of the new SOA", records)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"context"
+	"sync"
+)
+
+// Source produces work items lazily, calling yield once per item until it
+// runs out of items or yield returns false. It returns an error only if the
+// items could not be produced, e.g. when a wordlist cannot be read.
+type Source[T any] func(ctx context.Context, yield func(T) bool) error
+
+// Worker processes a single item. It returns false when the item produced
+// no result.
+type Worker[T, R any] func(ctx context.Context, item T) (R, bool)
+
+// Job is a running pool of workers
+type Job[R any] struct {
+	results chan R
+	done    chan struct{}
+	err     error
+}
+
+// Run starts a fixed number of workers that process the items of source and
+// returns immediately. Items are read from the source only as fast as the
+// workers take them, so memory use does not grow with the number of items.
+// Cancelling ctx stops the source; items already handed out are skipped.
+// Callers must drain Results until it is closed.
+func Run[T, R any](ctx context.Context, workers int, source Source[T], work Worker[T, R]) *Job[R] {
+	if workers < 1 {
+		workers = 1
+	}
+
+	job := &Job[R]{
+		results: make(chan R, workers),
+		done:    make(chan struct{}),
+	}
+	items := make(chan T, workers)
+
+	go func() {
+		defer close(items)
+		job.err = source(ctx, func(item T) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case items <- item:
+				return true
+			}
+		})
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				if ctx.Err() != nil {
+					continue
+				}
+				if result, ok := work(ctx, item); ok {
+					job.results <- result
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(job.results)
+		close(job.done)
+	}()
+
+	return job
+}
+
+// Results returns the channel results are delivered on. It is closed once
+// every worker has finished.
+func (j *Job[R]) Results() <-chan R {
+	return j.results
+}
+
+// Wait blocks until the job has finished and returns the source's error
+func (j *Job[R]) Wait() error {
+	<-j.done
+	return j.err
+}
+
+// Collect runs the job to completion, calling found for every result in
+// the calling goroutine, and returns the source's error
+func (j *Job[R]) Collect(found func(R)) error {
+	for result := range j.results {
+		found(result)
+	}
+	return j.Wait()
+}
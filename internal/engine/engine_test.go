@@ -0,0 +1,197 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// counting returns a source producing 0, 1, 2 and so on without end,
+// counting the items it produced
+func counting(produced *atomic.Int32) Source[int] {
+	return func(ctx context.Context, yield func(int) bool) error {
+		for i := 0; ; i++ {
+			produced.Add(1)
+			if !yield(i) {
+				return nil
+			}
+		}
+	}
+}
+
+func TestRunWorkerLimit(t *testing.T) {
+	const workers = 4
+	items := make([]int, 60)
+	for i := range items {
+		items[i] = i
+	}
+
+	var active, most atomic.Int32
+	job := Run(context.Background(), workers, Slice(items), func(ctx context.Context, item int) (int, bool) {
+		n := active.Add(1)
+		for {
+			m := most.Load()
+			if n <= m || most.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		active.Add(-1)
+		// Odd items produce no result
+		return item, item%2 == 0
+	})
+
+	sum, results := 0, 0
+	if err := job.Collect(func(item int) {
+		sum += item
+		results++
+	}); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if results != 30 || sum != 870 {
+		t.Errorf("%d results adding up to %d, want the 30 even items adding up to 870", results, sum)
+	}
+	if got := most.Load(); got > workers {
+		t.Errorf("%d items processed at once, want at most %d", got, workers)
+	}
+}
+
+func TestRunStopsWhenConsumerCancels(t *testing.T) {
+	const workers = 3
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var produced atomic.Int32
+	job := Run(ctx, workers, counting(&produced), func(ctx context.Context, item int) (int, bool) {
+		return item, true
+	})
+
+	collected := 0
+	err := job.Collect(func(int) {
+		collected++
+		if collected == 10 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Errorf("Collect: %v", err)
+	}
+	// Results already computed when the consumer stopped may still arrive,
+	// and the source may hand out a few more items that are skipped, but
+	// it stops soon after
+	if got := produced.Load(); got > int32(collected+50) {
+		t.Errorf("source produced %d items for %d results, want it stopped", got, collected)
+	}
+}
+
+func TestRunStopsAtDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var produced atomic.Int32
+	job := Run(ctx, 2, counting(&produced), func(ctx context.Context, item int) (int, bool) {
+		time.Sleep(5 * time.Millisecond)
+		return item, true
+	})
+
+	start := time.Now()
+	done := make(chan error)
+	go func() { done <- job.Collect(func(int) {}) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Collect: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not stop at the deadline")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("job took %v to stop", elapsed)
+	}
+}
+
+func TestRunSourceError(t *testing.T) {
+	job := Run(context.Background(), 2, Lines(filepath.Join(t.TempDir(), "missing.txt")), func(ctx context.Context, line string) (string, bool) {
+		t.Errorf("worker called with %q", line)
+		return line, true
+	})
+	results := 0
+	err := job.Collect(func(string) { results++ })
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("err = %v, want the missing file", err)
+	}
+	if results != 0 {
+		t.Errorf("%d results, want none", results)
+	}
+}
+
+func TestRunReadsLazily(t *testing.T) {
+	const workers = 2
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	release := make(chan struct{})
+	var produced atomic.Int32
+	job := Run(ctx, workers, counting(&produced), func(ctx context.Context, item int) (int, bool) {
+		<-release
+		return item, false
+	})
+
+	// With every worker busy, the source gets no further than filling the
+	// queue: an item per worker, as many queued and one waiting to be
+	time.Sleep(50 * time.Millisecond)
+	if got := produced.Load(); got > 2*workers+1 {
+		t.Errorf("source produced %d items ahead of %d busy workers", got, workers)
+	}
+
+	// Once the workers are free the source goes on
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for produced.Load() <= 2*workers+1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if produced.Load() <= 2*workers+1 {
+		t.Error("source did not go on once the workers were free")
+	}
+
+	cancel()
+	if err := job.Wait(); err != nil {
+		t.Errorf("Wait: %v", err)
+	}
+}
+
+func TestLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("  www \n\n# comment\napi\r\n\t#indented comment\nmail"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	if err := Lines(path)(context.Background(), func(line string) bool {
+		lines = append(lines, line)
+		return true
+	}); err != nil {
+		t.Fatalf("Lines: %v", err)
+	}
+	if want := []string{"www", "api", "mail"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+	if count, err := CountLines(path); err != nil || count != 3 {
+		t.Errorf("CountLines = %d, %v, want 3", count, err)
+	}
+
+	// Stopping early is no error
+	lines = nil
+	if err := Lines(path)(context.Background(), func(line string) bool {
+		lines = append(lines, line)
+		return false
+	}); err != nil || len(lines) != 1 {
+		t.Errorf("stopped after %q with %v, want a single line and no error", lines, err)
+	}
+}
@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+)
+
+// Slice returns a source that produces the given items
+func Slice[T any](items []T) Source[T] {
+	return func(ctx context.Context, yield func(T) bool) error {
+		for _, item := range items {
+			if !yield(item) {
+				return nil
+			}
+		}
+		return nil
+	}
+}
+
+// Lines returns a source that reads the file at path one line at a time.
+// Lines are trimmed; blank lines and lines starting with "#" are skipped.
+func Lines(path string) Source[string] {
+	return func(ctx context.Context, yield func(string) bool) error {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if !yield(line) {
+				return nil
+			}
+		}
+		return scanner.Err()
+	}
+}
+
+// CountLines returns how many items Lines(path) produces without keeping
+// the file in memory, for progress reporting
+func CountLines(path string) (int, error) {
+	count := 0
+	err := Lines(path)(context.Background(), func(string) bool {
+		count++
+		return true
+	})
+	return count, err
+}
+
+// Pair carries two values through a job, e.g. a work item together with
+// its line number
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
@@ -39,6 +39,9 @@ func NewMainWindow(app fyne.App, db *database.DB, cfg *config.Config, logger *lo
 
 	// Initialize module manager
 	moduleManager := modules.NewModuleManager(db, cfg, logger)
+	if err := moduleManager.LoadScope(); err != nil {
+		logger.WithError(err).Warn("Failed to load engagement scope")
+	}
 
 	mainWindow := &MainWindow{
 		Window:  window,
@@ -0,0 +1,73 @@
+package gui
+
+import (
+	"GoReconX/internal/modules"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// optionsForm is an options editor generated from a module's option schema
+type optionsForm struct {
+	content fyne.CanvasObject
+	values  map[string]func() interface{}
+}
+
+// newOptionsForm builds one input per option in schema
+func newOptionsForm(schema modules.OptionSchema) *optionsForm {
+	form := &optionsForm{values: make(map[string]func() interface{})}
+
+	if len(schema) == 0 {
+		form.content = widget.NewLabel("This module has no options.")
+		return form
+	}
+
+	box := container.NewVBox()
+	for _, spec := range schema {
+		label := spec.Description
+		if spec.Range != nil {
+			label += fmt.Sprintf(" (%v-%v)", spec.Range.Min, spec.Range.Max)
+		}
+		if spec.Required {
+			label += " *"
+		}
+
+		switch spec.Type {
+		case modules.OptionBool:
+			check := widget.NewCheck(label, nil)
+			if enabled, ok := spec.Default.(bool); ok {
+				check.SetChecked(enabled)
+			}
+			box.Add(check)
+			form.values[spec.Name] = func() interface{} { return check.Checked }
+
+		default:
+			entry := widget.NewEntry()
+			if spec.Default != nil {
+				entry.SetText(fmt.Sprint(spec.Default))
+			}
+			box.Add(widget.NewLabel(label + ":"))
+			box.Add(entry)
+			form.values[spec.Name] = func() interface{} { return entry.Text }
+		}
+	}
+
+	form.content = box
+	return form
+}
+
+// Options returns the values entered in the form. Empty text inputs are
+// left out so that the module's defaults apply.
+func (of *optionsForm) Options() map[string]interface{} {
+	options := make(map[string]interface{})
+	for name, value := range of.values {
+		v := value()
+		if text, ok := v.(string); ok && text == "" {
+			continue
+		}
+		options[name] = v
+	}
+	return options
+}
@@ -2,6 +2,11 @@ package gui
 
 import (
 	"GoReconX/internal/modules"
+	"GoReconX/internal/targets"
+	"context"
+	"fmt"
+	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -9,6 +14,172 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// scanRunner runs one module at a time in the background, streams its
+// events into an output console and lets the user cancel it from the GUI
+type scanRunner struct {
+	modules     *modules.ModuleManager
+	logger      *logrus.Logger
+	output      *widget.RichText
+	progress    *widget.ProgressBar
+	status      *widget.Label
+	optionsCard *widget.Card
+	form        *optionsForm
+
+	// Display names offered in the module select and their module IDs
+	names []string
+	ids   map[string]string
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// newScanRunner creates a runner for the registered modules in category
+// that writes to output
+func newScanRunner(moduleManager *modules.ModuleManager, category modules.ModuleCategory, logger *logrus.Logger, output *widget.RichText) *scanRunner {
+	sr := &scanRunner{
+		modules:     moduleManager,
+		logger:      logger,
+		output:      output,
+		progress:    widget.NewProgressBar(),
+		status:      widget.NewLabel("Idle"),
+		optionsCard: widget.NewCard("Options", "", widget.NewLabel("Select a module")),
+		form:        newOptionsForm(nil),
+		ids:         make(map[string]string),
+	}
+
+	for _, info := range moduleManager.ListModules(category) {
+		sr.names = append(sr.names, info.Name)
+		sr.ids[info.Name] = info.ID
+	}
+	return sr
+}
+
+// moduleSelect creates a select listing the runner's modules
+func (sr *scanRunner) moduleSelect() *widget.Select {
+	moduleSelect := widget.NewSelect(sr.names, sr.selectModule)
+	if len(sr.names) > 0 {
+		moduleSelect.SetSelected(sr.names[0])
+	}
+	return moduleSelect
+}
+
+// selectModule rebuilds the options form for the chosen module
+func (sr *scanRunner) selectModule(moduleName string) {
+	sr.form = newOptionsForm(sr.modules.OptionSchema(sr.ids[moduleName]))
+	sr.optionsCard.SetContent(sr.form.content)
+}
+
+// start launches moduleName against every target target expands to with
+// the options from the form unless a scan is already running
+func (sr *scanRunner) start(moduleName, target string) {
+	moduleID, ok := sr.ids[moduleName]
+	if !ok {
+		sr.output.ParseMarkdown(fmt.Sprintf("Module **%s** is not available.", moduleName))
+		return
+	}
+
+	sr.mu.Lock()
+	if sr.cancel != nil {
+		sr.mu.Unlock()
+		sr.logger.Warn("A scan is already running")
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sr.cancel = cancel
+	sr.mu.Unlock()
+
+	options := sr.form.Options()
+	sr.output.ParseMarkdown(fmt.Sprintf("Running **%s** against `%s`...", moduleName, target))
+	sr.progress.SetValue(0)
+	sr.status.SetText("Starting...")
+
+	go func() {
+		defer sr.finish()
+
+		// The target may be a comma list, a CIDR or IP range, or @file.
+		// Modules sweeping ranges get each range whole.
+		source := targets.Expand([]string{target}, nil)
+		if info, ok := sr.modules.GetModuleInfo(moduleID); ok && takesRanges(info) {
+			source = targets.Entries([]string{target}, nil)
+		}
+		for event := range sr.modules.StreamTargets(ctx, moduleID, source, options) {
+			sr.handleEvent(event)
+		}
+	}()
+}
+
+// takesRanges reports whether a module takes CIDR and IP ranges as targets
+func takesRanges(info modules.ModuleInfo) bool {
+	for _, input := range info.RequiredInputs {
+		if input == modules.InputRange {
+			return true
+		}
+	}
+	return false
+}
+
+// handleEvent renders a single module event in the output console
+func (sr *scanRunner) handleEvent(event modules.Event) {
+	switch event.Type {
+	case modules.EventStarted:
+		sr.status.SetText(fmt.Sprintf("Running %s", event.Module))
+
+	case modules.EventProgress:
+		if event.Total > 0 {
+			sr.progress.SetValue(float64(event.Done) / float64(event.Total))
+		}
+		sr.status.SetText(fmt.Sprintf("%d/%d done, ETA %s",
+			event.Done, event.Total, event.ETA().Round(time.Second)))
+
+	case modules.EventFinding:
+		sr.appendLine(fmt.Sprintf("+ %v", event.Finding))
+
+	case modules.EventFinished:
+		result := event.Result
+		if result == nil {
+			return
+		}
+		if result.Status == modules.StatusFailed {
+			sr.logger.WithField("target", event.Target).Error(result.ErrorMessage)
+			sr.appendLine(fmt.Sprintf("Scan failed: %s", result.ErrorMessage))
+		} else {
+			sr.appendLine(fmt.Sprintf("%s against %s %s with %d results in %s",
+				result.ModuleName, result.Target, result.Status, len(result.Results),
+				event.Elapsed.Round(time.Millisecond)))
+		}
+		sr.status.SetText(result.Status)
+	}
+}
+
+// appendLine adds a paragraph to the output console
+func (sr *scanRunner) appendLine(line string) {
+	sr.output.Segments = append(sr.output.Segments, &widget.TextSegment{
+		Text:  line,
+		Style: widget.RichTextStyleParagraph,
+	})
+	sr.output.Refresh()
+}
+
+// stop cancels the running scan, if any
+func (sr *scanRunner) stop() {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.cancel != nil {
+		sr.logger.Info("Cancelling running scan")
+		sr.cancel()
+	}
+}
+
+// finish releases the running scan slot
+func (sr *scanRunner) finish() {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.cancel != nil {
+		sr.cancel()
+		sr.cancel = nil
+	}
+}
+
 // PassiveOSINTTab represents the passive OSINT tab
 type PassiveOSINTTab struct {
 	modules *modules.ModuleManager
@@ -30,27 +201,17 @@ func NewPassiveOSINTTab(moduleManager *modules.ModuleManager, logger *logrus.Log
 func (pot *PassiveOSINTTab) setupContent() {
 	// Target input
 	targetEntry := widget.NewEntry()
-	targetEntry.SetPlaceHolder("Enter target domain (e.g., example.com)")
-
-	// Module selection
-	moduleSelect := widget.NewSelect([]string{
-		"Subdomain Enumeration",
-		"Email Harvesting",
-		"Web Analysis",
-		"IP Geolocation",
-		"GitHub Reconnaissance",
-	}, nil)
-	moduleSelect.SetSelected("Subdomain Enumeration")
-
-	// Options
-	optionsCard := widget.NewCard("Options", "",
-		container.NewVBox(
-			widget.NewLabel("Threads:"),
-			widget.NewSlider(1, 100),
-			widget.NewLabel("Timeout (seconds):"),
-			widget.NewSlider(1, 30),
-			widget.NewCheck("Resolve IPs", nil),
-		))
+	targetEntry.SetPlaceHolder("Enter target domains (e.g., example.com, example.org or @domains.txt)")
+
+	// Output console
+	outputText := widget.NewRichTextFromMarkdown("Ready to start passive reconnaissance...")
+	outputText.Resize(fyne.NewSize(600, 300))
+	outputScroll := container.NewScroll(outputText)
+	outputScroll.SetMinSize(fyne.NewSize(600, 300))
+	runner := newScanRunner(pot.modules, modules.CategoryPassive, pot.logger, outputText)
+
+	// Module selection, with an options form generated from its schema
+	moduleSelect := runner.moduleSelect()
 
 	// Control buttons
 	runButton := widget.NewButton("Run Scan", func() {
@@ -61,19 +222,15 @@ func (pot *PassiveOSINTTab) setupContent() {
 		}
 
 		pot.logger.WithField("target", target).Info("Starting passive OSINT scan")
-		// TODO: Implement scan execution
+		runner.start(moduleSelect.Selected, target)
 	})
 
+	stopButton := widget.NewButton("Stop", runner.stop)
+
 	clearButton := widget.NewButton("Clear", func() {
 		targetEntry.SetText("")
 	})
 
-	// Output console
-	outputText := widget.NewRichTextFromMarkdown("Ready to start passive reconnaissance...")
-	outputText.Resize(fyne.NewSize(600, 300))
-	outputScroll := container.NewScroll(outputText)
-	outputScroll.SetMinSize(fyne.NewSize(600, 300))
-
 	// Layout
 	inputSection := widget.NewCard("Target & Module", "",
 		container.NewVBox(
@@ -81,13 +238,14 @@ func (pot *PassiveOSINTTab) setupContent() {
 			targetEntry,
 			widget.NewLabel("Module:"),
 			moduleSelect,
-			container.NewHBox(runButton, clearButton),
+			container.NewHBox(runButton, stopButton, clearButton),
 		))
 
-	outputSection := widget.NewCard("Output", "", outputScroll)
+	outputSection := widget.NewCard("Output", "",
+		container.NewBorder(container.NewVBox(runner.status, runner.progress), nil, nil, nil, outputScroll))
 
 	pot.content = container.NewHSplit(
-		container.NewVBox(inputSection, optionsCard),
+		container.NewVBox(inputSection, runner.optionsCard),
 		outputSection,
 	)
 }
@@ -126,15 +284,14 @@ Unauthorized scanning may be illegal in your jurisdiction.
 
 	// Target input
 	targetEntry := widget.NewEntry()
-	targetEntry.SetPlaceHolder("Enter target IP or domain")
+	targetEntry.SetPlaceHolder("Enter targets (e.g., 10.0.0.1-50, 192.168.1.0/24 or example.com)")
+
+	// Output console
+	outputText := widget.NewRichTextFromMarkdown("Ready for active reconnaissance...")
+	runner := newScanRunner(art.modules, modules.CategoryActive, art.logger, outputText)
 
-	// Module selection
-	moduleSelect := widget.NewSelect([]string{
-		"Port Scanner",
-		"Directory Enumeration",
-		"Service Detection",
-	}, nil)
-	moduleSelect.SetSelected("Port Scanner")
+	// Module selection, with an options form generated from its schema
+	moduleSelect := runner.moduleSelect()
 
 	// Control buttons
 	runButton := widget.NewButton("Run Scan", func() {
@@ -145,11 +302,10 @@ Unauthorized scanning may be illegal in your jurisdiction.
 		}
 
 		art.logger.WithField("target", target).Info("Starting active reconnaissance")
-		// TODO: Implement scan execution
+		runner.start(moduleSelect.Selected, target)
 	})
 
-	// Output console
-	outputText := widget.NewRichTextFromMarkdown("Ready for active reconnaissance...")
+	stopButton := widget.NewButton("Stop", runner.stop)
 	outputScroll := container.NewScroll(outputText)
 	outputScroll.SetMinSize(fyne.NewSize(600, 400))
 
@@ -160,14 +316,15 @@ Unauthorized scanning may be illegal in your jurisdiction.
 			targetEntry,
 			widget.NewLabel("Module:"),
 			moduleSelect,
-			runButton,
+			container.NewHBox(runButton, stopButton),
 		))
 
-	outputSection := widget.NewCard("Output", "", outputScroll)
+	outputSection := widget.NewCard("Output", "",
+		container.NewBorder(container.NewVBox(runner.status, runner.progress), nil, nil, nil, outputScroll))
 
 	art.content = container.NewVBox(
 		warningCard,
-		container.NewHSplit(inputSection, outputSection),
+		container.NewHSplit(container.NewVBox(inputSection, runner.optionsCard), outputSection),
 	)
 }
 
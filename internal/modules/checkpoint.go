@@ -0,0 +1,293 @@
+package modules
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// checkpointInterval is how often a running scan saves its progress
+const checkpointInterval = 2 * time.Second
+
+type checkpointKey struct{}
+
+// checkpointStore saves the progress of a single stored scan
+type checkpointStore struct {
+	mm     *ModuleManager
+	scanID int
+	// resume is the checkpoint the scan continues from, empty for a new scan
+	resume string
+}
+
+// withCheckpoints returns a context whose scan saves its progress to store
+func withCheckpoints(ctx context.Context, store *checkpointStore) context.Context {
+	return context.WithValue(ctx, checkpointKey{}, store)
+}
+
+// checkpointState is the stored form of a checkpoint
+type checkpointState[T any] struct {
+	// Input is the hash of what the work items were derived from, see
+	// checkpointInput
+	Input string `json:"input"`
+	// Completed lists the keys of the finished work items as ranges,
+	// e.g. "0-1500,1502"
+	Completed string `json:"completed"`
+	Findings  []T    `json:"findings"`
+}
+
+// checkpoint tracks which work items of a scan are finished and what they
+// found, and saves both every checkpointInterval. Work items are identified
+// by an integer key, such as a wordlist line number or a port. A nil
+// checkpoint, for scans that are not stored, tracks nothing.
+type checkpoint[T any] struct {
+	store *checkpointStore
+	input string
+
+	mu       sync.Mutex
+	done     spans
+	findings []T
+	restored int
+	lastSave time.Time
+	// snapshots counts the snapshots taken for saving
+	snapshots int
+
+	// saving serializes writes, so that a snapshot never overwrites a later
+	// one; saved is the number of the last snapshot written
+	saving sync.Mutex
+	saved  int
+}
+
+// newCheckpoint returns the checkpoint of the scan running in ctx, restored
+// from the stored one when the scan is being resumed. input hashes what the
+// scan's work items are derived from, see checkpointInput, and is only
+// called for stored scans. A checkpoint made from different input is
+// refused, as its keys would refer to other work.
+func newCheckpoint[T any](ctx context.Context, input func() (string, error)) (*checkpoint[T], error) {
+	store, _ := ctx.Value(checkpointKey{}).(*checkpointStore)
+	if store == nil {
+		return nil, nil
+	}
+
+	hash, err := input()
+	if err != nil {
+		return nil, err
+	}
+	cp := &checkpoint[T]{store: store, input: hash, lastSave: time.Now()}
+	if store.resume == "" {
+		return cp, nil
+	}
+
+	var state checkpointState[T]
+	if err := json.Unmarshal([]byte(store.resume), &state); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint: %v", err)
+	}
+	if state.Input != hash {
+		return nil, fmt.Errorf("the checkpoint was made with a different wordlist, port list or options; start a new scan")
+	}
+	done, err := parseSpans(state.Completed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint: %v", err)
+	}
+	cp.done = done
+	cp.findings = state.Findings
+	cp.restored = len(state.Findings)
+	return cp, nil
+}
+
+// Finished reports whether the work item with key was finished before the
+// scan was resumed
+func (cp *checkpoint[T]) Finished(key int) bool {
+	if cp == nil {
+		return false
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.done.contains(key)
+}
+
+// Restored returns the findings made before the scan was resumed
+func (cp *checkpoint[T]) Restored() []T {
+	if cp == nil {
+		return nil
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.findings[:cp.restored:cp.restored]
+}
+
+// Done records that the work item with key is finished along with what it
+// found. The finding must be recorded together with the item, or a crash in
+// between would lose it.
+func (cp *checkpoint[T]) Done(key int, findings ...T) {
+	if cp == nil {
+		return
+	}
+	cp.mu.Lock()
+	cp.done = cp.done.add(key)
+	cp.findings = append(cp.findings, findings...)
+	due := time.Since(cp.lastSave) >= checkpointInterval
+	var number int
+	var state checkpointState[T]
+	if due {
+		number, state = cp.snapshot()
+	}
+	cp.mu.Unlock()
+
+	// Other work items finish while the snapshot is written
+	if due {
+		cp.save(number, state)
+	}
+}
+
+// Flush saves the current progress, e.g. when the scan stops. Once it
+// returns, no earlier save is still being written.
+func (cp *checkpoint[T]) Flush() {
+	if cp == nil {
+		return
+	}
+	cp.mu.Lock()
+	number, state := cp.snapshot()
+	cp.mu.Unlock()
+	cp.save(number, state)
+}
+
+// snapshot returns the progress to save and the number of the snapshot;
+// cp.mu must be held. Findings are only ever appended, so the snapshot
+// shares them.
+func (cp *checkpoint[T]) snapshot() (int, checkpointState[T]) {
+	cp.lastSave = time.Now()
+	cp.snapshots++
+	findings := cp.findings[:len(cp.findings):len(cp.findings)]
+	return cp.snapshots, checkpointState[T]{Input: cp.input, Completed: cp.done.String(), Findings: findings}
+}
+
+// save writes snapshot number to the scan record, unless a later one was
+// written first
+func (cp *checkpoint[T]) save(number int, state checkpointState[T]) {
+	cp.saving.Lock()
+	defer cp.saving.Unlock()
+	if number <= cp.saved {
+		return
+	}
+	cp.saved = number
+
+	data, err := json.Marshal(state)
+	if err == nil {
+		err = cp.store.mm.DB.SaveCheckpoint(cp.store.scanID, string(data))
+	}
+	if err != nil {
+		cp.store.mm.Logger.WithError(err).WithField("scan_id", cp.store.scanID).Warn("Failed to save checkpoint")
+	}
+}
+
+// checkpointInput hashes what the work items of a scan are derived from,
+// such as its target, options and port lists, for newCheckpoint
+func checkpointInput(parts ...interface{}) (string, error) {
+	hash := sha256.New()
+	encoder := json.NewEncoder(hash)
+	for _, part := range parts {
+		if err := encoder.Encode(part); err != nil {
+			return "", fmt.Errorf("failed to hash checkpoint input: %v", err)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashFile returns the hash of the contents of a file, e.g. a wordlist
+// whose line numbers are checkpoint keys, for checkpointInput
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// span is an inclusive range of work item keys
+type span struct{ first, last int }
+
+// spans is a sorted set of non-adjacent ranges. Work items finish roughly
+// in order, so even millions of them collapse into a few ranges.
+type spans []span
+
+// contains reports whether key is in the set
+func (s spans) contains(key int) bool {
+	i := sort.Search(len(s), func(i int) bool { return s[i].last >= key })
+	return i < len(s) && s[i].first <= key
+}
+
+// add returns the set with key added
+func (s spans) add(key int) spans {
+	i := sort.Search(len(s), func(i int) bool { return s[i].last >= key-1 })
+	switch {
+	case i < len(s) && s[i].first <= key && key <= s[i].last:
+		return s
+	case i < len(s) && s[i].last == key-1:
+		// Extend the range before key, joining it with the next one
+		s[i].last = key
+		if i+1 < len(s) && s[i+1].first == key+1 {
+			s[i].last = s[i+1].last
+			s = append(s[:i+1], s[i+2:]...)
+		}
+		return s
+	case i < len(s) && s[i].first == key+1:
+		s[i].first = key
+		return s
+	}
+	s = append(s, span{})
+	copy(s[i+1:], s[i:])
+	s[i] = span{key, key}
+	return s
+}
+
+// String formats the set as e.g. "0-1500,1502"
+func (s spans) String() string {
+	parts := make([]string, 0, len(s))
+	for _, r := range s {
+		if r.first == r.last {
+			parts = append(parts, strconv.Itoa(r.first))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", r.first, r.last))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseSpans parses the output of spans.String
+func parseSpans(value string) (spans, error) {
+	var s spans
+	if value == "" {
+		return s, nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		first, last, found := strings.Cut(part, "-")
+		if !found {
+			last = first
+		}
+		a, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %s", part)
+		}
+		b, err := strconv.Atoi(last)
+		if err != nil || b < a || (len(s) > 0 && a <= s[len(s)-1].last) {
+			return nil, fmt.Errorf("invalid range %s", part)
+		}
+		s = append(s, span{a, b})
+	}
+	return s, nil
+}
@@ -0,0 +1,146 @@
+package modules
+
+import (
+	"GoReconX/internal/database"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+// newCheckpointStore creates a scan in a database in a temporary directory
+// and returns a store saving its checkpoints
+func newCheckpointStore(t *testing.T) *checkpointStore {
+	t.Helper()
+
+	// The database lives in the data directory below the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	db, err := database.InitDB()
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	scan, err := db.CreateScan(0, "port_scanning", "192.0.2.1", "{}")
+	if err != nil {
+		t.Fatalf("CreateScan: %v", err)
+	}
+	return &checkpointStore{mm: &ModuleManager{DB: db, Logger: quietLogger()}, scanID: scan.ID}
+}
+
+// stored returns the checkpoint saved for the scan of store
+func stored(t *testing.T, store *checkpointStore) string {
+	t.Helper()
+	scan, err := store.mm.DB.GetScan(store.scanID)
+	if err != nil {
+		t.Fatalf("GetScan: %v", err)
+	}
+	return scan.Checkpoint
+}
+
+func input(parts ...interface{}) func() (string, error) {
+	return func() (string, error) { return checkpointInput(parts...) }
+}
+
+func TestCheckpointResume(t *testing.T) {
+	store := newCheckpointStore(t)
+	cp, err := newCheckpoint[int](withCheckpoints(context.Background(), store), input("192.0.2.1", []int{22, 80, 443}))
+	if err != nil {
+		t.Fatalf("newCheckpoint: %v", err)
+	}
+	cp.Done(22, 22)
+	cp.Done(80)
+	cp.Flush()
+
+	tests := []struct {
+		name    string
+		input   func() (string, error)
+		wantErr bool
+	}{
+		{"same input", input("192.0.2.1", []int{22, 80, 443}), false},
+		{"other ports", input("192.0.2.1", []int{22, 80, 8080}), true},
+		{"other target", input("192.0.2.2", []int{22, 80, 443}), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resumed := &checkpointStore{mm: store.mm, scanID: store.scanID, resume: stored(t, store)}
+			cp, err := newCheckpoint[int](withCheckpoints(context.Background(), resumed), tt.input)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "different") {
+					t.Fatalf("err = %v, want the checkpoint refused", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newCheckpoint: %v", err)
+			}
+			if !cp.Finished(22) || !cp.Finished(80) || cp.Finished(443) {
+				t.Errorf("finished 22 %v, 80 %v, 443 %v, want 22 and 80", cp.Finished(22), cp.Finished(80), cp.Finished(443))
+			}
+			if restored := cp.Restored(); len(restored) != 1 || restored[0] != 22 {
+				t.Errorf("restored = %v, want [22]", restored)
+			}
+		})
+	}
+}
+
+func TestCheckpointKeepsLaterSnapshot(t *testing.T) {
+	store := newCheckpointStore(t)
+	cp, err := newCheckpoint[int](withCheckpoints(context.Background(), store), input())
+	if err != nil {
+		t.Fatalf("newCheckpoint: %v", err)
+	}
+
+	cp.Done(1)
+	cp.mu.Lock()
+	first, older := cp.snapshot()
+	cp.mu.Unlock()
+	cp.Done(2)
+	cp.Flush()
+
+	// A snapshot taken before the flush but written after it is dropped
+	cp.save(first, older)
+	if checkpoint := stored(t, store); !strings.Contains(checkpoint, `"completed":"1-2"`) {
+		t.Errorf("checkpoint = %s, want items 1-2 completed", checkpoint)
+	}
+}
+
+func TestCheckpointDoneConcurrently(t *testing.T) {
+	store := newCheckpointStore(t)
+	cp, err := newCheckpoint[int](withCheckpoints(context.Background(), store), input())
+	if err != nil {
+		t.Fatalf("newCheckpoint: %v", err)
+	}
+
+	done := make(chan struct{})
+	for worker := 0; worker < 4; worker++ {
+		worker := worker
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for key := worker; key < 400; key += 4 {
+				cp.Done(key, key)
+				if key%50 == 0 {
+					// Force a save while the others keep going
+					cp.mu.Lock()
+					cp.lastSave = cp.lastSave.Add(-checkpointInterval)
+					cp.mu.Unlock()
+				}
+			}
+		}()
+	}
+	for worker := 0; worker < 4; worker++ {
+		<-done
+	}
+	cp.Flush()
+
+	if checkpoint := stored(t, store); !strings.Contains(checkpoint, `"completed":"0-399"`) {
+		t.Errorf("checkpoint = %.80s..., want items 0-399 completed", checkpoint)
+	}
+}
@@ -0,0 +1,264 @@
+package modules
+
+import (
+	"GoReconX/internal/engine"
+	"GoReconX/internal/ratelimit"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// Ways a host is found to be up
+const (
+	DiscoveryTCP  = "tcp"
+	DiscoveryICMP = "icmp"
+	DiscoveryARP  = "arp"
+)
+
+// defaultDiscoveryPorts are ports that hosts commonly have open, or that
+// their firewalls at least reset rather than drop
+const defaultDiscoveryPorts = "21,22,25,80,135,139,443,445,3389,8080"
+
+// arpComplete is the flag of resolved entries in /proc/net/arp
+const arpComplete = 0x2
+
+// errNoICMP is returned when neither a raw ICMP socket nor an unprivileged
+// ping socket can be opened
+var errNoICMP = errors.New("ICMP sockets are not permitted")
+
+// hostDiscovery checks whether a host is up before its ports are scanned
+type hostDiscovery struct {
+	ports   []int
+	timeout time.Duration
+	icmp    bool
+	arp     bool
+	limiter *ratelimit.Limiter
+	logger  *logrus.Logger
+}
+
+// discoveryResult is how a host answered the discovery probes
+type discoveryResult struct {
+	Up bool
+	// Method is how the host was found up, e.g. tcp
+	Method string
+	// Reason is the answer that showed it, e.g. "port 443 refused"
+	Reason string
+	RTT    time.Duration
+	Probes int64
+}
+
+// discover sends TCP connect pings to the discovery ports and an ICMP echo
+// request to ip at once, and takes the first answer as proof the host is
+// up. A connection the host refuses counts, as only a live host resets it.
+// When nothing answers on a directly attached network, the ARP table is
+// consulted, since the probes made the kernel resolve the address.
+func (hd *hostDiscovery) discover(ctx context.Context, ip net.IP) discoveryResult {
+	address := ip.String()
+	start := time.Now()
+	var probes int64
+
+	type probe func(ctx context.Context) (string, string, bool)
+	var pings []probe
+	for _, port := range hd.ports {
+		port := port
+		pings = append(pings, func(ctx context.Context) (string, string, bool) {
+			atomic.AddInt64(&probes, 1)
+			state := hd.tcpPing(ctx, address, port)
+			return DiscoveryTCP, fmt.Sprintf("port %d %s", port, state), state != ""
+		})
+	}
+	if hd.icmp {
+		pings = append(pings, func(ctx context.Context) (string, string, bool) {
+			atomic.AddInt64(&probes, 1)
+			ok, err := hd.icmpEcho(ctx, ip)
+			if err != nil && ctx.Err() == nil {
+				hd.logger.WithFields(logrus.Fields{
+					"address": address,
+					"error":   err,
+				}).Debug("ICMP echo failed")
+			}
+			return DiscoveryICMP, "echo reply", ok
+		})
+	}
+
+	// The first answer settles it; the other probes are cut short
+	pingCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	job := engine.Run(pingCtx, len(pings), engine.Slice(pings), func(ctx context.Context, ping probe) (discoveryResult, bool) {
+		method, reason, ok := ping(ctx)
+		return discoveryResult{Up: true, Method: method, Reason: reason, RTT: time.Since(start)}, ok
+	})
+	var result discoveryResult
+	job.Collect(func(answer discoveryResult) {
+		if !result.Up {
+			result = answer
+			cancel()
+		}
+	})
+
+	if !result.Up && hd.arp && ctx.Err() == nil && ip.To4() != nil && onLocalNetwork(ip) {
+		atomic.AddInt64(&probes, 1)
+		if mac := arpEntry(ip); mac != "" {
+			result = discoveryResult{Up: true, Method: DiscoveryARP, Reason: "ARP entry " + mac, RTT: time.Since(start)}
+		}
+	}
+	result.Probes = atomic.LoadInt64(&probes)
+	return result
+}
+
+// tcpPing connects to port and returns "open" or "refused" if the host
+// answered, or "" if it did not
+func (hd *hostDiscovery) tcpPing(ctx context.Context, address string, port int) string {
+	if err := hd.limiter.WaitHost(ctx, address); err != nil {
+		return ""
+	}
+	dialer := &net.Dialer{Timeout: hd.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, strconv.Itoa(port)))
+	if err == nil {
+		conn.Close()
+		return "open"
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return "refused"
+	}
+	return ""
+}
+
+// icmpEcho sends an echo request to ip and reports whether it was answered.
+// It uses a raw socket where privileged, and otherwise an unprivileged ping
+// socket where the system allows them.
+func (hd *hostDiscovery) icmpEcho(ctx context.Context, ip net.IP) (bool, error) {
+	network, address, protocol := "ip4:icmp", "0.0.0.0", 1
+	var request, reply icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	if ip.To4() == nil {
+		network, address, protocol = "ip6:ipv6-icmp", "::", 58
+		request, reply = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+	}
+
+	privileged := true
+	conn, err := icmp.ListenPacket(network, address)
+	if err != nil {
+		privileged = false
+		if ip.To4() != nil {
+			conn, err = icmp.ListenPacket("udp4", address)
+		} else {
+			conn, err = icmp.ListenPacket("udp6", address)
+		}
+		if err != nil {
+			return false, errNoICMP
+		}
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// Ping sockets choose the identifier themselves, so replies are told
+	// apart by their sequence number and sender
+	id, seq := os.Getpid()&0xffff, rand.Intn(1<<16)
+	message := icmp.Message{Type: request, Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("GoReconX")}}
+	data, err := message.Marshal(nil)
+	if err != nil {
+		return false, err
+	}
+	var destination net.Addr = &net.IPAddr{IP: ip}
+	if !privileged {
+		destination = &net.UDPAddr{IP: ip}
+	}
+
+	if err := hd.limiter.WaitHost(ctx, ip.String()); err != nil {
+		return false, err
+	}
+	if _, err := conn.WriteTo(data, destination); err != nil {
+		return false, err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(hd.timeout))
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) || ctx.Err() != nil {
+				return false, nil
+			}
+			return false, err
+		}
+		if !sameHost(peer, ip) {
+			continue
+		}
+		answer, err := icmp.ParseMessage(protocol, buf[:n])
+		if err != nil || answer.Type != reply {
+			continue
+		}
+		if echo, ok := answer.Body.(*icmp.Echo); ok && echo.Seq == seq && (!privileged || echo.ID == id) {
+			return true, nil
+		}
+	}
+}
+
+// sameHost reports whether the sender of a packet is ip
+func sameHost(peer net.Addr, ip net.IP) bool {
+	switch addr := peer.(type) {
+	case *net.IPAddr:
+		return addr.IP.Equal(ip)
+	case *net.UDPAddr:
+		return addr.IP.Equal(ip)
+	}
+	return false
+}
+
+// onLocalNetwork reports whether ip is on a network an interface is
+// attached to, where hosts answer ARP requests. Loopback addresses are not.
+func onLocalNetwork(ip net.IP) bool {
+	if ip.IsLoopback() {
+		return false
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if network, ok := addr.(*net.IPNet); ok && !network.IP.IsLoopback() && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// arpEntry returns the hardware address of the resolved entry for ip in
+// the kernel's ARP table, or "" if there is none. Only Linux exposes the
+// table, in /proc/net/arp.
+func arpEntry(ip net.IP) string {
+	file, err := os.Open("/proc/net/arp")
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	// IP address, HW type, Flags, HW address, Mask, Device
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !ip.Equal(net.ParseIP(fields[0])) {
+			continue
+		}
+		flags, err := strconv.ParseInt(fields[2], 0, 64)
+		if err == nil && flags&arpComplete != 0 && fields[3] != "00:00:00:00:00:00" {
+			return fields[3]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package modules
+
+import (
+	"GoReconX/internal/config"
+	"context"
+	"net"
+	"strconv"
+	"testing"
+)
+
+func TestPortScannerDiscoversResolvedHost(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	ps := NewPortScanner(config.DefaultConfig(), quietLogger())
+	options, err := ps.GetOptionSchema().Validate(map[string]interface{}{
+		"ports":             port,
+		"host_discovery":    true,
+		"discovery_ports":   port,
+		"discovery_icmp":    false,
+		"service_detection": false,
+		"tls_inspection":    false,
+	})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	// Without a scope the domain is still resolved before discovery
+	result, err := ps.Execute(context.Background(), "localhost", options)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if result.Status != StatusCompleted {
+		t.Errorf("status = %s, want %s", result.Status, StatusCompleted)
+	}
+	if address := result.Metadata["address"]; net.ParseIP(address.(string)) == nil {
+		t.Errorf("address = %v, want an IP address", address)
+	}
+	if result.Metadata["host_up"] != true || result.Metadata["discovery_method"] != DiscoveryTCP {
+		t.Errorf("host_up = %v by %v, want up by tcp", result.Metadata["host_up"], result.Metadata["discovery_method"])
+	}
+	if result.Metadata["open_ports"] != 1 {
+		t.Errorf("open_ports = %v, want 1", result.Metadata["open_ports"])
+	}
+}
+
+func TestTargetResultsCountDiscovery(t *testing.T) {
+	results := &TargetResults{}
+	for _, metadata := range []map[string]interface{}{
+		{"host_up": true, "discovery_method": DiscoveryTCP},
+		{"host_up": true, "discovery_method": DiscoveryTCP},
+		{"host_up": true, "discovery_method": DiscoveryARP},
+		{"host_up": false, "skip_reason": "host down"},
+		{"open_ports": 3},
+		nil,
+	} {
+		results.countDiscovery(&ScanResult{Metadata: metadata})
+	}
+
+	stats := results.Discovery
+	if stats == nil {
+		t.Fatal("no discovery statistics")
+	}
+	if stats.Probed != 4 || stats.Up != 3 || stats.Down != 1 {
+		t.Errorf("probed %d, up %d, down %d, want 4, 3, 1", stats.Probed, stats.Up, stats.Down)
+	}
+	if stats.Methods[DiscoveryTCP] != 2 || stats.Methods[DiscoveryARP] != 1 || len(stats.Methods) != 2 {
+		t.Errorf("methods = %v, want tcp 2 and arp 1", stats.Methods)
+	}
+
+	none := &TargetResults{}
+	none.countDiscovery(&ScanResult{})
+	if none.Discovery != nil {
+		t.Errorf("discovery = %+v for results without discovery, want nil", none.Discovery)
+	}
+}
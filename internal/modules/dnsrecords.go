@@ -0,0 +1,327 @@
+package modules
+
+import (
+	"GoReconX/internal/config"
+	"GoReconX/internal/dns"
+	"GoReconX/internal/engine"
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func init() {
+	Register(ModuleInfo{
+		ID:             "dns_records",
+		Name:           "DNS Record Enumerator",
+		Category:       CategoryPassive,
+		Description:    "Collects A, AAAA, CNAME, MX, NS, TXT, SOA, SRV, CAA and PTR records",
+		RequiredInputs: []string{InputDomain},
+	}, func(cfg *config.Config, logger *logrus.Logger) ModuleInterface {
+		return NewDNSRecordEnumerator(cfg, logger)
+	})
+}
+
+// srvServices are the common SRV service names looked up below a domain
+var srvServices = []string{
+	"_ldap._tcp", "_ldaps._tcp", "_gc._tcp", "_kerberos._tcp", "_kerberos._udp",
+	"_kpasswd._tcp", "_kpasswd._udp", "_sip._tcp", "_sip._udp", "_sip._tls",
+	"_sips._tcp", "_sipfederationtls._tcp", "_xmpp-client._tcp", "_xmpp-server._tcp",
+	"_jabber._tcp", "_caldav._tcp", "_caldavs._tcp", "_carddav._tcp", "_carddavs._tcp",
+	"_imap._tcp", "_imaps._tcp", "_pop3._tcp", "_pop3s._tcp", "_submission._tcp",
+	"_submissions._tcp", "_smtp._tcp", "_autodiscover._tcp", "_matrix._tcp",
+	"_mongodb._tcp", "_http._tcp", "_https._tcp", "_ftp._tcp", "_ssh._tcp",
+	"_ntp._udp", "_stun._udp", "_stun._tcp", "_turn._udp", "_turn._tcp",
+	"_h323cs._tcp", "_vlmcs._tcp", "_minecraft._tcp", "_ts3._udp",
+}
+
+// DNSRecordResult is a DNS record found for the target
+type DNSRecordResult struct {
+	dns.Record
+}
+
+// String returns a short human readable form of the result
+func (dr *DNSRecordResult) String() string {
+	return fmt.Sprintf("%s %s %s", dr.Name, dr.Type, dr.Value)
+}
+
+// ResultType identifies DNS record findings in the results table
+func (dr *DNSRecordResult) ResultType() string {
+	return "dns_record"
+}
+
+// DNSRecordEnumerator collects the DNS records of a domain
+type DNSRecordEnumerator struct {
+	config *config.Config
+	logger *logrus.Logger
+}
+
+// NewDNSRecordEnumerator creates a new DNS record enumerator
+func NewDNSRecordEnumerator(cfg *config.Config, logger *logrus.Logger) *DNSRecordEnumerator {
+	return &DNSRecordEnumerator{config: cfg, logger: logger}
+}
+
+// GetName returns the module name
+func (de *DNSRecordEnumerator) GetName() string {
+	return "DNS Record Enumerator"
+}
+
+// GetDescription returns the module description
+func (de *DNSRecordEnumerator) GetDescription() string {
+	return "Collects A, AAAA, CNAME, MX, NS, TXT, SOA, SRV, CAA and PTR records"
+}
+
+// Validate validates the target domain or IP address
+func (de *DNSRecordEnumerator) Validate(target string) error {
+	if target == "" {
+		return fmt.Errorf("target cannot be empty")
+	}
+	if net.ParseIP(target) == nil && !strings.Contains(target, ".") {
+		return fmt.Errorf("invalid domain format")
+	}
+	return nil
+}
+
+// GetOptionSchema returns the options accepted by the module
+func (de *DNSRecordEnumerator) GetOptionSchema() OptionSchema {
+	return OptionSchema{
+		{Name: "types", Type: OptionString, Default: "all", Description: "Record types to collect, e.g. MX,TXT,CAA, or all"},
+		{Name: "srv_services", Type: OptionBool, Default: true, Description: "Look up common SRV services such as _ldap._tcp and _sip._tls"},
+		{Name: "threads", Type: OptionInt, Default: 10, Range: &OptionRange{Min: 1, Max: 200}, Description: "Number of concurrent DNS queries"},
+		{Name: "timeout", Type: OptionInt, Default: 5, Range: &OptionRange{Min: 1, Max: 60}, Description: "DNS query timeout in seconds"},
+	}
+}
+
+// Execute collects the records of the target. The records of an IP address
+// target are its PTR records.
+func (de *DNSRecordEnumerator) Execute(ctx context.Context, target string, options Options) (*ScanResult, error) {
+	startTime := time.Now()
+	de.logger.WithField("target", target).Info("Starting DNS record enumeration")
+
+	result := &ScanResult{
+		ModuleName: de.GetName(),
+		Target:     target,
+		Status:     StatusRunning,
+		StartTime:  startTime.Format(time.RFC3339),
+		Metadata:   make(map[string]interface{}),
+	}
+	fail := func(err error) (*ScanResult, error) {
+		result.Status = StatusFailed
+		result.ErrorMessage = err.Error()
+		result.EndTime = time.Now().Format(time.RFC3339)
+		return result, err
+	}
+
+	types, err := parseRecordTypes(options.String("types"))
+	if err != nil {
+		return fail(err)
+	}
+	pool, err := recordPool(ctx, de.config, de.logger)
+	if err != nil {
+		return fail(err)
+	}
+
+	collector := &recordCollector{
+		pool:        pool,
+		timeout:     time.Duration(options.Int("timeout")) * time.Second,
+		threads:     options.Int("threads"),
+		srvServices: options.Bool("srv_services"),
+	}
+	var records []dns.Record
+	if ip := net.ParseIP(target); ip != nil {
+		records, err = collector.reverse(ctx, []string{ip.String()})
+	} else {
+		records, err = collector.collect(ctx, target, types)
+	}
+
+	counts := make(map[string]int)
+	var services []string
+	for _, record := range records {
+		counts[record.Type]++
+		if record.Type == "SRV" {
+			services = appendUnique(services, record.Name)
+		}
+		result.Results = append(result.Results, &DNSRecordResult{Record: record})
+	}
+
+	endTime := time.Now()
+	result.Status = StatusCompleted
+	result.EndTime = endTime.Format(time.RFC3339)
+	result.Metadata["records"] = counts
+	result.Metadata["cname_chain"] = dns.Chain(records, target)
+	result.Metadata["srv_services"] = services
+	result.Metadata["duration_seconds"] = endTime.Sub(startTime).Seconds()
+
+	if err := ctx.Err(); err != nil {
+		result.Status = StatusCancelled
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+	if err != nil && len(records) == 0 {
+		return fail(err)
+	}
+
+	de.logger.WithFields(logrus.Fields{
+		"target":   target,
+		"records":  len(records),
+		"duration": endTime.Sub(startTime),
+	}).Info("DNS record enumeration completed")
+
+	return result, nil
+}
+
+// parseRecordTypes parses a comma-separated list of record types, or "all"
+func parseRecordTypes(spec string) ([]dnsmessage.Type, error) {
+	if strings.EqualFold(strings.TrimSpace(spec), "all") {
+		return dns.RecordTypes, nil
+	}
+
+	var types []dnsmessage.Type
+	for _, name := range strings.Split(spec, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		qtype, err := dns.ParseType(name)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, qtype)
+	}
+	return types, nil
+}
+
+// recordPool returns the resolvers to query records with: the configured
+// pool, or the system's name servers
+func recordPool(ctx context.Context, cfg *config.Config, logger *logrus.Logger) (*dns.Pool, error) {
+	if pool := dns.FromContext(ctx); pool != nil {
+		return pool, nil
+	}
+	return dns.SystemPool(cfg.Network.Retries, logger)
+}
+
+// recordCollector looks up the records of names
+type recordCollector struct {
+	pool        *dns.Pool
+	timeout     time.Duration
+	threads     int
+	srvServices bool
+}
+
+// recordQuery is a single query of a collection
+type recordQuery struct {
+	name  string
+	qtype dnsmessage.Type
+}
+
+// collect looks up the records of the given types for name. SRV records are
+// looked up for the common service names below name and PTR records for
+// every address found. Failed queries are skipped; the last error is
+// returned along with the records that were found.
+func (rc *recordCollector) collect(ctx context.Context, name string, types []dnsmessage.Type) ([]dns.Record, error) {
+	var queries []recordQuery
+	reverse := false
+	for _, qtype := range types {
+		switch qtype {
+		case dnsmessage.TypePTR:
+			reverse = true
+		case dnsmessage.TypeSRV:
+			if rc.srvServices {
+				for _, service := range srvServices {
+					queries = append(queries, recordQuery{service + "." + name, qtype})
+				}
+			}
+		default:
+			queries = append(queries, recordQuery{name, qtype})
+		}
+	}
+
+	records, err := rc.run(ctx, queries)
+
+	if reverse {
+		var addresses []string
+		for _, record := range records {
+			if record.Type == "A" || record.Type == "AAAA" {
+				addresses = appendUnique(addresses, record.Value)
+			}
+		}
+		ptrs, ptrErr := rc.reverse(ctx, addresses)
+		records = append(records, ptrs...)
+		if ptrErr != nil {
+			err = ptrErr
+		}
+	}
+
+	return records, err
+}
+
+// reverse looks up the PTR records of addresses
+func (rc *recordCollector) reverse(ctx context.Context, addresses []string) ([]dns.Record, error) {
+	var queries []recordQuery
+	for _, address := range addresses {
+		if name, err := dns.ReverseName(net.ParseIP(address)); err == nil {
+			queries = append(queries, recordQuery{name, dnsmessage.TypePTR})
+		}
+	}
+	return rc.run(ctx, queries)
+}
+
+// run sends queries in parallel and returns the distinct records answered
+func (rc *recordCollector) run(ctx context.Context, queries []recordQuery) ([]dns.Record, error) {
+	type answer struct {
+		records []dns.Record
+		err     error
+	}
+
+	job := engine.Run(ctx, rc.threads, engine.Slice(queries), func(ctx context.Context, q recordQuery) (answer, bool) {
+		queryCtx, cancel := context.WithTimeout(ctx, rc.timeout)
+		defer cancel()
+
+		records, err := rc.pool.Lookup(queryCtx, q.name, q.qtype)
+		return answer{records, err}, ctx.Err() == nil
+	})
+
+	var records []dns.Record
+	var lastErr error
+	seen := make(map[dns.Record]bool)
+	job.Collect(func(a answer) {
+		if a.err != nil {
+			lastErr = a.err
+		}
+		for _, record := range a.records {
+			// Answers for different types repeat e.g. the CNAME chain
+			key := record
+			key.TTL = 0
+			if !seen[key] {
+				seen[key] = true
+				records = append(records, record)
+			}
+		}
+	})
+
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Value < b.Value
+	})
+	return records, lastErr
+}
+
+// appendUnique appends value to list unless it is already in it
+func appendUnique(list []string, value string) []string {
+	for _, item := range list {
+		if item == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
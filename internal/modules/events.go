@@ -0,0 +1,157 @@
+package modules
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EventType identifies the kind of event emitted while a module runs
+type EventType string
+
+const (
+	EventStarted  EventType = "started"
+	EventProgress EventType = "progress"
+	EventFinding  EventType = "finding"
+	EventFinished EventType = "finished"
+)
+
+// progressInterval limits how often progress events are emitted
+const progressInterval = 250 * time.Millisecond
+
+// Event is a live update from a running module
+type Event struct {
+	Type      EventType     `json:"type"`
+	ModuleID  string        `json:"module_id"`
+	Module    string        `json:"module"`
+	Target    string        `json:"target"`
+	Done      int64         `json:"done,omitempty"`
+	Total     int64         `json:"total,omitempty"`
+	Elapsed   time.Duration `json:"elapsed"`
+	Finding   interface{}   `json:"finding,omitempty"`
+	Result    *ScanResult   `json:"result,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// ETA estimates the remaining run time from the progress so far. It returns
+// zero when there is not enough information to make an estimate.
+func (e Event) ETA() time.Duration {
+	if e.Done <= 0 || e.Total <= e.Done {
+		return 0
+	}
+	perItem := e.Elapsed / time.Duration(e.Done)
+	return perItem * time.Duration(e.Total-e.Done)
+}
+
+// EventHandler receives events from a running module
+type EventHandler func(Event)
+
+type eventHandlerKey struct{}
+
+// WithEventHandler returns a context that delivers module events to handler.
+// Events for one scan are delivered one at a time, never concurrently.
+func WithEventHandler(ctx context.Context, handler EventHandler) context.Context {
+	return context.WithValue(ctx, eventHandlerKey{}, handler)
+}
+
+// eventHandlerFrom returns the event handler attached to ctx, if any
+func eventHandlerFrom(ctx context.Context) EventHandler {
+	handler, _ := ctx.Value(eventHandlerKey{}).(EventHandler)
+	return handler
+}
+
+// progressReporter emits progress and finding events for a single scan. It
+// is safe for use from multiple worker goroutines and does nothing when no
+// event handler is attached to the context.
+type progressReporter struct {
+	handler EventHandler
+	module  string
+	target  string
+	started time.Time
+
+	mu       sync.Mutex
+	done     int64
+	total    int64
+	lastEmit time.Time
+}
+
+// newProgressReporter creates a reporter for module running against target
+func newProgressReporter(ctx context.Context, module, target string, total int) *progressReporter {
+	return &progressReporter{
+		handler: eventHandlerFrom(ctx),
+		module:  module,
+		target:  target,
+		started: time.Now(),
+		total:   int64(total),
+	}
+}
+
+// SetTotal updates the number of work items once it is known
+func (pr *progressReporter) SetTotal(total int) {
+	pr.mu.Lock()
+	pr.total = int64(total)
+	pr.mu.Unlock()
+}
+
+// Grow adds n work items that turned up while the scan was running
+func (pr *progressReporter) Grow(n int) {
+	pr.mu.Lock()
+	pr.total += int64(n)
+	pr.mu.Unlock()
+}
+
+// Advance marks n work items as done, emitting a throttled progress event
+func (pr *progressReporter) Advance(n int) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	pr.done += int64(n)
+	pr.emitProgress()
+}
+
+// Update sets the absolute progress, for modules that report it that way
+func (pr *progressReporter) Update(done, total int) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	pr.done = int64(done)
+	pr.total = int64(total)
+	pr.emitProgress()
+}
+
+// emitProgress emits a progress event unless one was sent very recently;
+// pr.mu must be held
+func (pr *progressReporter) emitProgress() {
+	if pr.handler == nil {
+		return
+	}
+
+	now := time.Now()
+	if pr.done < pr.total && now.Sub(pr.lastEmit) < progressInterval {
+		return
+	}
+	pr.lastEmit = now
+	pr.emit(Event{Type: EventProgress})
+}
+
+// Finding emits a finding event as soon as a result is discovered
+func (pr *progressReporter) Finding(finding interface{}) {
+	if pr.handler == nil {
+		return
+	}
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	pr.emit(Event{Type: EventFinding, Finding: finding})
+}
+
+// emit fills in the common fields and delivers event; pr.mu must be held
+func (pr *progressReporter) emit(event Event) {
+	event.Module = pr.module
+	event.Target = pr.target
+	event.Done = pr.done
+	event.Total = pr.total
+	event.Elapsed = time.Since(pr.started)
+	event.Timestamp = time.Now()
+	pr.handler(event)
+}
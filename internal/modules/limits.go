@@ -0,0 +1,71 @@
+package modules
+
+import (
+	"GoReconX/internal/ratelimit"
+)
+
+// RateLimitOptions are accepted by every module on top of its own options
+// and override the rate limit settings from the config for a single scan
+var RateLimitOptions = OptionSchema{
+	{Name: "rate_limit", Type: OptionFloat, Range: &OptionRange{Min: 0, Max: 1e6}, Description: "Maximum requests per second for the whole scan, 0 for no limit"},
+	{Name: "host_rate_limit", Type: OptionFloat, Range: &OptionRange{Min: 0, Max: 1e6}, Description: "Maximum requests per second to a single host, 0 for no limit"},
+	{Name: "resolver_rate_limit", Type: OptionFloat, Range: &OptionRange{Min: 0, Max: 1e6}, Description: "Maximum queries per second to a single DNS resolver, 0 for no limit"},
+	{Name: "burst", Type: OptionInt, Range: &OptionRange{Min: 1, Max: 10000}, Description: "Requests allowed in a burst above the rate limits"},
+	{Name: "min_delay_ms", Type: OptionInt, Range: &OptionRange{Min: 0, Max: 60000}, Description: "Minimum random delay before each request in milliseconds"},
+	{Name: "max_delay_ms", Type: OptionInt, Range: &OptionRange{Min: 0, Max: 60000}, Description: "Maximum random delay before each request in milliseconds"},
+}
+
+// OptionSchema returns every option a scan with the module accepts: the
+// module's own options followed by RateLimitOptions
+func (mm *ModuleManager) OptionSchema(moduleID string) OptionSchema {
+	mm.mu.RLock()
+	module, exists := mm.modules[moduleID]
+	mm.mu.RUnlock()
+	if !exists {
+		return nil
+	}
+	return withRateLimitOptions(module.GetOptionSchema())
+}
+
+// withRateLimitOptions appends RateLimitOptions to a copy of schema
+func withRateLimitOptions(schema OptionSchema) OptionSchema {
+	combined := make(OptionSchema, 0, len(schema)+len(RateLimitOptions))
+	combined = append(combined, schema...)
+	return append(combined, RateLimitOptions...)
+}
+
+// scanLimiter removes the rate limit overrides from options and returns the
+// limiter the scan should use. Scans without overrides share the manager's
+// limiter; a scan with overrides gets its own.
+func (mm *ModuleManager) scanLimiter(options Options) *ratelimit.Limiter {
+	settings := mm.limiter.Settings()
+	overridden := false
+
+	for _, spec := range RateLimitOptions {
+		if _, ok := options[spec.Name]; !ok {
+			continue
+		}
+		overridden = true
+
+		switch spec.Name {
+		case "rate_limit":
+			settings.GlobalRPS = options.Float(spec.Name)
+		case "host_rate_limit":
+			settings.PerHostRPS = options.Float(spec.Name)
+		case "resolver_rate_limit":
+			settings.PerResolverRPS = options.Float(spec.Name)
+		case "burst":
+			settings.Burst = options.Int(spec.Name)
+		case "min_delay_ms":
+			settings.MinDelay = options.Int(spec.Name)
+		case "max_delay_ms":
+			settings.MaxDelay = options.Int(spec.Name)
+		}
+		delete(options, spec.Name)
+	}
+
+	if !overridden {
+		return mm.limiter
+	}
+	return ratelimit.New(settings)
+}
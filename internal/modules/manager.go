@@ -4,7 +4,15 @@ import (
 	"GoReconX/internal/config"
 	"GoReconX/internal/database"
 	"GoReconX/internal/ai"
+	"GoReconX/internal/dns"
+	"GoReconX/internal/engine"
+	"GoReconX/internal/ratelimit"
+	"GoReconX/internal/scope"
+	"context"
+	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -17,33 +25,63 @@ type ModuleManager struct {
 	
 	// AI client
 	AIClient       *ai.GeminiClient
+
+	// ProjectID is the project that scans and their findings are stored under
+	ProjectID int
 	
-	// Module instances
-	SubdomainEnum    *SubdomainEnumerator
-	EmailHarvester   *EmailHarvester
-	PortScanner      *PortScanner
-	DirEnumerator    *DirectoryEnumerator
-	WebAnalyzer      *WebAnalyzer
-	IPGeolocation    *IPGeolocator
-	GitHubRecon      *GitHubRecon
+	// Module instances and their descriptions, keyed by module ID
+	mu      sync.RWMutex
+	modules map[string]ModuleInterface
+	infos   map[string]ModuleInfo
+
+	// Engagement scope enforced on every scan, nil for none
+	scope *scope.Scope
+
+	// Rate limiter shared by all scans without rate limit overrides
+	limiter *ratelimit.Limiter
+
+	// DNS resolvers to query, nil to use the system resolver
+	resolvers *dns.Pool
+
+	// One slot per target that may be scanned at the same time
+	targetSlots chan struct{}
 }
 
-// NewModuleManager creates a new module manager instance
+// NewModuleManager creates a new module manager instance with one instance
+// of every module in the registry
 func NewModuleManager(db *database.DB, cfg *config.Config, logger *logrus.Logger) *ModuleManager {
 	mm := &ModuleManager{
 		DB:     db,
 		Config: cfg,
 		Logger: logger,
-		
-		// Initialize modules
-		SubdomainEnum:    NewSubdomainEnumerator(cfg, logger),
-		EmailHarvester:   NewEmailHarvester(cfg, logger),
-		PortScanner:      NewPortScanner(cfg, logger),
-		DirEnumerator:    NewDirectoryEnumerator(cfg, logger),
-		WebAnalyzer:      NewWebAnalyzer(cfg, logger),
-		IPGeolocation:    NewIPGeolocator(cfg, logger),
-		GitHubRecon:      NewGitHubRecon(cfg, logger),
+
+		modules: make(map[string]ModuleInterface),
+		infos:   make(map[string]ModuleInfo),
+		limiter: ratelimit.New(cfg.Network.RateLimit),
 	}
+
+	parallelTargets := cfg.Network.MaxParallelTargets
+	if parallelTargets < 1 {
+		parallelTargets = 1
+	}
+	mm.targetSlots = make(chan struct{}, parallelTargets)
+
+	if cfg.Network.Resolvers != "" {
+		pool, err := dns.LoadPool(cfg.Network.Resolvers, cfg.Network.Retries, logger)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to load DNS resolvers, using the system resolver")
+		} else {
+			mm.resolvers = pool
+		}
+	}
+
+	// Initialize modules
+	registryMu.RLock()
+	for id, reg := range registry {
+		mm.modules[id] = reg.factory(cfg, logger)
+		mm.infos[id] = reg.info
+	}
+	registryMu.RUnlock()
 	
 	// Initialize AI client if API key is available
 	if cfg.API.GeminiKey != "" {
@@ -59,24 +97,157 @@ func NewModuleManager(db *database.DB, cfg *config.Config, logger *logrus.Logger
 	return mm
 }
 
-// GetAvailableModules returns a list of all available modules
+// AddModule makes a module instance available on this manager only
+func (mm *ModuleManager) AddModule(info ModuleInfo, module ModuleInterface) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if _, exists := mm.modules[info.ID]; exists {
+		return fmt.Errorf("module already exists: %s", info.ID)
+	}
+	mm.modules[info.ID] = module
+	mm.infos[info.ID] = info
+	return nil
+}
+
+// GetAvailableModules returns all available modules keyed by module ID
 func (mm *ModuleManager) GetAvailableModules() map[string]ModuleInterface {
-	return map[string]ModuleInterface{
-		"subdomain_enumeration": mm.SubdomainEnum,
-		"email_harvesting":      mm.EmailHarvester,
-		"port_scanning":         mm.PortScanner,
-		"directory_enumeration": mm.DirEnumerator,
-		"web_analysis":          mm.WebAnalyzer,
-		"ip_geolocation":        mm.IPGeolocation,
-		"github_reconnaissance": mm.GitHubRecon,
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	modules := make(map[string]ModuleInterface, len(mm.modules))
+	for id, module := range mm.modules {
+		modules[id] = module
 	}
+	return modules
 }
 
-// ExecuteModule executes a specific module
-func (mm *ModuleManager) ExecuteModule(moduleName, target string, options map[string]interface{}) (*ScanResult, error) {
-	modules := mm.GetAvailableModules()
-	
-	module, exists := modules[moduleName]
+// GetModuleInfo returns the description of a module
+func (mm *ModuleManager) GetModuleInfo(moduleID string) (ModuleInfo, bool) {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	info, ok := mm.infos[moduleID]
+	return info, ok
+}
+
+// ListModules returns the descriptions of all modules in category, or of
+// every module if category is empty, sorted by category and name
+func (mm *ModuleManager) ListModules(category ModuleCategory) []ModuleInfo {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	var infos []ModuleInfo
+	for _, info := range mm.infos {
+		if category == "" || info.Category == category {
+			infos = append(infos, info)
+		}
+	}
+	sortModuleInfos(infos)
+	return infos
+}
+
+// Scope returns the engagement scope enforced on scans, or nil if there is none
+func (mm *ModuleManager) Scope() *scope.Scope {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	return mm.scope
+}
+
+// SetScope changes the engagement scope and stores it for the current
+// project. A nil scope removes all restrictions.
+func (mm *ModuleManager) SetScope(s *scope.Scope) error {
+	if mm.DB != nil {
+		definition, err := json.Marshal(s.Definition())
+		if err != nil {
+			return err
+		}
+		if err := mm.DB.SaveScope(mm.ProjectID, string(definition)); err != nil {
+			return fmt.Errorf("failed to save scope: %v", err)
+		}
+	}
+
+	mm.mu.Lock()
+	mm.scope = s
+	mm.mu.Unlock()
+	return nil
+}
+
+// LoadScope loads the engagement scope stored for the current project
+func (mm *ModuleManager) LoadScope() error {
+	if mm.DB == nil {
+		return nil
+	}
+
+	stored, err := mm.DB.GetScope(mm.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to load scope: %v", err)
+	}
+
+	var s *scope.Scope
+	if stored != "" {
+		var def scope.Definition
+		if err := json.Unmarshal([]byte(stored), &def); err != nil {
+			return fmt.Errorf("invalid stored scope: %v", err)
+		}
+		if s, err = scope.New(def); err != nil {
+			return err
+		}
+	}
+
+	mm.mu.Lock()
+	mm.scope = s
+	mm.mu.Unlock()
+	return nil
+}
+
+// ExecuteModule executes a specific module. Cancelling ctx stops the module
+// and returns whatever it collected so far with status "cancelled".
+func (mm *ModuleManager) ExecuteModule(ctx context.Context, moduleName, target string, options map[string]interface{}) (*ScanResult, error) {
+	return mm.execute(ctx, moduleName, target, options, nil)
+}
+
+// ResumeScan continues an interrupted scan from its last checkpoint with
+// the options it was started with, without repeating the work finished
+// before the interruption. The scan keeps its ID and record.
+func (mm *ModuleManager) ResumeScan(ctx context.Context, scanID int) (*ScanResult, error) {
+	if mm.DB == nil {
+		return nil, fmt.Errorf("resuming scans requires a database")
+	}
+
+	scan, err := mm.DB.GetScan(scanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load scan %d: %v", scanID, err)
+	}
+	if scan.Status == StatusCompleted {
+		return nil, fmt.Errorf("scan %d has already completed", scanID)
+	}
+	if scan.Checkpoint == "" {
+		return nil, fmt.Errorf("scan %d has no checkpoint to resume from", scanID)
+	}
+
+	var options map[string]interface{}
+	if scan.Options != "" {
+		if err := json.Unmarshal([]byte(scan.Options), &options); err != nil {
+			return nil, fmt.Errorf("invalid options of scan %d: %v", scanID, err)
+		}
+	}
+
+	mm.Logger.WithFields(logrus.Fields{
+		"scan_id": scanID,
+		"module":  scan.ScanType,
+		"target":  scan.Target,
+	}).Info("Resuming scan")
+	return mm.execute(ctx, scan.ScanType, scan.Target, options, scan)
+}
+
+// execute runs a module, continuing the stored scan resume if it is set
+func (mm *ModuleManager) execute(ctx context.Context, moduleName, target string, options map[string]interface{}, resume *database.Scan) (*ScanResult, error) {
+	mm.mu.RLock()
+	module, exists := mm.modules[moduleName]
+	info := mm.infos[moduleName]
+	engagement := mm.scope
+	mm.mu.RUnlock()
 	if !exists {
 		return nil, fmt.Errorf("module not found: %s", moduleName)
 	}
@@ -85,9 +256,93 @@ func (mm *ModuleManager) ExecuteModule(moduleName, target string, options map[st
 	if err := module.Validate(target); err != nil {
 		return nil, fmt.Errorf("target validation failed: %v", err)
 	}
+
+	// Validate options and coerce them to their declared types, then take
+	// out the rate limit overrides that are handled here
+	validated, err := withRateLimitOptions(module.GetOptionSchema()).Validate(options)
+	if err != nil {
+		return nil, fmt.Errorf("option validation failed: %v", err)
+	}
+	stored := make(Options, len(validated))
+	for name, value := range validated {
+		stored[name] = value
+	}
+	ctx = ratelimit.NewContext(ctx, mm.scanLimiter(validated))
+	ctx = dns.NewContext(ctx, mm.resolvers)
+
+	// Never let active modules touch a target outside the engagement scope.
+	// Modules re-check every host they derive from the target themselves.
+	if info.Category == CategoryActive {
+		if err := engagement.Check(target); err != nil {
+			mm.Logger.WithFields(logrus.Fields{
+				"module": moduleName,
+				"target": target,
+			}).Warn("Refusing to scan out-of-scope target")
+			return nil, err
+		}
+	}
+	ctx = scope.NewContext(ctx, engagement)
 	
-	// Execute module
-	return module.Execute(target, options)
+	// Don't start work for a request that is already cancelled
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Tag events from the module with its ID and announce the run
+	handler := eventHandlerFrom(ctx)
+	if handler != nil {
+		ctx = WithEventHandler(ctx, func(event Event) {
+			event.ModuleID = moduleName
+			handler(event)
+		})
+		handler(Event{
+			Type:      EventStarted,
+			ModuleID:  moduleName,
+			Module:    module.GetName(),
+			Target:    target,
+			Timestamp: time.Now(),
+		})
+	}
+
+	// Execute module, saving its progress with the scan record
+	var scan *database.Scan
+	if resume != nil {
+		scan = resume
+		mm.resumeScanRecord(scan)
+		ctx = withCheckpoints(ctx, &checkpointStore{mm: mm, scanID: scan.ID, resume: scan.Checkpoint})
+	} else if scan = mm.startScanRecord(ctx, moduleName, target, stored); scan != nil {
+		ctx = withCheckpoints(ctx, &checkpointStore{mm: mm, scanID: scan.ID})
+	}
+	startTime := time.Now()
+	result, err := module.Execute(ctx, target, validated)
+	if result != nil {
+		result.ModuleID = moduleName
+		if scan != nil {
+			result.ScanID = scan.ID
+		}
+	}
+	mm.finishScanRecord(scan, result, err)
+
+	if handler != nil {
+		handler(Event{
+			Type:      EventFinished,
+			ModuleID:  moduleName,
+			Module:    module.GetName(),
+			Target:    target,
+			Elapsed:   time.Since(startTime),
+			Result:    result,
+			Timestamp: time.Now(),
+		})
+	}
+
+	return result, err
+}
+
+// StreamModule runs a module in the background and returns a channel of its
+// events. The last event is always EventFinished, after which the channel is
+// closed. Callers must drain the channel; cancel ctx to stop early.
+func (mm *ModuleManager) StreamModule(ctx context.Context, moduleName, target string, options map[string]interface{}) <-chan Event {
+	return mm.StreamTargets(ctx, moduleName, engine.Slice([]string{target}), options)
 }
 
 // Close closes any open connections
@@ -98,8 +353,22 @@ func (mm *ModuleManager) Close() error {
 	return nil
 }
 
+// Scan statuses reported in ScanResult.Status
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+	// StatusSkipped is a scan that found nothing to scan, e.g. a port scan
+	// of a host that answered no discovery probe
+	StatusSkipped = "skipped"
+)
+
 // ScanResult represents the result of a scan operation
 type ScanResult struct {
+	// ScanID is the ID of the stored scan, used to resume it
+	ScanID       int                    `json:"scan_id,omitempty"`
+	ModuleID     string                 `json:"module_id"`
 	ModuleName   string                 `json:"module_name"`
 	Target       string                 `json:"target"`
 	Status       string                 `json:"status"`
@@ -110,11 +379,12 @@ type ScanResult struct {
 	ErrorMessage string                 `json:"error_message,omitempty"`
 }
 
-// ModuleInterface defines the interface that all modules must implement
+// ModuleInterface defines the interface that all modules must implement.
+// Execute receives options already validated against GetOptionSchema.
 type ModuleInterface interface {
 	GetName() string
 	GetDescription() string
 	Validate(target string) error
-	Execute(target string, options map[string]interface{}) (*ScanResult, error)
-	GetDefaultOptions() map[string]interface{}
+	Execute(ctx context.Context, target string, options Options) (*ScanResult, error)
+	GetOptionSchema() OptionSchema
 }
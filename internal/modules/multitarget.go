@@ -0,0 +1,160 @@
+package modules
+
+import (
+	"GoReconX/internal/engine"
+	"context"
+	"time"
+)
+
+// TargetResults groups the results of running one module against many
+// targets by target
+type TargetResults struct {
+	ModuleID string                 `json:"module_id"`
+	Targets  []string               `json:"targets"`
+	Results  map[string]*ScanResult `json:"results"`
+	// Discovery is nil unless some target went through host discovery
+	Discovery *DiscoveryStats `json:"discovery,omitempty"`
+}
+
+// DiscoveryStats counts how the targets of a run answered host discovery
+type DiscoveryStats struct {
+	Probed int `json:"probed"`
+	Up     int `json:"up"`
+	Down   int `json:"down"`
+	// Methods counts the hosts found up by how they were found, e.g. tcp
+	Methods map[string]int `json:"methods"`
+}
+
+// countDiscovery adds the host discovery outcome of result, if it has one,
+// to the statistics
+func (tr *TargetResults) countDiscovery(result *ScanResult) {
+	up, ok := result.Metadata["host_up"].(bool)
+	if !ok {
+		return
+	}
+	if tr.Discovery == nil {
+		tr.Discovery = &DiscoveryStats{Methods: make(map[string]int)}
+	}
+	tr.Discovery.Probed++
+	if up {
+		tr.Discovery.Up++
+		if method, ok := result.Metadata["discovery_method"].(string); ok {
+			tr.Discovery.Methods[method]++
+		}
+	} else {
+		tr.Discovery.Down++
+	}
+}
+
+// Ordered returns the results in the order the targets were produced,
+// leaving out targets that were never scanned because the run was cancelled
+func (tr *TargetResults) Ordered() []*ScanResult {
+	ordered := make([]*ScanResult, 0, len(tr.Results))
+	for _, target := range tr.Targets {
+		if result, ok := tr.Results[target]; ok {
+			ordered = append(ordered, result)
+		}
+	}
+	return ordered
+}
+
+// ExecuteTargets runs a module against every target produced by targets,
+// such as a source from targets.Expand. Across all calls on the manager at
+// most Network.MaxParallelTargets targets are scanned at the same time.
+// Targets that cannot be scanned, e.g. because they are out of scope, get a
+// failed result; the returned error is only set when the targets could not
+// be read or ctx was cancelled. Events of different targets may be
+// delivered concurrently.
+func (mm *ModuleManager) ExecuteTargets(ctx context.Context, moduleName string, targets engine.Source[string], options map[string]interface{}) (*TargetResults, error) {
+	results := &TargetResults{
+		ModuleID: moduleName,
+		Results:  make(map[string]*ScanResult),
+	}
+
+	// Remember the order targets were produced in; the source runs in a
+	// single goroutine
+	ordered := func(ctx context.Context, yield func(string) bool) error {
+		return targets(ctx, func(target string) bool {
+			results.Targets = append(results.Targets, target)
+			return yield(target)
+		})
+	}
+
+	job := engine.Run(ctx, cap(mm.targetSlots), ordered, func(ctx context.Context, target string) (*ScanResult, bool) {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case mm.targetSlots <- struct{}{}:
+		}
+		defer func() { <-mm.targetSlots }()
+
+		result, err := mm.ExecuteModule(ctx, moduleName, target, options)
+		if result == nil {
+			if ctx.Err() != nil {
+				return nil, false
+			}
+			result = mm.failedResult(moduleName, target, err)
+			if handler := eventHandlerFrom(ctx); handler != nil {
+				handler(Event{
+					Type:      EventFinished,
+					ModuleID:  moduleName,
+					Module:    result.ModuleName,
+					Target:    target,
+					Result:    result,
+					Timestamp: time.Now(),
+				})
+			}
+		}
+		return result, true
+	})
+
+	err := job.Collect(func(result *ScanResult) {
+		results.Results[result.Target] = result
+		results.countDiscovery(result)
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	return results, err
+}
+
+// StreamTargets runs a module against every target produced by targets in
+// the background and returns a channel of the events of all scans. Every
+// target gets an EventFinished; the channel is closed once all are done.
+// Callers must drain the channel; cancel ctx to stop early.
+func (mm *ModuleManager) StreamTargets(ctx context.Context, moduleName string, targets engine.Source[string], options map[string]interface{}) <-chan Event {
+	events := make(chan Event, 64)
+
+	go func() {
+		defer close(events)
+
+		ctx := WithEventHandler(ctx, func(event Event) {
+			events <- event
+		})
+
+		_, err := mm.ExecuteTargets(ctx, moduleName, targets, options)
+		if err != nil && ctx.Err() == nil {
+			// The targets themselves could not be read
+			events <- Event{
+				Type:      EventFinished,
+				ModuleID:  moduleName,
+				Result:    mm.failedResult(moduleName, "", err),
+				Timestamp: time.Now(),
+			}
+		}
+	}()
+
+	return events
+}
+
+// failedResult describes a scan that could not be started
+func (mm *ModuleManager) failedResult(moduleName, target string, err error) *ScanResult {
+	info, _ := mm.GetModuleInfo(moduleName)
+	return &ScanResult{
+		ModuleID:     moduleName,
+		ModuleName:   info.Name,
+		Target:       target,
+		Status:       StatusFailed,
+		ErrorMessage: err.Error(),
+	}
+}
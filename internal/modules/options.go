@@ -0,0 +1,320 @@
+package modules
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OptionType is the value type of a module option
+type OptionType string
+
+const (
+	OptionString OptionType = "string"
+	OptionInt    OptionType = "int"
+	OptionFloat  OptionType = "float"
+	OptionBool   OptionType = "bool"
+)
+
+// OptionRange is the inclusive range allowed for a numeric option
+type OptionRange struct {
+	Min float64 `json:"min" yaml:"min"`
+	Max float64 `json:"max" yaml:"max"`
+}
+
+// OptionSpec describes a single module option
+type OptionSpec struct {
+	Name        string       `json:"name" yaml:"name"`
+	Type        OptionType   `json:"type" yaml:"type"`
+	Default     interface{}  `json:"default,omitempty" yaml:"default,omitempty"`
+	Range       *OptionRange `json:"range,omitempty" yaml:"range,omitempty"`
+	Description string       `json:"description" yaml:"description"`
+	Required    bool         `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// OptionSchema is the typed list of options a module accepts
+type OptionSchema []OptionSpec
+
+// Options holds option values that have been validated against a schema
+type Options map[string]interface{}
+
+// Lookup returns the spec for the named option
+func (s OptionSchema) Lookup(name string) (OptionSpec, bool) {
+	for _, spec := range s {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return OptionSpec{}, false
+}
+
+// Defaults returns the default value of every option that has one
+func (s OptionSchema) Defaults() Options {
+	defaults := make(Options)
+	for _, spec := range s {
+		if spec.Default != nil {
+			defaults[spec.Name] = spec.Default
+		}
+	}
+	return defaults
+}
+
+// Validate checks options against the schema and returns a copy with
+// defaults filled in and every value coerced to its declared type, so that
+// e.g. a float64 decoded from JSON becomes an int.
+func (s OptionSchema) Validate(options map[string]interface{}) (Options, error) {
+	validated := s.Defaults()
+
+	// Sort names so that errors are reported deterministically
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		spec, ok := s.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown option: %s", name)
+		}
+
+		value, err := spec.Coerce(options[name])
+		if err != nil {
+			return nil, err
+		}
+		validated[name] = value
+	}
+
+	for _, spec := range s {
+		if _, ok := validated[spec.Name]; !ok && spec.Required {
+			return nil, fmt.Errorf("option %s is required", spec.Name)
+		}
+	}
+
+	return validated, nil
+}
+
+// Coerce converts value to the spec's type and checks its range
+func (spec OptionSpec) Coerce(value interface{}) (interface{}, error) {
+	var (
+		coerced interface{}
+		err     error
+	)
+
+	switch spec.Type {
+	case OptionString:
+		coerced, err = toString(value)
+	case OptionInt:
+		coerced, err = toInt(value)
+	case OptionFloat:
+		coerced, err = toFloat(value)
+	case OptionBool:
+		coerced, err = toBool(value)
+	default:
+		return nil, fmt.Errorf("option %s has unsupported type %q", spec.Name, spec.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("option %s: %v", spec.Name, err)
+	}
+
+	if spec.Range != nil {
+		var number float64
+		switch v := coerced.(type) {
+		case int:
+			number = float64(v)
+		case float64:
+			number = v
+		}
+		if number < spec.Range.Min || number > spec.Range.Max {
+			return nil, fmt.Errorf("option %s must be between %v and %v, got %v",
+				spec.Name, spec.Range.Min, spec.Range.Max, coerced)
+		}
+	}
+
+	return coerced, nil
+}
+
+// toString accepts strings and formats numbers and booleans
+func toString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool, json.Number:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("expected a string, got %T", value)
+	}
+}
+
+// toInt accepts integers, integral floats and numeric strings
+func toInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int8:
+		return int(v), nil
+	case int16:
+		return int(v), nil
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case uint:
+		return int(v), nil
+	case uint8:
+		return int(v), nil
+	case uint16:
+		return int(v), nil
+	case uint32:
+		return int(v), nil
+	case uint64:
+		return int(v), nil
+	case float32:
+		return toInt(float64(v))
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("expected an integer, got %v", v)
+		}
+		return int(v), nil
+	case json.Number:
+		return toInt(v.String())
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("expected an integer, got %q", v)
+		}
+		return i, nil
+	default:
+		return 0, fmt.Errorf("expected an integer, got %T", value)
+	}
+}
+
+// toFloat accepts any number or numeric string
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case json.Number:
+		return toFloat(v.String())
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected a number, got %q", v)
+		}
+		return f, nil
+	default:
+		i, err := toInt(value)
+		if err != nil {
+			return 0, fmt.Errorf("expected a number, got %T", value)
+		}
+		return float64(i), nil
+	}
+}
+
+// toBool accepts booleans and strings such as "true", "1" or "no"
+func toBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "yes", "on", "1":
+			return true, nil
+		case "false", "no", "off", "0":
+			return false, nil
+		}
+		return false, fmt.Errorf("expected a boolean, got %q", v)
+	default:
+		return false, fmt.Errorf("expected a boolean, got %T", value)
+	}
+}
+
+// String returns the named string option
+func (o Options) String(name string) string {
+	v, _ := o[name].(string)
+	return v
+}
+
+// Int returns the named integer option
+func (o Options) Int(name string) int {
+	v, _ := o[name].(int)
+	return v
+}
+
+// Float returns the named float option
+func (o Options) Float(name string) float64 {
+	v, _ := o[name].(float64)
+	return v
+}
+
+// Bool returns the named boolean option
+func (o Options) Bool(name string) bool {
+	v, _ := o[name].(bool)
+	return v
+}
+
+// optionFlag adapts an option spec to the flag package. Values are kept as
+// strings and coerced by Validate.
+type optionFlag struct {
+	spec  OptionSpec
+	value string
+}
+
+func (f *optionFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+func (f *optionFlag) Set(value string) error {
+	if _, err := f.spec.Coerce(value); err != nil {
+		return err
+	}
+	f.value = value
+	return nil
+}
+
+// IsBoolFlag lets boolean options be passed as a bare -flag
+func (f *optionFlag) IsBoolFlag() bool {
+	return f.spec.Type == OptionBool
+}
+
+// BindFlags registers one flag per option on fs, named prefix+option. The
+// returned function reports the options that were set on the command line.
+func (s OptionSchema) BindFlags(fs *flag.FlagSet, prefix string) func() map[string]interface{} {
+	flags := make(map[string]*optionFlag)
+	for _, spec := range s {
+		f := &optionFlag{spec: spec}
+		if spec.Default != nil {
+			f.value = fmt.Sprint(spec.Default)
+		}
+
+		usage := spec.Description
+		if spec.Range != nil {
+			usage += fmt.Sprintf(" (%v-%v)", spec.Range.Min, spec.Range.Max)
+		}
+		if spec.Required {
+			usage += " (required)"
+		}
+
+		fs.Var(f, prefix+spec.Name, usage)
+		flags[prefix+spec.Name] = f
+	}
+
+	return func() map[string]interface{} {
+		set := make(map[string]interface{})
+		fs.Visit(func(fl *flag.Flag) {
+			if f, ok := flags[fl.Name]; ok {
+				set[f.spec.Name] = f.value
+			}
+		})
+		return set
+	}
+}
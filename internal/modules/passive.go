@@ -0,0 +1,173 @@
+package modules
+
+import (
+	"GoReconX/internal/config"
+	"GoReconX/internal/dns"
+	"GoReconX/internal/engine"
+	"GoReconX/internal/sources"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// passiveSources returns the sources selected by the import_files and
+// passive_sources options. Import files are checked up front so that a
+// wrong path fails the scan early.
+func (se *SubdomainEnumerator) passiveSources(options Options) ([]sources.Source, error) {
+	var selected []sources.Source
+	for _, path := range splitList(options.String("import_files")) {
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+		selected = append(selected, sources.NewFile(path))
+	}
+
+	names := splitList(options.String("passive_sources"))
+	if len(names) == 0 {
+		return selected, nil
+	}
+	timeout := time.Duration(options.Int("source_timeout")) * time.Second
+	client, err := sources.NewClient(timeout, se.config.Network.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	configured := make(map[string]config.SourceConfig, len(se.config.Sources))
+	for _, source := range se.config.Sources {
+		configured[strings.ToLower(source.Name)] = source
+	}
+	if len(names) == 1 && strings.EqualFold(names[0], "all") {
+		names = nil
+		for _, source := range se.config.Sources {
+			names = append(names, source.Name)
+		}
+	}
+	for _, name := range names {
+		source, ok := configured[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown passive source: %s", name)
+		}
+		selected = append(selected, sources.NewHTTP(source, client, se.config.Network.UserAgent))
+	}
+	return selected, nil
+}
+
+// sourceListing is the answer of a single passive source
+type sourceListing struct {
+	source string
+	names  []string
+	err    error
+}
+
+// queryPassive asks every source for the subdomains of domain at once. It
+// returns the subdomains listed, each with the sources that listed it, and
+// for every source how many subdomains it listed or why it failed.
+func (se *SubdomainEnumerator) queryPassive(ctx context.Context, domain string, selected []sources.Source) (map[string][]string, map[string]string) {
+	job := engine.Run(ctx, len(selected), engine.Slice(selected), func(ctx context.Context, source sources.Source) (sourceListing, bool) {
+		names, err := source.Subdomains(ctx, domain)
+		return sourceListing{source: source.Name(), names: names, err: err}, true
+	})
+
+	listed := make(map[string][]string)
+	statuses := make(map[string]string)
+	job.Collect(func(listing sourceListing) {
+		if listing.err != nil {
+			statuses[listing.source] = listing.err.Error()
+			se.logger.WithFields(logrus.Fields{
+				"source": listing.source,
+				"error":  listing.err,
+			}).Warn("Passive source failed")
+			return
+		}
+
+		statuses[listing.source] = fmt.Sprintf("%d subdomains", len(listing.names))
+		se.logger.WithFields(logrus.Fields{
+			"source":     listing.source,
+			"subdomains": len(listing.names),
+		}).Info("Queried passive source")
+		for _, name := range listing.names {
+			listed[name] = append(listed[name], listing.source)
+		}
+	})
+
+	for _, names := range listed {
+		sort.Strings(names)
+	}
+	return listed, statuses
+}
+
+// addPassive merges the subdomains passive sources listed into the results
+// found so far: known names get the sources that listed them added, and
+// the others are resolved and returned as new findings
+func (se *SubdomainEnumerator) addPassive(ctx context.Context, run *enumeration, results []*SubdomainResult, listed map[string][]string) []*SubdomainResult {
+	known := make(map[string]bool, len(results))
+	for _, result := range results {
+		name := strings.ToLower(result.Subdomain)
+		known[name] = true
+		result.Sources = append(result.Sources, listed[name]...)
+	}
+
+	var names []string
+	for name := range listed {
+		if !known[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	se.logger.WithField("subdomains", len(names)).Info("Resolving subdomains from passive sources")
+	run.progress.Grow(len(names))
+
+	job := engine.Run(ctx, run.threads, engine.Slice(names), func(ctx context.Context, name string) (*SubdomainResult, bool) {
+		defer run.progress.Advance(1)
+		result := run.resolveListed(ctx, name)
+		if result == nil || ctx.Err() != nil {
+			return nil, false
+		}
+		result.Sources = listed[name]
+		return result, true
+	})
+
+	var discovered []*SubdomainResult
+	job.Collect(func(result *SubdomainResult) {
+		discovered = append(discovered, result)
+		run.progress.Finding(result)
+
+		se.logger.WithFields(logrus.Fields{
+			"subdomain": result.Subdomain,
+			"sources":   result.Sources,
+		}).Debug("Found subdomain in passive sources")
+	})
+	return discovered
+}
+
+// resolveListed looks up a name a passive source listed. Unlike a guessed
+// name it is kept when it does not resolve, as its records may still point
+// somewhere, e.g. at a service that can be taken over. Names that only
+// resolve to filtered wildcard answers are dropped.
+func (run *enumeration) resolveListed(ctx context.Context, name string) *SubdomainResult {
+	ips, err := run.lookup(ctx, name)
+	if err == nil && len(ips) > 0 {
+		return run.found(ctx, name, ips)
+	}
+	if err != nil {
+		run.lookupFailed(ctx, err)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	result := &SubdomainResult{
+		Subdomain:  name,
+		OutOfScope: !inScope(run.engagement, name, nil),
+	}
+	if run.records != nil {
+		result.Records, _ = run.records.collect(ctx, name, run.recordTypes)
+		result.CNAMEChain = dns.Chain(result.Records, name)
+	}
+	return result
+}
@@ -0,0 +1,209 @@
+package modules
+
+import (
+	"GoReconX/internal/engine"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultPermutationWords are written to the permutation wordlist when it
+// does not exist
+var defaultPermutationWords = []string{
+	"dev", "development", "staging", "stage", "stg", "test", "testing", "qa",
+	"uat", "prod", "production", "preprod", "pre", "beta", "alpha", "demo",
+	"sandbox", "internal", "int", "ext", "corp", "admin", "api", "app", "web",
+	"m", "mobile", "v1", "v2", "old", "new", "legacy", "backup", "bak", "tmp",
+	"cdn", "static", "origin", "eu", "us", "east", "west", "1", "2",
+}
+
+// maxLabel is the longest DNS label allowed
+const maxLabel = 63
+
+// permutations returns the variants of name, a subdomain of domain, that
+// differ from it: its first label with each word joined by a dash on
+// either side or replacing one of its dash-separated parts, each word as a
+// new label in front, numbers in the first label incremented and
+// decremented or appended, and dashes and dots between its labels swapped,
+// e.g. dev-api, api-dev, dev.api, api2 and api.dev for api.dev.example.com
+func permutations(name, domain string, words []string) []string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	relative := strings.TrimSuffix(name, "."+domain)
+	if relative == name || relative == "" {
+		return nil
+	}
+	first, rest, _ := strings.Cut(relative, ".")
+	if rest != "" {
+		rest = "." + rest
+	}
+
+	seen := map[string]bool{relative: true}
+	var variants []string
+	add := func(variant string) {
+		if !seen[variant] && validRelativeName(variant) {
+			seen[variant] = true
+			variants = append(variants, variant+"."+domain)
+		}
+	}
+
+	parts := strings.Split(first, "-")
+	for _, word := range words {
+		add(word + "-" + first + rest)
+		add(first + "-" + word + rest)
+		add(word + "." + relative)
+		if len(parts) > 1 {
+			for i := range parts {
+				replaced := append([]string(nil), parts...)
+				replaced[i] = word
+				add(strings.Join(replaced, "-") + rest)
+			}
+		}
+	}
+
+	for _, variant := range numberVariants(first) {
+		add(variant + rest)
+	}
+
+	for _, variant := range separatorSwaps(relative) {
+		add(variant)
+	}
+	return variants
+}
+
+// numberVariants returns label with each number in it one lower, one and
+// two higher, keeping zero padding, e.g. web01 -> web00, web02, web03. A
+// label without numbers gets 1 and 2 appended.
+func numberVariants(label string) []string {
+	var variants []string
+	for start := 0; start < len(label); start++ {
+		if !isDigit(label[start]) {
+			continue
+		}
+		end := start
+		for end < len(label) && isDigit(label[end]) {
+			end++
+		}
+		digits := label[start:end]
+		n, err := strconv.Atoi(digits)
+		if err == nil {
+			for _, delta := range []int{-1, 1, 2} {
+				if n+delta < 0 {
+					continue
+				}
+				number := strconv.Itoa(n + delta)
+				for len(number) < len(digits) {
+					number = "0" + number
+				}
+				variants = append(variants, label[:start]+number+label[end:])
+			}
+		}
+		start = end
+	}
+
+	if len(variants) == 0 {
+		variants = append(variants, label+"1", label+"2")
+	}
+	return variants
+}
+
+// separatorSwaps returns name with each dash turned into a dot or dropped,
+// and each dot turned into a dash, one at a time and all at once
+func separatorSwaps(name string) []string {
+	var variants []string
+	for i := 0; i < len(name); i++ {
+		switch name[i] {
+		case '-':
+			variants = append(variants, name[:i]+"."+name[i+1:], name[:i]+name[i+1:])
+		case '.':
+			variants = append(variants, name[:i]+"-"+name[i+1:])
+		}
+	}
+	if strings.Contains(name, "-") {
+		variants = append(variants, strings.ReplaceAll(name, "-", "."), strings.ReplaceAll(name, "-", ""))
+	}
+	if strings.Contains(name, ".") {
+		variants = append(variants, strings.ReplaceAll(name, ".", "-"))
+	}
+	return variants
+}
+
+// validRelativeName reports whether every label of name is a valid host
+// name label
+func validRelativeName(name string) bool {
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > maxLabel || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !isDigit(c) && (c < 'a' || c > 'z') && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// resolvePermutations resolves the permutations of the subdomains found,
+// then those of the names they turn up, until a round finds nothing new or
+// depth rounds have run. Names flagged as wildcard answers are not permuted
+// as every variant of them resolves too. It returns the new subdomains and
+// the number of rounds run.
+func (se *SubdomainEnumerator) resolvePermutations(ctx context.Context, run *enumeration, found []*SubdomainResult, words []string, depth int) ([]*SubdomainResult, int) {
+	seen := make(map[string]bool)
+	for _, result := range found {
+		seen[strings.ToLower(result.Subdomain)] = true
+	}
+
+	var discovered []*SubdomainResult
+	seeds := found
+	rounds := 0
+	for rounds < depth && len(seeds) > 0 && ctx.Err() == nil {
+		rounds++
+
+		var candidates []string
+		for _, seed := range seeds {
+			if seed.Wildcard {
+				continue
+			}
+			for _, name := range permutations(seed.Subdomain, run.domain, words) {
+				if !seen[name] {
+					seen[name] = true
+					candidates = append(candidates, name)
+				}
+			}
+		}
+		se.logger.WithFields(logrus.Fields{
+			"round":      rounds,
+			"candidates": len(candidates),
+		}).Info("Resolving subdomain permutations")
+		run.progress.Grow(len(candidates))
+
+		job := engine.Run(ctx, run.threads, engine.Slice(candidates), func(ctx context.Context, name string) (*SubdomainResult, bool) {
+			defer run.progress.Advance(1)
+			result := run.resolve(ctx, name, SourcePermutation)
+			return result, result != nil && ctx.Err() == nil
+		})
+
+		seeds = nil
+		job.Collect(func(result *SubdomainResult) {
+			seeds = append(seeds, result)
+			run.progress.Finding(result)
+
+			se.logger.WithFields(logrus.Fields{
+				"subdomain": result.Subdomain,
+				"ips":       result.IPs,
+			}).Debug("Found subdomain permutation")
+		})
+		discovered = append(discovered, seeds...)
+	}
+	return discovered, rounds
+}
@@ -0,0 +1,125 @@
+package modules
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPermutations(t *testing.T) {
+	long := strings.Repeat("a", 61)
+
+	tests := []struct {
+		name   string
+		domain string
+		words  []string
+		want   []string
+	}{
+		{
+			name:   "api.dev.example.com",
+			domain: "example.com",
+			words:  []string{"dev"},
+			want: []string{
+				"dev-api.dev.example.com", "api-dev.dev.example.com", "dev.api.dev.example.com",
+				"api1.dev.example.com", "api2.dev.example.com",
+				"api-dev.example.com",
+			},
+		},
+		{
+			name:   "api-v1.example.com",
+			domain: "example.com",
+			words:  []string{"dev"},
+			want: []string{
+				"dev-api-v1.example.com", "api-v1-dev.example.com", "dev.api-v1.example.com",
+				"dev-v1.example.com", "api-dev.example.com",
+				"api-v0.example.com", "api-v2.example.com", "api-v3.example.com",
+				"api.v1.example.com", "apiv1.example.com",
+			},
+		},
+		{
+			name:   "web01.example.com",
+			domain: "example.com",
+			want:   []string{"web00.example.com", "web02.example.com", "web03.example.com"},
+		},
+		{
+			name:   "node0-9.example.com",
+			domain: "example.com",
+			want: []string{
+				"node1-9.example.com", "node2-9.example.com",
+				"node0-8.example.com", "node0-10.example.com", "node0-11.example.com",
+				"node0.9.example.com", "node09.example.com",
+			},
+		},
+		{
+			name:   "WWW.Example.com.",
+			domain: "example.com.",
+			want:   []string{"www1.example.com", "www2.example.com"},
+		},
+		{
+			name:   "www.example.com",
+			domain: "example.com",
+			words:  []string{"bad_word", "Dev", "ok"},
+			want: []string{
+				"ok-www.example.com", "www-ok.example.com", "ok.www.example.com",
+				"www1.example.com", "www2.example.com",
+			},
+		},
+		{
+			// Joined to the word, the label grows beyond 63 characters
+			name:   long + ".example.com",
+			domain: "example.com",
+			words:  []string{"dev"},
+			want:   []string{"dev." + long + ".example.com", long + "1.example.com", long + "2.example.com"},
+		},
+		{name: "example.com", domain: "example.com", words: []string{"dev"}},
+		{name: "www.example.net", domain: "example.com", words: []string{"dev"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := permutations(tt.name, tt.domain, tt.words); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("permutations = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumberVariants(t *testing.T) {
+	tests := []struct {
+		label string
+		want  []string
+	}{
+		{"web01", []string{"web00", "web02", "web03"}},
+		{"web9", []string{"web8", "web10", "web11"}},
+		{"web0", []string{"web1", "web2"}},
+		{"099", []string{"098", "100", "101"}},
+		{"api", []string{"api1", "api2"}},
+	}
+	for _, tt := range tests {
+		if got := numberVariants(tt.label); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("numberVariants(%q) = %q, want %q", tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestValidRelativeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"api.dev", true},
+		{"web-01", true},
+		{"a--b", true},
+		{"-api", false},
+		{"api-", false},
+		{"api..dev", false},
+		{"api_dev", false},
+		{"API", false},
+		{strings.Repeat("a", 63), true},
+		{strings.Repeat("a", 64), false},
+	}
+	for _, tt := range tests {
+		if got := validRelativeName(tt.name); got != tt.want {
+			t.Errorf("validRelativeName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package modules
+
+import (
+	"GoReconX/internal/database"
+	"context"
+	"encoding/json"
+)
+
+type jobKey struct{}
+
+// WithJob returns a context whose scans are recorded as part of the job
+// with ID jobID, e.g. a pipeline run
+func WithJob(ctx context.Context, jobID int) context.Context {
+	return context.WithValue(ctx, jobKey{}, jobID)
+}
+
+// resultTyper is implemented by findings that know which kind of result
+// they should be stored as, e.g. "subdomain" or "port"
+type resultTyper interface {
+	ResultType() string
+}
+
+// startScanRecord stores a scan that is about to run along with the options
+// needed to resume it. It returns nil when the manager has no database or
+// the record could not be created.
+func (mm *ModuleManager) startScanRecord(ctx context.Context, moduleID, target string, options Options) *database.Scan {
+	if mm.DB == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(options)
+	if err != nil {
+		mm.Logger.WithError(err).WithField("module", moduleID).Warn("Failed to encode scan options")
+	}
+
+	scan, err := mm.DB.CreateScan(mm.ProjectID, moduleID, target, string(data))
+	if err != nil {
+		mm.Logger.WithError(err).WithField("module", moduleID).Warn("Failed to record scan")
+		return nil
+	}
+
+	if jobID, ok := ctx.Value(jobKey{}).(int); ok {
+		if err := mm.DB.SetScanJob(scan.ID, jobID); err != nil {
+			mm.Logger.WithError(err).WithField("scan_id", scan.ID).Warn("Failed to link scan to job")
+		}
+	}
+
+	if err := mm.DB.UpdateScanStatus(scan.ID, StatusRunning, "", ""); err != nil {
+		mm.Logger.WithError(err).WithField("scan_id", scan.ID).Warn("Failed to update scan status")
+	}
+	return scan
+}
+
+// resumeScanRecord marks a stored scan as running again. Its findings are
+// dropped since the resumed scan reports them again from its checkpoint.
+func (mm *ModuleManager) resumeScanRecord(scan *database.Scan) {
+	logger := mm.Logger.WithField("scan_id", scan.ID)
+	if err := mm.DB.DeleteResults(scan.ID); err != nil {
+		logger.WithError(err).Warn("Failed to clear findings")
+	}
+	if err := mm.DB.UpdateScanStatus(scan.ID, StatusRunning, "", ""); err != nil {
+		logger.WithError(err).Warn("Failed to update scan status")
+	}
+}
+
+// finishScanRecord stores the outcome of a scan and each of its findings
+func (mm *ModuleManager) finishScanRecord(scan *database.Scan, result *ScanResult, execErr error) {
+	if scan == nil {
+		return
+	}
+	logger := mm.Logger.WithField("scan_id", scan.ID)
+
+	if result == nil {
+		message := ""
+		if execErr != nil {
+			message = execErr.Error()
+		}
+		if err := mm.DB.UpdateScanStatus(scan.ID, StatusFailed, "", message); err != nil {
+			logger.WithError(err).Warn("Failed to update scan status")
+		}
+		return
+	}
+
+	for _, item := range result.Results {
+		data, err := json.Marshal(item)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to encode finding")
+			continue
+		}
+
+		resultType := scan.ScanType
+		if typed, ok := item.(resultTyper); ok {
+			resultType = typed.ResultType()
+		}
+
+		var metadata string
+		if finding, ok := item.(SeverityFinding); ok {
+			encoded, _ := json.Marshal(map[string]string{"severity": finding.Severity()})
+			metadata = string(encoded)
+		}
+
+		if err := mm.DB.SaveResult(scan.ID, resultType, string(data), metadata); err != nil {
+			logger.WithError(err).Warn("Failed to store finding")
+		}
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to encode scan result")
+	}
+	if err := mm.DB.UpdateScanStatus(scan.ID, result.Status, string(data), result.ErrorMessage); err != nil {
+		logger.WithError(err).Warn("Failed to update scan status")
+	}
+
+	// A completed or skipped scan is never resumed
+	if result.Status == StatusCompleted || result.Status == StatusSkipped {
+		if err := mm.DB.SaveCheckpoint(scan.ID, ""); err != nil {
+			logger.WithError(err).Warn("Failed to clear checkpoint")
+		}
+	}
+}
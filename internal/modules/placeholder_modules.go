@@ -2,17 +2,77 @@ package modules
 
 import (
 	"GoReconX/internal/config"
+	"GoReconX/internal/engine"
+	"GoReconX/internal/ratelimit"
+	"GoReconX/internal/scope"
+	"context"
 	"fmt"
 	"net"
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+func init() {
+	Register(ModuleInfo{
+		ID:             "email_harvesting",
+		Name:           "Email Harvester",
+		Category:       CategoryPassive,
+		Description:    "Harvests email addresses from various sources",
+		RequiredInputs: []string{InputDomain},
+	}, func(cfg *config.Config, logger *logrus.Logger) ModuleInterface {
+		return NewEmailHarvester(cfg, logger)
+	})
+	Register(ModuleInfo{
+		ID:             "port_scanning",
+		Name:           "Port Scanner",
+		Category:       CategoryActive,
+		Description:    "Scans for open TCP and UDP ports on target hosts",
+		RequiredInputs: []string{InputIP, InputDomain},
+	}, func(cfg *config.Config, logger *logrus.Logger) ModuleInterface {
+		return NewPortScanner(cfg, logger)
+	})
+	Register(ModuleInfo{
+		ID:             "directory_enumeration",
+		Name:           "Directory Enumerator",
+		Category:       CategoryActive,
+		Description:    "Enumerates directories and files on web servers",
+		RequiredInputs: []string{InputURL},
+	}, func(cfg *config.Config, logger *logrus.Logger) ModuleInterface {
+		return NewDirectoryEnumerator(cfg, logger)
+	})
+	Register(ModuleInfo{
+		ID:             "web_analysis",
+		Name:           "Web Analyzer",
+		Category:       CategoryPassive,
+		Description:    "Analyzes web applications for technologies and vulnerabilities",
+		RequiredInputs: []string{InputURL, InputDomain},
+	}, func(cfg *config.Config, logger *logrus.Logger) ModuleInterface {
+		return NewWebAnalyzer(cfg, logger)
+	})
+	Register(ModuleInfo{
+		ID:             "ip_geolocation",
+		Name:           "IP Geolocator",
+		Category:       CategoryPassive,
+		Description:    "Provides geolocation information for IP addresses",
+		RequiredInputs: []string{InputIP},
+	}, func(cfg *config.Config, logger *logrus.Logger) ModuleInterface {
+		return NewIPGeolocator(cfg, logger)
+	})
+	Register(ModuleInfo{
+		ID:             "github_reconnaissance",
+		Name:           "GitHub Reconnaissance",
+		Category:       CategoryPassive,
+		Description:    "Searches GitHub for sensitive information and code",
+		RequiredInputs: []string{InputText},
+	}, func(cfg *config.Config, logger *logrus.Logger) ModuleInterface {
+		return NewGitHubRecon(cfg, logger)
+	})
+}
+
 // EmailHarvester handles email harvesting operations
 type EmailHarvester struct {
 	config *config.Config
@@ -28,13 +88,13 @@ func (eh *EmailHarvester) GetName() string { return "Email Harvester" }
 func (eh *EmailHarvester) GetDescription() string {
 	return "Harvests email addresses from various sources"
 }
-func (eh *EmailHarvester) Validate(target string) error              { return nil }
-func (eh *EmailHarvester) GetDefaultOptions() map[string]interface{} { return map[string]interface{}{} }
-func (eh *EmailHarvester) Execute(target string, options map[string]interface{}) (*ScanResult, error) {
+func (eh *EmailHarvester) Validate(target string) error  { return nil }
+func (eh *EmailHarvester) GetOptionSchema() OptionSchema { return OptionSchema{} }
+func (eh *EmailHarvester) Execute(ctx context.Context, target string, options Options) (*ScanResult, error) {
 	return &ScanResult{
 		ModuleName: eh.GetName(),
 		Target:     target,
-		Status:     "completed",
+		Status:     StatusCompleted,
 		Results:    []interface{}{},
 		StartTime:  time.Now().Format(time.RFC3339),
 		EndTime:    time.Now().Format(time.RFC3339),
@@ -43,11 +103,32 @@ func (eh *EmailHarvester) Execute(target string, options map[string]interface{})
 
 // PortResult represents a port scan result
 type PortResult struct {
-	Port     int    `json:"port"`
-	Protocol string `json:"protocol"`
-	State    string `json:"state"`
-	Service  string `json:"service"`
-	Banner   string `json:"banner,omitempty"`
+	Port     int      `json:"port"`
+	Protocol string   `json:"protocol"`
+	State    string   `json:"state"`
+	Service  string   `json:"service"`
+	Product  string   `json:"product,omitempty"`
+	Version  string   `json:"version,omitempty"`
+	Info     string   `json:"info,omitempty"`
+	CPE      []string `json:"cpe,omitempty"`
+	Banner   string   `json:"banner,omitempty"`
+}
+
+// String returns a short human readable form of the result
+func (pr *PortResult) String() string {
+	s := fmt.Sprintf("%d/%s %s (%s)", pr.Port, pr.Protocol, pr.State, pr.Service)
+	if pr.Product != "" {
+		s += " " + pr.Product
+	}
+	if pr.Version != "" {
+		s += " " + pr.Version
+	}
+	return s
+}
+
+// ResultType identifies port findings in the results table
+func (pr *PortResult) ResultType() string {
+	return "port"
 }
 
 // PortScanner handles port scanning operations
@@ -80,65 +161,336 @@ func (ps *PortScanner) Validate(target string) error {
 	return nil
 }
 
-func (ps *PortScanner) GetDefaultOptions() map[string]interface{} {
-	return map[string]interface{}{
-		"ports":    "1-1000",
-		"threads":  100,
-		"timeout":  2,
-		"scan_tcp": true,
+func (ps *PortScanner) GetOptionSchema() OptionSchema {
+	return OptionSchema{
+		{Name: "ports", Type: OptionString, Default: "1-1000", Description: "Ports to scan, e.g. 22,80,443,8000-8100"},
+		{Name: "threads", Type: OptionInt, Default: 100, Range: &OptionRange{Min: 1, Max: 5000}, Description: "Number of concurrent connection attempts"},
+		{Name: "timeout", Type: OptionInt, Default: 2, Range: &OptionRange{Min: 1, Max: 60}, Description: "Connection timeout in seconds"},
+		{Name: "host_discovery", Type: OptionBool, Default: false, Description: "Skip the port scan of hosts that answer no discovery probe"},
+		{Name: "discovery_ports", Type: OptionString, Default: defaultDiscoveryPorts, Description: "Ports sent TCP connect pings during host discovery"},
+		{Name: "discovery_icmp", Type: OptionBool, Default: true, Description: "Send an ICMP echo request during host discovery where ICMP sockets are permitted"},
+		{Name: "discovery_arp", Type: OptionBool, Default: true, Description: "Take an ARP table entry as a sign of life for hosts on local networks"},
+		{Name: "scan_tcp", Type: OptionBool, Default: true, Description: "Scan TCP ports"},
+		{Name: "scan_udp", Type: OptionBool, Default: false, Description: "Scan UDP ports with protocol probes"},
+		{Name: "udp_ports", Type: OptionString, Default: defaultUDPPorts(), Description: "UDP ports to scan; defaults to the ports with a protocol probe"},
+		{Name: "udp_retries", Type: OptionInt, Default: 1, Range: &OptionRange{Min: 0, Max: 5}, Description: "Times a UDP probe is resent when nothing answers"},
+		{Name: "service_detection", Type: OptionBool, Default: true, Description: "Identify the services of open ports from their banners and probe answers"},
+		{Name: "probes", Type: OptionString, Default: ps.config.Services.Probes, Description: "Service probe file in nmap-service-probes format"},
+		{Name: "version_intensity", Type: OptionInt, Default: 7, Range: &OptionRange{Min: 0, Max: 9}, Description: "Rarest service probes sent to each port"},
+		{Name: "tls_inspection", Type: OptionBool, Default: true, Description: "Inspect the certificates and configuration of TLS services"},
+		{Name: "tls_ciphers", Type: OptionBool, Default: true, Description: "Test which cipher suites TLS services accept, one handshake per suite"},
 	}
 }
 
-func (ps *PortScanner) Execute(target string, options map[string]interface{}) (*ScanResult, error) {
+func (ps *PortScanner) Execute(ctx context.Context, target string, options Options) (*ScanResult, error) {
 	startTime := time.Now()
 	ps.logger.WithField("target", target).Info("Starting port scan")
 
 	result := &ScanResult{
 		ModuleName: ps.GetName(),
 		Target:     target,
-		Status:     "running",
+		Status:     StatusRunning,
 		StartTime:  startTime.Format(time.RFC3339),
 		Metadata:   make(map[string]interface{}),
 	}
 
 	// Parse options
-	portsStr, _ := options["ports"].(string)
-	threads, _ := options["threads"].(int)
-	timeout, _ := options["timeout"].(int)
+	portsStr := options.String("ports")
+	threads := options.Int("threads")
+	timeout := options.Int("timeout")
 
 	if portsStr == "" {
 		portsStr = "1-1000"
 	}
 
-	// Parse port range
-	ports, err := ps.parsePorts(portsStr)
+	// Parse port ranges of the protocols to scan and of host discovery
+	var ports, udpPorts, discoveryPorts []int
+	var err error
+	if options.Bool("scan_tcp") {
+		ports, err = ps.parsePorts(portsStr)
+	}
+	if err == nil && options.Bool("scan_udp") {
+		udpPorts, err = ps.parsePorts(options.String("udp_ports"))
+	}
+	if err == nil && options.Bool("host_discovery") && options.String("discovery_ports") != "" {
+		discoveryPorts, err = ps.parsePorts(options.String("discovery_ports"))
+	}
 	if err != nil {
-		result.Status = "failed"
+		result.Status = StatusFailed
 		result.ErrorMessage = fmt.Sprintf("Invalid port specification: %v", err)
 		result.EndTime = time.Now().Format(time.RFC3339)
 		return result, err
 	}
+	if !options.Bool("scan_tcp") && !options.Bool("scan_udp") {
+		err := fmt.Errorf("neither TCP nor UDP scanning is enabled")
+		result.Status = StatusFailed
+		result.ErrorMessage = err.Error()
+		result.EndTime = time.Now().Format(time.RFC3339)
+		return result, err
+	}
+
+	// Domain targets are named in TLS handshakes so that virtual hosts answer
+	serverName := ""
+	if net.ParseIP(target) == nil {
+		serverName = target
+	}
+
+	// Load the service probes up front so that a bad file fails fast
+	var detector *serviceDetector
+	if options.Bool("service_detection") {
+		db, err := ps.loadProbes(options.String("probes"))
+		if err != nil {
+			result.Status = StatusFailed
+			result.ErrorMessage = err.Error()
+			result.EndTime = time.Now().Format(time.RFC3339)
+			return result, err
+		}
+		detector = &serviceDetector{
+			db:         db,
+			timeout:    time.Duration(timeout) * time.Second,
+			intensity:  options.Int("version_intensity"),
+			serverName: serverName,
+			limiter:    ratelimit.FromContext(ctx),
+			logger:     ps.logger,
+		}
+	}
+
+	// Only probe addresses and ports inside the engagement scope. Names
+	// are resolved through the resolver pool and its rate limits.
+	engagement := scope.FromContext(ctx)
+	lookup := newLookup(ctx, timeout)
+	addresses, excluded, err := ps.scanAddresses(ctx, engagement, target, lookup)
+	if len(excluded) > 0 {
+		result.Metadata["out_of_scope_addresses"] = excluded
+	}
+	if err != nil {
+		result.Status = StatusFailed
+		result.ErrorMessage = err.Error()
+		result.EndTime = time.Now().Format(time.RFC3339)
+		return result, err
+	}
+	address := addresses[0]
+
+	requested := len(ports) + len(udpPorts)
+	ports = inScopePorts(engagement, ports)
+	udpPorts = inScopePorts(engagement, udpPorts)
+	if skipped := requested - len(ports) - len(udpPorts); skipped > 0 {
+		result.Metadata["out_of_scope_ports"] = skipped
+	}
+	if len(ports) == 0 && len(udpPorts) == 0 {
+		err := fmt.Errorf("none of the requested ports are in scope")
+		result.Status = StatusFailed
+		result.ErrorMessage = err.Error()
+		result.EndTime = time.Now().Format(time.RFC3339)
+		return result, err
+	}
 
-	// Scan TCP ports
-	results := ps.scanTCPPorts(target, ports, threads, timeout)
+	// Skip hosts that answer no discovery probe. Only in-scope ports are
+	// pinged. A target with several in-scope addresses is scanned on the
+	// first one found up.
+	discoveryPorts = inScopePorts(engagement, discoveryPorts)
+	if options.Bool("host_discovery") && (len(discoveryPorts) > 0 || options.Bool("discovery_icmp")) {
+		discovery := &hostDiscovery{
+			ports:   discoveryPorts,
+			timeout: time.Duration(timeout) * time.Second,
+			icmp:    options.Bool("discovery_icmp"),
+			arp:     options.Bool("discovery_arp"),
+			limiter: ratelimit.FromContext(ctx),
+			logger:  ps.logger,
+		}
+		var found discoveryResult
+		var probes int64
+		for _, candidate := range addresses {
+			found = discovery.discover(ctx, net.ParseIP(candidate))
+			probes += found.Probes
+			if found.Up {
+				address = candidate
+				break
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		result.Metadata["discovery_probes"] = probes
+
+		// A cancelled discovery settles nothing
+		switch {
+		case found.Up:
+			result.Metadata["host_up"] = true
+			result.Metadata["discovery_method"] = found.Method
+			result.Metadata["discovery_reason"] = found.Reason
+			result.Metadata["discovery_rtt_ms"] = found.RTT.Milliseconds()
+		case ctx.Err() == nil:
+			ps.logger.WithFields(logrus.Fields{
+				"target":    target,
+				"addresses": addresses,
+			}).Info("Host did not answer discovery, skipping port scan")
+			endTime := time.Now()
+			result.Status = StatusSkipped
+			result.EndTime = endTime.Format(time.RFC3339)
+			result.Metadata["host_up"] = false
+			result.Metadata["skip_reason"] = "host down"
+			result.Metadata["duration_seconds"] = endTime.Sub(startTime).Seconds()
+			return result, nil
+		}
+	}
+	result.Metadata["address"] = address
+
+	// Continue from the checkpoint of an interrupted run, skipping the
+	// ports it already scanned
+	cp, err := newCheckpoint[*PortResult](ctx, func() (string, error) {
+		return checkpointInput(target, options, ports, udpPorts)
+	})
+	if err != nil {
+		result.Status = StatusFailed
+		result.ErrorMessage = err.Error()
+		result.EndTime = time.Now().Format(time.RFC3339)
+		return result, err
+	}
+	progress := newProgressReporter(ctx, ps.GetName(), target, len(ports)+len(udpPorts))
+	var remaining, remainingUDP []int
+	for _, port := range ports {
+		if cp.Finished(port) {
+			progress.Advance(1)
+		} else {
+			remaining = append(remaining, port)
+		}
+	}
+	for _, port := range udpPorts {
+		if cp.Finished(udpKeyOffset + port) {
+			progress.Advance(1)
+		} else {
+			remainingUDP = append(remainingUDP, port)
+		}
+	}
+
+	// Scan TCP ports, then UDP ports
+	found := ps.scanTCPPorts(ctx, address, remaining, threads, timeout, progress, cp)
+	if len(remainingUDP) > 0 && ctx.Err() == nil {
+		foundUDP, states := ps.scanUDPPorts(ctx, address, remainingUDP, threads, timeout, options.Int("udp_retries"), progress, cp)
+		found = append(found, foundUDP...)
+		result.Metadata["udp_states"] = states
+	}
+	cp.Flush()
+	results := append(cp.Restored(), found...)
+
+	// Identify the services behind the ports found, including restored
+	// ones, whose checkpoint entries predate detection
+	if detector != nil && ctx.Err() == nil {
+		var candidates []*PortResult
+		for _, r := range results {
+			if r.State == PortOpen || r.State == PortOpenFiltered {
+				candidates = append(candidates, r)
+			}
+		}
+		result.Metadata["identified_services"] = ps.detectServices(ctx, address, candidates, threads, detector, progress)
+	}
 
 	// Convert results to interface slice
 	var interfaceResults []interface{}
+	openPorts := 0
 	for _, r := range results {
 		interfaceResults = append(interfaceResults, r)
+		if r.State == PortOpen {
+			openPorts++
+		}
+	}
+
+	// Inspect the TLS services and report the hostnames their certificates
+	// are valid for
+	if options.Bool("tls_inspection") && ctx.Err() == nil {
+		inspector := &tlsInspector{
+			timeout:    time.Duration(timeout) * time.Second,
+			ciphers:    options.Bool("tls_ciphers"),
+			serverName: serverName,
+			limiter:    ratelimit.FromContext(ctx),
+		}
+		services, weaknesses := ps.inspectTLS(ctx, address, results, threads, inspector, progress)
+		for _, service := range services {
+			interfaceResults = append(interfaceResults, service)
+		}
+		for _, weakness := range weaknesses {
+			interfaceResults = append(interfaceResults, weakness)
+		}
+
+		domains := engagement.Domains()
+		if serverName != "" {
+			domains = append(domains, strings.ToLower(serverName))
+		}
+		hostnames := ps.resolveCertificateNames(ctx, certificateHostnames(services), domains, threads, lookup, engagement, progress)
+		for _, hostname := range hostnames {
+			interfaceResults = append(interfaceResults, hostname)
+		}
+		result.Metadata["tls_services"] = len(services)
+		result.Metadata["tls_weaknesses"] = len(weaknesses)
+		result.Metadata["certificate_hostnames"] = len(hostnames)
 	}
 
 	endTime := time.Now()
 	result.Results = interfaceResults
-	result.Status = "completed"
+	result.Status = StatusCompleted
 	result.EndTime = endTime.Format(time.RFC3339)
-	result.Metadata["open_ports"] = len(results)
+	result.Metadata["open_ports"] = openPorts
 	result.Metadata["scanned_ports"] = len(ports)
+	if udpPorts != nil {
+		result.Metadata["scanned_udp_ports"] = len(udpPorts)
+	}
 	result.Metadata["duration_seconds"] = endTime.Sub(startTime).Seconds()
 
+	if err := ctx.Err(); err != nil {
+		result.Status = StatusCancelled
+		result.ErrorMessage = err.Error()
+		ps.logger.WithFields(logrus.Fields{
+			"target":     target,
+			"open_ports": openPorts,
+		}).Warn("Port scan cancelled")
+		return result, err
+	}
+
 	return result, nil
 }
 
+// scanAddresses resolves target with lookup to the addresses that may be
+// scanned, in the order the resolver returned them. Every address is
+// checked against the engagement scope so that only in-scope ones are
+// dialled; the addresses left out are returned too.
+func (ps *PortScanner) scanAddresses(ctx context.Context, engagement *scope.Scope, target string, lookup lookupFunc) ([]string, []string, error) {
+	if err := engagement.Check(target); err != nil {
+		return nil, nil, err
+	}
+	if net.ParseIP(target) != nil {
+		return []string{target}, nil, nil
+	}
+
+	ips, err := lookup(ctx, target)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve %s: %v", target, err)
+	}
+
+	allowed, excluded := splitByScope(engagement, ips)
+	if len(allowed) == 0 {
+		return nil, excluded, fmt.Errorf("%s only resolves to out-of-scope addresses", target)
+	}
+	if len(excluded) > 0 {
+		ps.logger.WithFields(logrus.Fields{
+			"target":   target,
+			"excluded": excluded,
+		}).Warn("Skipping out-of-scope addresses")
+	}
+	return allowed, excluded, nil
+}
+
+// inScopePorts returns the ports the engagement scope allows
+func inScopePorts(engagement *scope.Scope, ports []int) []int {
+	var allowed []int
+	for _, port := range ports {
+		if engagement.ContainsPort(port) {
+			allowed = append(allowed, port)
+		}
+	}
+	return allowed
+}
+
 // parsePorts parses port specification (e.g., "80,443,1000-2000")
 func (ps *PortScanner) parsePorts(portsStr string) ([]int, error) {
 	var ports []int
@@ -193,48 +545,53 @@ func (ps *PortScanner) parsePorts(portsStr string) ([]int, error) {
 	return ports, nil
 }
 
-// scanTCPPorts scans TCP ports
-func (ps *PortScanner) scanTCPPorts(target string, ports []int, threads, timeout int) []*PortResult {
-	var results []*PortResult
-	var resultsMutex sync.Mutex
+// scanTCPPorts scans TCP ports with a fixed pool of workers, recording each
+// scanned port in cp. Cancelling ctx stops new connection attempts, aborts
+// in-flight dials and returns the ports found so far.
+func (ps *PortScanner) scanTCPPorts(ctx context.Context, target string, ports []int, threads, timeout int, progress *progressReporter, cp *checkpoint[*PortResult]) []*PortResult {
+	dialer := &net.Dialer{Timeout: time.Duration(timeout) * time.Second}
+	limiter := ratelimit.FromContext(ctx)
 
-	semaphore := make(chan struct{}, threads)
-	var wg sync.WaitGroup
+	job := engine.Run(ctx, threads, engine.Slice(ports), func(ctx context.Context, p int) (*PortResult, bool) {
+		defer progress.Advance(1)
 
-	for _, port := range ports {
-		wg.Add(1)
-		go func(p int) {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-
-			address := fmt.Sprintf("%s:%d", target, p)
-			conn, err := net.DialTimeout("tcp", address, time.Duration(timeout)*time.Second)
-
-			if err == nil {
-				defer conn.Close()
-
-				result := &PortResult{
-					Port:     p,
-					Protocol: "tcp",
-					State:    "open",
-					Service:  ps.getServiceName(p),
-				}
-
-				resultsMutex.Lock()
-				results = append(results, result)
-				resultsMutex.Unlock()
+		if err := limiter.WaitHost(ctx, target); err != nil {
+			return nil, false
+		}
 
-				ps.logger.WithFields(logrus.Fields{
-					"target": target,
-					"port":   p,
-					"state":  "open",
-				}).Debug("Found open port")
+		address := net.JoinHostPort(target, strconv.Itoa(p))
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			// A dial cut short by cancellation is repeated on resume
+			if ctx.Err() == nil {
+				cp.Done(p)
 			}
-		}(port)
-	}
+			return nil, false
+		}
+		conn.Close()
+
+		result := &PortResult{
+			Port:     p,
+			Protocol: "tcp",
+			State:    PortOpen,
+			Service:  ps.getServiceName(p),
+		}
+		cp.Done(p, result)
+		return result, true
+	})
+
+	var results []*PortResult
+	job.Collect(func(result *PortResult) {
+		results = append(results, result)
+		progress.Finding(result)
+
+		ps.logger.WithFields(logrus.Fields{
+			"target": target,
+			"port":   result.Port,
+			"state":  result.State,
+		}).Debug("Found open port")
+	})
 
-	wg.Wait()
 	return results
 }
 
@@ -283,14 +640,14 @@ func (de *DirectoryEnumerator) GetDescription() string {
 	return "Enumerates directories and files on web servers"
 }
 func (de *DirectoryEnumerator) Validate(target string) error { return nil }
-func (de *DirectoryEnumerator) GetDefaultOptions() map[string]interface{} {
-	return map[string]interface{}{}
+func (de *DirectoryEnumerator) GetOptionSchema() OptionSchema {
+	return OptionSchema{}
 }
-func (de *DirectoryEnumerator) Execute(target string, options map[string]interface{}) (*ScanResult, error) {
+func (de *DirectoryEnumerator) Execute(ctx context.Context, target string, options Options) (*ScanResult, error) {
 	return &ScanResult{
 		ModuleName: de.GetName(),
 		Target:     target,
-		Status:     "completed",
+		Status:     StatusCompleted,
 		Results:    []interface{}{},
 		StartTime:  time.Now().Format(time.RFC3339),
 		EndTime:    time.Now().Format(time.RFC3339),
@@ -312,13 +669,13 @@ func (wa *WebAnalyzer) GetName() string { return "Web Analyzer" }
 func (wa *WebAnalyzer) GetDescription() string {
 	return "Analyzes web applications for technologies and vulnerabilities"
 }
-func (wa *WebAnalyzer) Validate(target string) error              { return nil }
-func (wa *WebAnalyzer) GetDefaultOptions() map[string]interface{} { return map[string]interface{}{} }
-func (wa *WebAnalyzer) Execute(target string, options map[string]interface{}) (*ScanResult, error) {
+func (wa *WebAnalyzer) Validate(target string) error  { return nil }
+func (wa *WebAnalyzer) GetOptionSchema() OptionSchema { return OptionSchema{} }
+func (wa *WebAnalyzer) Execute(ctx context.Context, target string, options Options) (*ScanResult, error) {
 	return &ScanResult{
 		ModuleName: wa.GetName(),
 		Target:     target,
-		Status:     "completed",
+		Status:     StatusCompleted,
 		Results:    []interface{}{},
 		StartTime:  time.Now().Format(time.RFC3339),
 		EndTime:    time.Now().Format(time.RFC3339),
@@ -340,13 +697,13 @@ func (ig *IPGeolocator) GetName() string { return "IP Geolocator" }
 func (ig *IPGeolocator) GetDescription() string {
 	return "Provides geolocation information for IP addresses"
 }
-func (ig *IPGeolocator) Validate(target string) error              { return nil }
-func (ig *IPGeolocator) GetDefaultOptions() map[string]interface{} { return map[string]interface{}{} }
-func (ig *IPGeolocator) Execute(target string, options map[string]interface{}) (*ScanResult, error) {
+func (ig *IPGeolocator) Validate(target string) error  { return nil }
+func (ig *IPGeolocator) GetOptionSchema() OptionSchema { return OptionSchema{} }
+func (ig *IPGeolocator) Execute(ctx context.Context, target string, options Options) (*ScanResult, error) {
 	return &ScanResult{
 		ModuleName: ig.GetName(),
 		Target:     target,
-		Status:     "completed",
+		Status:     StatusCompleted,
 		Results:    []interface{}{},
 		StartTime:  time.Now().Format(time.RFC3339),
 		EndTime:    time.Now().Format(time.RFC3339),
@@ -368,13 +725,13 @@ func (gr *GitHubRecon) GetName() string { return "GitHub Reconnaissance" }
 func (gr *GitHubRecon) GetDescription() string {
 	return "Searches GitHub for sensitive information and code"
 }
-func (gr *GitHubRecon) Validate(target string) error              { return nil }
-func (gr *GitHubRecon) GetDefaultOptions() map[string]interface{} { return map[string]interface{}{} }
-func (gr *GitHubRecon) Execute(target string, options map[string]interface{}) (*ScanResult, error) {
+func (gr *GitHubRecon) Validate(target string) error  { return nil }
+func (gr *GitHubRecon) GetOptionSchema() OptionSchema { return OptionSchema{} }
+func (gr *GitHubRecon) Execute(ctx context.Context, target string, options Options) (*ScanResult, error) {
 	return &ScanResult{
 		ModuleName: gr.GetName(),
 		Target:     target,
-		Status:     "completed",
+		Status:     StatusCompleted,
 		Results:    []interface{}{},
 		StartTime:  time.Now().Format(time.RFC3339),
 		EndTime:    time.Now().Format(time.RFC3339),
@@ -0,0 +1,50 @@
+package modules
+
+import (
+	"GoReconX/internal/config"
+	"GoReconX/internal/dns"
+	"GoReconX/internal/scope"
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestScanAddresses(t *testing.T) {
+	// Names that only the resolver pool knows, so that the system resolver
+	// would fail them
+	pool := startResolver(t, map[string]standInAnswer{
+		"www.example.com": {addresses: []string{"192.0.2.1", "198.51.100.1", "192.0.2.2"}},
+		"out.example.com": {addresses: []string{"198.51.100.2"}},
+	})
+	engagement, err := scope.New(scope.Definition{Include: []string{"*.example.com", "192.0.2.0/24"}})
+	if err != nil {
+		t.Fatalf("scope: %v", err)
+	}
+	ctx := dns.NewContext(context.Background(), pool)
+	lookup := newLookup(ctx, 2)
+	ps := NewPortScanner(config.DefaultConfig(), quietLogger())
+
+	tests := []struct {
+		target       string
+		want         []string
+		wantExcluded []string
+		wantErr      bool
+	}{
+		{target: "www.example.com", want: []string{"192.0.2.1", "192.0.2.2"}, wantExcluded: []string{"198.51.100.1"}},
+		{target: "192.0.2.9", want: []string{"192.0.2.9"}},
+		{target: "out.example.com", wantExcluded: []string{"198.51.100.2"}, wantErr: true},
+		{target: "missing.example.com", wantErr: true},
+		{target: "www.example.net", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			addresses, excluded, err := ps.scanAddresses(ctx, engagement, tt.target, lookup)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(addresses, tt.want) || !reflect.DeepEqual(excluded, tt.wantExcluded) {
+				t.Errorf("addresses = %v excluding %v, want %v excluding %v", addresses, excluded, tt.want, tt.wantExcluded)
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package modules
+
+import (
+	"GoReconX/internal/config"
+	"GoReconX/internal/ratelimit"
+	"GoReconX/internal/scope"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// PluginManifestFile is the manifest every plugin directory must contain
+const PluginManifestFile = "plugin.yaml"
+
+// PluginManifest describes an external plugin module. Each plugin lives in
+// its own directory below the plugins directory:
+//
+//	plugins/crtsh/plugin.yaml
+//	plugins/crtsh/crtsh.py
+type PluginManifest struct {
+	ModuleInfo `yaml:",inline"`
+
+	// Command is the executable to run, relative to the plugin directory
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+	// Timeout in seconds, overriding Plugins.Timeout from the config
+	Timeout int          `yaml:"timeout"`
+	Options OptionSchema `yaml:"options"`
+
+	dir string
+}
+
+// pluginRequest is written as a single JSON line to the plugin's stdin.
+// Plugins must not actively probe hosts outside Scope when it is set and
+// are expected to keep to the RateLimit settings.
+type pluginRequest struct {
+	Target    string                 `json:"target"`
+	Options   Options                `json:"options"`
+	Scope     *scope.Definition      `json:"scope,omitempty"`
+	RateLimit config.RateLimitConfig `json:"rate_limit"`
+}
+
+// pluginMessage is one JSON line read from the plugin's stdout
+type pluginMessage struct {
+	Type        string                 `json:"type"`
+	Done        int                    `json:"done"`
+	Total       int                    `json:"total"`
+	FindingType string                 `json:"finding_type"`
+	Data        map[string]interface{} `json:"data"`
+	Level       string                 `json:"level"`
+	Message     string                 `json:"message"`
+}
+
+// PluginFinding is a finding reported by a plugin
+type PluginFinding struct {
+	Type string                 `json:"type"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// ResultType stores plugin findings under the type the plugin declared
+func (pf *PluginFinding) ResultType() string {
+	return pf.Type
+}
+
+// String returns the finding data as compact JSON
+func (pf *PluginFinding) String() string {
+	data, _ := json.Marshal(pf.Data)
+	return string(data)
+}
+
+// LoadPlugins discovers the plugins in dir and registers each of them as a
+// module. It must be called before module managers are created. A missing
+// directory is not an error; broken plugins are logged and skipped.
+func LoadPlugins(dir string, logger *logrus.Logger) ([]ModuleInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var loaded []ModuleInfo
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		pluginDir := filepath.Join(dir, entry.Name())
+		manifest, err := readPluginManifest(pluginDir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			logger.WithError(err).WithField("plugin", pluginDir).Warn("Skipping invalid plugin")
+			continue
+		}
+
+		err = register(manifest.ModuleInfo, func(cfg *config.Config, logger *logrus.Logger) ModuleInterface {
+			return NewPluginModule(manifest, cfg, logger)
+		})
+		if err != nil {
+			logger.WithError(err).WithField("plugin", pluginDir).Warn("Skipping plugin")
+			continue
+		}
+
+		logger.WithField("plugin", manifest.ID).Info("Loaded plugin module")
+		loaded = append(loaded, manifest.ModuleInfo)
+	}
+
+	return loaded, nil
+}
+
+// readPluginManifest loads and checks the manifest in pluginDir
+func readPluginManifest(pluginDir string) (*PluginManifest, error) {
+	data, err := os.ReadFile(filepath.Join(pluginDir, PluginManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &PluginManifest{dir: pluginDir}
+	if err := yaml.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+
+	if manifest.ID == "" || manifest.Command == "" {
+		return nil, fmt.Errorf("manifest must set id and command")
+	}
+	if manifest.Name == "" {
+		manifest.Name = manifest.ID
+	}
+	switch manifest.Category {
+	case CategoryPassive, CategoryActive:
+	case "":
+		manifest.Category = CategoryPassive
+	default:
+		return nil, fmt.Errorf("unknown category %q", manifest.Category)
+	}
+
+	// Reject option schemas that could never validate
+	for i, spec := range manifest.Options {
+		if spec.Default != nil {
+			value, err := spec.Coerce(spec.Default)
+			if err != nil {
+				return nil, fmt.Errorf("invalid default: %v", err)
+			}
+			manifest.Options[i].Default = value
+		}
+	}
+
+	// The command runs with the plugin directory as its working directory,
+	// so resolve it to an absolute path up front
+	command := manifest.Command
+	if !filepath.IsAbs(command) {
+		command = filepath.Join(pluginDir, command)
+	}
+	command, err = filepath.Abs(command)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(command)
+	if err != nil {
+		return nil, fmt.Errorf("plugin command: %v", err)
+	}
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return nil, fmt.Errorf("plugin command %s is not executable", command)
+	}
+	manifest.Command = command
+
+	return manifest, nil
+}
+
+// PluginModule runs an external executable as a module, speaking a
+// JSON-lines protocol over its stdin and stdout
+type PluginModule struct {
+	manifest *PluginManifest
+	config   *config.Config
+	logger   *logrus.Logger
+}
+
+// NewPluginModule creates a module backed by the plugin in manifest
+func NewPluginModule(manifest *PluginManifest, cfg *config.Config, logger *logrus.Logger) *PluginModule {
+	return &PluginModule{manifest: manifest, config: cfg, logger: logger}
+}
+
+// GetName returns the module name
+func (pm *PluginModule) GetName() string { return pm.manifest.Name }
+
+// GetDescription returns the module description
+func (pm *PluginModule) GetDescription() string { return pm.manifest.Description }
+
+// GetOptionSchema returns the options declared in the plugin manifest
+func (pm *PluginModule) GetOptionSchema() OptionSchema { return pm.manifest.Options }
+
+// Validate validates the target
+func (pm *PluginModule) Validate(target string) error {
+	if strings.TrimSpace(target) == "" {
+		return fmt.Errorf("target cannot be empty")
+	}
+	return nil
+}
+
+// Execute runs the plugin and collects its findings
+func (pm *PluginModule) Execute(ctx context.Context, target string, options Options) (*ScanResult, error) {
+	startTime := time.Now()
+	logger := pm.logger.WithFields(logrus.Fields{"plugin": pm.manifest.ID, "target": target})
+	logger.Info("Starting plugin")
+
+	result := &ScanResult{
+		ModuleName: pm.GetName(),
+		Target:     target,
+		Status:     StatusRunning,
+		StartTime:  startTime.Format(time.RFC3339),
+		Metadata:   make(map[string]interface{}),
+	}
+
+	fail := func(err error) (*ScanResult, error) {
+		result.Status = StatusFailed
+		result.ErrorMessage = err.Error()
+		result.EndTime = time.Now().Format(time.RFC3339)
+		return result, err
+	}
+
+	req := pluginRequest{
+		Target:    target,
+		Options:   options,
+		RateLimit: ratelimit.FromContext(ctx).Settings(),
+	}
+	if engagement := scope.FromContext(ctx); engagement != nil {
+		def := engagement.Definition()
+		req.Scope = &def
+	}
+	request, err := json.Marshal(req)
+	if err != nil {
+		return fail(err)
+	}
+
+	timeout := pm.manifest.Timeout
+	if timeout <= 0 {
+		timeout = pm.config.Plugins.Timeout
+	}
+	runCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(runCtx, pm.manifest.Command, pm.manifest.Args...)
+	cmd.Dir = pm.manifest.dir
+	cmd.Stdin = bytes.NewReader(append(request, '\n'))
+	// Ask the plugin to stop first and kill it if it does not exit in time
+	cmd.Cancel = func() error { return interruptProcess(cmd.Process) }
+	cmd.WaitDelay = 5 * time.Second
+
+	// Plugin output goes through a pipe that is closed once the process has
+	// been waited for, so a child process that inherited stdout cannot keep
+	// the reader below blocked after a timeout
+	stdout, stdoutWriter := io.Pipe()
+	stderrWriter := logger.WriterLevel(logrus.DebugLevel)
+	defer stderrWriter.Close()
+	cmd.Stdout = stdoutWriter
+	cmd.Stderr = stderrWriter
+
+	if err := cmd.Start(); err != nil {
+		return fail(fmt.Errorf("failed to start plugin: %v", err))
+	}
+
+	var waitErr error
+	waited := make(chan struct{})
+	go func() {
+		waitErr = cmd.Wait()
+		stdoutWriter.Close()
+		close(waited)
+	}()
+
+	progress := newProgressReporter(ctx, pm.GetName(), target, 0)
+	var pluginError string
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg pluginMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			logger.WithField("line", string(line)).Debug("Ignoring non-JSON plugin output")
+			continue
+		}
+
+		switch msg.Type {
+		case "progress":
+			progress.Update(msg.Done, msg.Total)
+		case "finding":
+			findingType := msg.FindingType
+			if findingType == "" {
+				findingType = pm.manifest.ID
+			}
+			finding := &PluginFinding{Type: findingType, Data: msg.Data}
+			result.Results = append(result.Results, finding)
+			progress.Finding(finding)
+		case "metadata":
+			for key, value := range msg.Data {
+				result.Metadata[key] = value
+			}
+		case "log":
+			level, err := logrus.ParseLevel(msg.Level)
+			if err != nil {
+				level = logrus.InfoLevel
+			}
+			logger.Log(level, msg.Message)
+		case "error":
+			pluginError = msg.Message
+		default:
+			logger.WithField("type", msg.Type).Debug("Ignoring unknown plugin message")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		logger.WithError(err).Warn("Failed to read plugin output")
+		// Drain the rest so the plugin is not blocked writing to a full pipe
+		io.Copy(io.Discard, stdout)
+	}
+	<-waited
+
+	endTime := time.Now()
+	result.EndTime = endTime.Format(time.RFC3339)
+	result.Metadata["findings"] = len(result.Results)
+	result.Metadata["duration_seconds"] = endTime.Sub(startTime).Seconds()
+
+	switch {
+	case ctx.Err() != nil:
+		result.Status = StatusCancelled
+		result.ErrorMessage = ctx.Err().Error()
+		logger.Warn("Plugin cancelled")
+		return result, ctx.Err()
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		err := fmt.Errorf("plugin timed out after %d seconds", timeout)
+		logger.Warn(err.Error())
+		return fail(err)
+	case pluginError != "":
+		return fail(errors.New(pluginError))
+	case waitErr != nil:
+		return fail(fmt.Errorf("plugin exited with error: %v", waitErr))
+	}
+
+	result.Status = StatusCompleted
+	logger.WithField("findings", len(result.Results)).Info("Plugin completed")
+	return result, nil
+}
+
+// interruptProcess asks process to stop. Where interrupts cannot be sent,
+// as on Windows, the process is killed instead.
+func interruptProcess(process *os.Process) error {
+	if runtime.GOOS != "windows" {
+		err := process.Signal(os.Interrupt)
+		if err == nil || errors.Is(err, os.ErrProcessDone) {
+			return err
+		}
+	}
+	return process.Kill()
+}
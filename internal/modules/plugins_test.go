@@ -0,0 +1,39 @@
+package modules
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestInterruptProcess(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("no sleep command")
+	}
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if err := interruptProcess(cmd.Process); err != nil {
+		t.Fatalf("interruptProcess: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("interrupted process exited cleanly")
+		}
+	case <-time.After(5 * time.Second):
+		cmd.Process.Kill()
+		t.Fatal("process still running after the interrupt")
+	}
+
+	// A process that is gone already is no error to the caller of Cancel
+	if err := interruptProcess(cmd.Process); !errors.Is(err, os.ErrProcessDone) {
+		t.Errorf("err = %v, want os.ErrProcessDone", err)
+	}
+}
@@ -0,0 +1,160 @@
+package modules
+
+import (
+	"GoReconX/internal/engine"
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultRecursiveWords are written to the recursive wordlist when it does
+// not exist. Levels below a subdomain hold fewer, more generic names than
+// the top level, so the list is kept short.
+var defaultRecursiveWords = []string{
+	"www", "api", "dev", "test", "staging", "stage", "qa", "uat", "prod",
+	"admin", "internal", "intranet", "portal", "app", "apps", "mail", "vpn",
+	"remote", "git", "jenkins", "ci", "build", "db", "sql", "ldap", "auth",
+	"sso", "login", "beta", "demo", "old", "new", "backup", "static", "cdn",
+	"m", "monitor", "grafana", "kibana", "files",
+}
+
+// SubdomainNode is a subdomain in the hierarchy of a scan's findings, with
+// the subdomains found directly below it
+type SubdomainNode struct {
+	Name     string           `json:"name"`
+	IPs      []string         `json:"ips,omitempty"`
+	Children []*SubdomainNode `json:"children,omitempty"`
+}
+
+// enumerateRecursively brute-forces the words below every subdomain found,
+// then below the names that turn up, for depth levels. Levels with a
+// wildcard record are skipped, as every name below them resolves; their
+// names are returned along with the new subdomains and the number of levels
+// run.
+func (se *SubdomainEnumerator) enumerateRecursively(ctx context.Context, run *enumeration, found []*SubdomainResult, words []string, depth int) ([]*SubdomainResult, []string, int) {
+	seen := make(map[string]bool)
+	for _, result := range found {
+		seen[strings.ToLower(result.Subdomain)] = true
+	}
+
+	var discovered []*SubdomainResult
+	var skipped []string
+	parents := found
+	levels := 0
+	for levels < depth && len(parents) > 0 && ctx.Err() == nil {
+		levels++
+
+		var candidates []string
+		for _, parent := range parents {
+			name := strings.ToLower(parent.Subdomain)
+			if parent.Wildcard || run.wildcards.Detect(ctx, name) != nil {
+				skipped = append(skipped, name)
+				continue
+			}
+			for _, word := range words {
+				candidate := word + "." + name
+				if !seen[candidate] {
+					seen[candidate] = true
+					candidates = append(candidates, candidate)
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		se.logger.WithFields(logrus.Fields{
+			"depth":      levels,
+			"candidates": len(candidates),
+		}).Info("Brute-forcing below found subdomains")
+		run.progress.Grow(len(candidates))
+
+		job := engine.Run(ctx, run.threads, engine.Slice(candidates), func(ctx context.Context, name string) (*SubdomainResult, bool) {
+			defer run.progress.Advance(1)
+			result := run.resolve(ctx, name, SourceRecursion)
+			return result, result != nil && ctx.Err() == nil
+		})
+
+		parents = nil
+		job.Collect(func(result *SubdomainResult) {
+			parents = append(parents, result)
+			run.progress.Finding(result)
+
+			se.logger.WithFields(logrus.Fields{
+				"subdomain": result.Subdomain,
+				"ips":       result.IPs,
+			}).Debug("Found nested subdomain")
+		})
+		discovered = append(discovered, parents...)
+	}
+
+	sort.Strings(skipped)
+	return discovered, skipped, levels
+}
+
+// linkParents sets the parent of every result to the closest name above it
+// that was found too, or domain if there is none
+func linkParents(domain string, results []*SubdomainResult) {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	found := make(map[string]bool, len(results))
+	for _, result := range results {
+		found[strings.ToLower(result.Subdomain)] = true
+	}
+
+	for _, result := range results {
+		result.Parent = domain
+		name := strings.ToLower(result.Subdomain)
+		for {
+			_, above, ok := strings.Cut(name, ".")
+			if !ok || above == domain || !strings.HasSuffix(above, "."+domain) {
+				break
+			}
+			if found[above] {
+				result.Parent = above
+				break
+			}
+			name = above
+		}
+	}
+}
+
+// SubdomainTree arranges subdomains below domain by their parents. Results
+// without a parent, or whose parent is not among them, hang off the root.
+func SubdomainTree(domain string, results []*SubdomainResult) *SubdomainNode {
+	root := &SubdomainNode{Name: strings.ToLower(strings.TrimSuffix(domain, "."))}
+	nodes := map[string]*SubdomainNode{root.Name: root}
+	for _, result := range results {
+		name := strings.ToLower(result.Subdomain)
+		if _, ok := nodes[name]; !ok {
+			nodes[name] = &SubdomainNode{Name: name, IPs: result.IPs}
+		}
+	}
+
+	attached := make(map[string]bool)
+	for _, result := range results {
+		name := strings.ToLower(result.Subdomain)
+		if name == root.Name || attached[name] {
+			continue
+		}
+		attached[name] = true
+		parent, ok := nodes[strings.ToLower(result.Parent)]
+		if !ok || parent == nodes[name] {
+			parent = root
+		}
+		parent.Children = append(parent.Children, nodes[name])
+	}
+
+	sortTree(root)
+	return root
+}
+
+// sortTree orders the children of every node by name
+func sortTree(node *SubdomainNode) {
+	sort.Slice(node.Children, func(i, j int) bool {
+		return node.Children[i].Name < node.Children[j].Name
+	})
+	for _, child := range node.Children {
+		sortTree(child)
+	}
+}
@@ -0,0 +1,110 @@
+package modules
+
+import (
+	"GoReconX/internal/config"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ModuleCategory groups modules by how they interact with the target
+type ModuleCategory string
+
+const (
+	// CategoryPassive modules gather information without touching the target
+	CategoryPassive ModuleCategory = "passive"
+	// CategoryActive modules interact with the target directly
+	CategoryActive ModuleCategory = "active"
+)
+
+// Inputs a module may require as its target. Modules taking an InputRange
+// get CIDR and IP ranges as a single target rather than one per address.
+const (
+	InputDomain = "domain"
+	InputIP     = "ip"
+	InputRange  = "range"
+	InputURL    = "url"
+	InputText   = "text"
+)
+
+// ModuleInfo describes a registered module
+type ModuleInfo struct {
+	ID             string         `json:"id" yaml:"id"`
+	Name           string         `json:"name" yaml:"name"`
+	Category       ModuleCategory `json:"category" yaml:"category"`
+	Description    string         `json:"description" yaml:"description"`
+	RequiredInputs []string       `json:"required_inputs" yaml:"required_inputs"`
+}
+
+// ModuleFactory creates a module instance for a module manager
+type ModuleFactory func(cfg *config.Config, logger *logrus.Logger) ModuleInterface
+
+// registration pairs a module description with its factory
+type registration struct {
+	info    ModuleInfo
+	factory ModuleFactory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]registration)
+)
+
+// Register makes a module available to every module manager created
+// afterwards. It is meant to be called from the init function of the file
+// that implements the module and panics if the ID is already taken.
+func Register(info ModuleInfo, factory ModuleFactory) {
+	if err := register(info, factory); err != nil {
+		panic("modules: " + err.Error())
+	}
+}
+
+// register adds a module to the registry unless its ID is already taken
+func register(info ModuleInfo, factory ModuleFactory) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if info.ID == "" || factory == nil {
+		return fmt.Errorf("module registered with an empty ID or nil factory")
+	}
+	if _, exists := registry[info.ID]; exists {
+		return fmt.Errorf("module %s is already registered", info.ID)
+	}
+	registry[info.ID] = registration{info: info, factory: factory}
+	return nil
+}
+
+// RegisteredModules returns the description of every registered module,
+// sorted by category and name
+func RegisteredModules() []ModuleInfo {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	infos := make([]ModuleInfo, 0, len(registry))
+	for _, reg := range registry {
+		infos = append(infos, reg.info)
+	}
+	sortModuleInfos(infos)
+	return infos
+}
+
+// LookupModule returns the description of a registered module
+func LookupModule(id string) (ModuleInfo, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	reg, ok := registry[id]
+	return reg.info, ok
+}
+
+// sortModuleInfos orders module descriptions by category, then name
+func sortModuleInfos(infos []ModuleInfo) {
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Category != infos[j].Category {
+			return infos[i].Category > infos[j].Category // passive first
+		}
+		return infos[i].Name < infos[j].Name
+	})
+}
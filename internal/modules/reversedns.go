@@ -0,0 +1,420 @@
+package modules
+
+import (
+	"GoReconX/internal/config"
+	"GoReconX/internal/dns"
+	"GoReconX/internal/engine"
+	"GoReconX/internal/ratelimit"
+	"GoReconX/internal/scope"
+	"GoReconX/internal/targets"
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func init() {
+	Register(ModuleInfo{
+		ID:             "reverse_dns",
+		Name:           "Reverse DNS Sweep",
+		Category:       CategoryPassive,
+		Description:    "Looks up the PTR records of every address in an IP range to find the hostnames behind it",
+		RequiredInputs: []string{InputRange},
+	}, func(cfg *config.Config, logger *logrus.Logger) ModuleInterface {
+		return NewReverseDNS(cfg, logger)
+	})
+}
+
+// ReverseDNS sweeps IP ranges for PTR records and reports the hostnames
+// below the engagement's domains as subdomains
+type ReverseDNS struct {
+	config *config.Config
+	logger *logrus.Logger
+}
+
+// reverseLookupFunc returns the PTR records of an address
+type reverseLookupFunc func(ctx context.Context, addr netip.Addr) ([]dns.Record, error)
+
+// NewReverseDNS creates a new reverse DNS sweep module
+func NewReverseDNS(cfg *config.Config, logger *logrus.Logger) *ReverseDNS {
+	return &ReverseDNS{config: cfg, logger: logger}
+}
+
+// GetName returns the module name
+func (rd *ReverseDNS) GetName() string {
+	return "Reverse DNS Sweep"
+}
+
+// GetDescription returns the module description
+func (rd *ReverseDNS) GetDescription() string {
+	return "Looks up the PTR records of every address in an IP range to find the hostnames behind it"
+}
+
+// Validate checks that the target is a CIDR range, an IP range or an IP
+// address
+func (rd *ReverseDNS) Validate(target string) error {
+	if target == "" {
+		return fmt.Errorf("target range cannot be empty")
+	}
+	_, _, err := targets.Range(target)
+	return err
+}
+
+// GetOptionSchema returns the options accepted by the module
+func (rd *ReverseDNS) GetOptionSchema() OptionSchema {
+	return OptionSchema{
+		{Name: "domains", Type: OptionString, Default: "", Description: "Domains the hostnames found must belong to, e.g. example.com,example.net; defaults to the domains in scope, or any domain if the scope names none"},
+		{Name: "threads", Type: OptionInt, Default: 50, Range: &OptionRange{Min: 1, Max: 1000}, Description: "Number of concurrent DNS lookups"},
+		{Name: "timeout", Type: OptionInt, Default: 5, Range: &OptionRange{Min: 1, Max: 60}, Description: "DNS lookup timeout in seconds"},
+	}
+}
+
+// Execute looks up the PTR records of every address in the target range.
+// Each record is reported, and each hostname belonging to one of the
+// domains is resolved and reported as a subdomain of it.
+func (rd *ReverseDNS) Execute(ctx context.Context, target string, options Options) (*ScanResult, error) {
+	startTime := time.Now()
+	rd.logger.WithField("target", target).Info("Starting reverse DNS sweep")
+
+	result := &ScanResult{
+		ModuleName: rd.GetName(),
+		Target:     target,
+		Status:     StatusRunning,
+		StartTime:  startTime.Format(time.RFC3339),
+		Metadata:   make(map[string]interface{}),
+	}
+	fail := func(err error) (*ScanResult, error) {
+		result.Status = StatusFailed
+		result.ErrorMessage = err.Error()
+		result.EndTime = time.Now().Format(time.RFC3339)
+		return result, err
+	}
+
+	first, last, err := targets.Range(target)
+	if err != nil {
+		return fail(err)
+	}
+	engagement := scope.FromContext(ctx)
+	domains := splitList(strings.ToLower(options.String("domains")))
+	if len(domains) == 0 {
+		domains = engagement.Domains()
+	}
+	for i, domain := range domains {
+		domains[i] = strings.TrimSuffix(domain, ".")
+	}
+
+	// Continue from the checkpoint of an interrupted run
+	cp, err := newCheckpoint[dns.Record](ctx, func() (string, error) {
+		return checkpointInput(target, options)
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	size := targets.Size(first, last)
+	threads := options.Int("threads")
+	timeout := options.Int("timeout")
+	progress := newProgressReporter(ctx, rd.GetName(), target, size)
+	records, failed := rd.sweep(ctx, first, last, threads, newReverseLookup(ctx, timeout), progress, cp)
+	cp.Flush()
+	records = append(cp.Restored(), records...)
+	sort.Slice(records, func(i, j int) bool {
+		return compareReverse(records[i].Name, records[j].Name) < 0
+	})
+
+	// Hostnames outside the domains are reported as records only, since
+	// a shared range names hosts of other organisations too
+	addresses := make(map[string][]string)
+	seen := make(map[string]bool)
+	var hostnames []string
+	filtered := 0
+	for _, record := range records {
+		result.Results = append(result.Results, &DNSRecordResult{Record: record})
+		if address := reverseAddress(record.Name); address != "" {
+			addresses[record.Value] = appendUnique(addresses[record.Value], address)
+		}
+		if seen[record.Value] {
+			continue
+		}
+		seen[record.Value] = true
+		if len(domains) > 0 && ownerDomain(record.Value, domains) == "" {
+			filtered++
+			continue
+		}
+		hostnames = append(hostnames, record.Value)
+	}
+
+	subdomains, confirmed := rd.resolveHostnames(ctx, hostnames, addresses, threads, newLookup(ctx, timeout), engagement, progress)
+	byDomain := make(map[string][]*SubdomainResult)
+	outOfScope := 0
+	for _, subdomain := range subdomains {
+		domain := ownerDomain(subdomain.Subdomain, domains)
+		byDomain[domain] = append(byDomain[domain], subdomain)
+		if subdomain.OutOfScope {
+			outOfScope++
+		}
+		result.Results = append(result.Results, subdomain)
+	}
+	for domain, results := range byDomain {
+		if domain != "" {
+			linkParents(domain, results)
+		}
+	}
+
+	endTime := time.Now()
+	result.Status = StatusCompleted
+	result.EndTime = endTime.Format(time.RFC3339)
+	result.Metadata["addresses"] = size
+	result.Metadata["domains"] = domains
+	result.Metadata["ptr_records"] = len(records)
+	result.Metadata["failed_lookups"] = failed
+	result.Metadata["filtered_hostnames"] = filtered
+	result.Metadata["subdomains"] = len(subdomains)
+	result.Metadata["forward_confirmed"] = confirmed
+	result.Metadata["out_of_scope"] = outOfScope
+	result.Metadata["duration_seconds"] = endTime.Sub(startTime).Seconds()
+
+	if err := ctx.Err(); err != nil {
+		result.Status = StatusCancelled
+		result.ErrorMessage = err.Error()
+		rd.logger.WithFields(logrus.Fields{
+			"target":      target,
+			"ptr_records": len(records),
+		}).Warn("Reverse DNS sweep cancelled")
+		return result, err
+	}
+
+	rd.logger.WithFields(logrus.Fields{
+		"target":      target,
+		"ptr_records": len(records),
+		"subdomains":  len(subdomains),
+		"duration":    endTime.Sub(startTime),
+	}).Info("Reverse DNS sweep completed")
+
+	return result, nil
+}
+
+// sweep looks up the PTR records of every address from first to last using
+// a fixed pool of workers, skipping the addresses the checkpoint has seen
+// finished. It returns the records found and the number of failed lookups.
+func (rd *ReverseDNS) sweep(ctx context.Context, first, last netip.Addr, threads int, lookup reverseLookupFunc, progress *progressReporter, cp *checkpoint[dns.Record]) ([]dns.Record, int64) {
+	// Number the addresses by their offset in the range, which is how the
+	// checkpoint refers to them
+	numbered := func(ctx context.Context, yield func(engine.Pair[int, netip.Addr]) bool) error {
+		index := 0
+		for addr := first; addr.IsValid() && addr.Compare(last) <= 0; addr = addr.Next() {
+			index++
+			if cp.Finished(index) {
+				progress.Advance(1)
+				continue
+			}
+			if !yield(engine.Pair[int, netip.Addr]{First: index, Second: addr}) {
+				break
+			}
+		}
+		return nil
+	}
+
+	var failed int64
+	job := engine.Run(ctx, threads, numbered, func(ctx context.Context, addr engine.Pair[int, netip.Addr]) ([]dns.Record, bool) {
+		defer progress.Advance(1)
+
+		// A lookup cut short by cancellation is repeated on resume
+		records, err := lookup(ctx, addr.Second)
+		if ctx.Err() != nil {
+			return nil, false
+		}
+		if err != nil {
+			atomic.AddInt64(&failed, 1)
+			rd.logger.WithFields(logrus.Fields{
+				"address": addr.Second,
+				"error":   err,
+			}).Debug("PTR lookup failed")
+		}
+		cp.Done(addr.First, records...)
+		return records, len(records) > 0
+	})
+
+	var found []dns.Record
+	job.Collect(func(records []dns.Record) {
+		for _, record := range records {
+			found = append(found, record)
+			progress.Finding(&DNSRecordResult{Record: record})
+		}
+	})
+	return found, atomic.LoadInt64(&failed)
+}
+
+// resolveHostnames resolves the hostnames found and returns them as
+// subdomains, along with how many resolve back to an address that named
+// them. Hostnames that do not resolve are kept, as their PTR records show
+// they were in use.
+func (rd *ReverseDNS) resolveHostnames(ctx context.Context, hostnames []string, addresses map[string][]string, threads int, lookup lookupFunc, engagement *scope.Scope, progress *progressReporter) ([]*SubdomainResult, int) {
+	progress.Grow(len(hostnames))
+	job := engine.Run(ctx, threads, engine.Slice(hostnames), func(ctx context.Context, name string) (engine.Pair[*SubdomainResult, bool], bool) {
+		defer progress.Advance(1)
+
+		ips, err := lookup(ctx, name)
+		if ctx.Err() != nil {
+			return engine.Pair[*SubdomainResult, bool]{}, false
+		}
+		result := &SubdomainResult{
+			Subdomain:  name,
+			OutOfScope: !inScope(engagement, name, ips),
+			Sources:    []string{SourceReverseDNS},
+		}
+		confirmed := false
+		if err == nil && len(ips) > 0 {
+			result.Resolved = true
+			for _, ip := range ips {
+				address := ip.IP.String()
+				result.IPs = append(result.IPs, address)
+				for _, swept := range addresses[name] {
+					confirmed = confirmed || swept == address
+				}
+			}
+		}
+		return engine.Pair[*SubdomainResult, bool]{First: result, Second: confirmed}, true
+	})
+
+	var results []*SubdomainResult
+	confirmed := 0
+	job.Collect(func(found engine.Pair[*SubdomainResult, bool]) {
+		results = append(results, found.First)
+		if found.Second {
+			confirmed++
+		}
+		progress.Finding(found.First)
+
+		rd.logger.WithFields(logrus.Fields{
+			"subdomain": found.First.Subdomain,
+			"ips":       found.First.IPs,
+		}).Debug("Found subdomain by reverse DNS")
+	})
+	sort.Slice(results, func(i, j int) bool {
+		return dns.CompareNames(results[i].Subdomain, results[j].Subdomain) < 0
+	})
+	return results, confirmed
+}
+
+// newReverseLookup returns a rate limited PTR lookup with the given timeout
+// in seconds, going through the configured resolver pool, or the system
+// resolver if there is none, like newLookup. Hostnames are lower case and
+// without a trailing dot.
+func newReverseLookup(ctx context.Context, timeout int) reverseLookupFunc {
+	if pool := dns.FromContext(ctx); pool != nil {
+		return func(ctx context.Context, addr netip.Addr) ([]dns.Record, error) {
+			name, err := dns.ReverseName(net.IP(addr.AsSlice()))
+			if err != nil {
+				return nil, err
+			}
+			lookupCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+			defer cancel()
+
+			answers, err := pool.Lookup(lookupCtx, name, dnsmessage.TypePTR)
+			var records []dns.Record
+			for _, record := range answers {
+				if record.Type == "PTR" {
+					record.Name = strings.ToLower(record.Name)
+					record.Value = strings.ToLower(strings.TrimSuffix(record.Value, "."))
+					records = append(records, record)
+				}
+			}
+			return records, err
+		}
+	}
+
+	resolver := &net.Resolver{}
+	limiter := ratelimit.FromContext(ctx)
+
+	return func(ctx context.Context, addr netip.Addr) ([]dns.Record, error) {
+		name, err := dns.ReverseName(net.IP(addr.AsSlice()))
+		if err != nil {
+			return nil, err
+		}
+		if err := limiter.WaitResolver(ctx, systemResolver); err != nil {
+			return nil, err
+		}
+		lookupCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+
+		hostnames, err := resolver.LookupAddr(lookupCtx, addr.String())
+		if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+			err = nil
+		}
+		var records []dns.Record
+		for _, hostname := range hostnames {
+			records = append(records, dns.Record{
+				Name:  name,
+				Type:  "PTR",
+				Value: strings.ToLower(strings.TrimSuffix(hostname, ".")),
+			})
+		}
+		return records, err
+	}
+}
+
+// ownerDomain returns the domain of domains that name belongs to, the
+// longest if several match, or "" if it belongs to none
+func ownerDomain(name string, domains []string) string {
+	owner := ""
+	for _, domain := range domains {
+		if (name == domain || strings.HasSuffix(name, "."+domain)) && len(domain) > len(owner) {
+			owner = domain
+		}
+	}
+	return owner
+}
+
+// reverseAddress returns the address a reverse name such as
+// 4.3.2.1.in-addr.arpa stands for, or "" if it is not one
+func reverseAddress(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if labels, ok := strings.CutSuffix(name, ".in-addr.arpa"); ok {
+		octets := strings.Split(labels, ".")
+		if len(octets) != 4 {
+			return ""
+		}
+		for i, j := 0, len(octets)-1; i < j; i, j = i+1, j-1 {
+			octets[i], octets[j] = octets[j], octets[i]
+		}
+		if addr, err := netip.ParseAddr(strings.Join(octets, ".")); err == nil {
+			return addr.String()
+		}
+		return ""
+	}
+	if nibbles, ok := strings.CutSuffix(name, ".ip6.arpa"); ok {
+		digits := strings.Split(nibbles, ".")
+		if len(digits) != 32 {
+			return ""
+		}
+		var hex strings.Builder
+		for i := len(digits) - 1; i >= 0; i-- {
+			hex.WriteString(digits[i])
+			if i%4 == 0 && i > 0 {
+				hex.WriteByte(':')
+			}
+		}
+		if addr, err := netip.ParseAddr(hex.String()); err == nil {
+			return addr.String()
+		}
+	}
+	return ""
+}
+
+// compareReverse orders reverse names by the address they stand for
+func compareReverse(a, b string) int {
+	addrA, errA := netip.ParseAddr(reverseAddress(a))
+	addrB, errB := netip.ParseAddr(reverseAddress(b))
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	return addrA.Compare(addrB)
+}
@@ -0,0 +1,33 @@
+package modules
+
+import (
+	"GoReconX/internal/scope"
+	"net"
+)
+
+// inScope reports whether a host discovered during a scan, together with
+// every address it resolved to, may be probed actively
+func inScope(s *scope.Scope, host string, ips []net.IPAddr) bool {
+	if !s.Contains(host) {
+		return false
+	}
+	for _, ip := range ips {
+		if !s.ContainsResolved(ip.IP) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitByScope separates the addresses a target resolved to into those that
+// may be probed and those that must be left alone
+func splitByScope(s *scope.Scope, ips []net.IPAddr) (allowed, excluded []string) {
+	for _, ip := range ips {
+		if s.ContainsResolved(ip.IP) {
+			allowed = append(allowed, ip.IP.String())
+		} else {
+			excluded = append(excluded, ip.IP.String())
+		}
+	}
+	return allowed, excluded
+}
@@ -0,0 +1,236 @@
+package modules
+
+import (
+	"GoReconX/internal/engine"
+	"GoReconX/internal/ratelimit"
+	"GoReconX/internal/services"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxServiceResponse caps how much of an answer to a probe is read
+	maxServiceResponse = 64 << 10
+	// responseGap is how long to wait for more of an answer once it
+	// started arriving
+	responseGap = 300 * time.Millisecond
+	// maxBanner caps the length of the banners reported
+	maxBanner = 256
+)
+
+// serviceDetector identifies the services behind open ports by sending
+// them the probes of a database and matching their answers
+type serviceDetector struct {
+	db        *services.Database
+	timeout   time.Duration
+	intensity int
+	// serverName is sent in TLS handshakes so that virtual hosts answer
+	serverName string
+	limiter    *ratelimit.Limiter
+	logger     *logrus.Logger
+}
+
+// loadProbes reads the probe file, creating it with the built-in probes if
+// it does not exist
+func (ps *PortScanner) loadProbes(path string) (*services.Database, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		ps.logger.WithField("file", path).Warn("Service probes not found, creating defaults")
+		if err := services.Save(path, services.Defaults); err != nil {
+			return nil, fmt.Errorf("failed to create service probes: %v", err)
+		}
+	}
+
+	db, err := services.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load service probes: %v", err)
+	}
+	if db.Skipped > 0 {
+		ps.logger.WithFields(logrus.Fields{
+			"file":    path,
+			"skipped": db.Skipped,
+		}).Debug("Skipped service matches with unsupported patterns")
+	}
+	return db, nil
+}
+
+// detectServices identifies the services of the ports found, updating
+// them in place, and returns how many were identified
+func (ps *PortScanner) detectServices(ctx context.Context, target string, ports []*PortResult, threads int, sd *serviceDetector, progress *progressReporter) int {
+	progress.Grow(len(ports))
+	job := engine.Run(ctx, threads, engine.Slice(ports), func(ctx context.Context, port *PortResult) (*PortResult, bool) {
+		defer progress.Advance(1)
+		return port, sd.identify(ctx, target, port)
+	})
+
+	identified := 0
+	job.Collect(func(port *PortResult) {
+		identified++
+		ps.logger.WithFields(logrus.Fields{
+			"target":  target,
+			"port":    port.Port,
+			"service": port.Service,
+			"product": port.Product,
+			"version": port.Version,
+		}).Debug("Identified service")
+	})
+	return identified
+}
+
+// identify probes an open port and fills in the service, product, version,
+// CPE and banner its answers reveal. TLS services are probed again through
+// a TLS connection to find out what they carry, e.g. ssl/http. It reports
+// whether the service was identified.
+func (sd *serviceDetector) identify(ctx context.Context, host string, port *PortResult) bool {
+	protocol := services.TCP
+	if port.Protocol == "udp" {
+		protocol = services.UDP
+	}
+	if sd.db.Excluded(protocol, port.Port) {
+		return false
+	}
+
+	address := net.JoinHostPort(host, strconv.Itoa(port.Port))
+	service, banner := sd.probe(ctx, protocol, address, port.Port, nil)
+	if service != nil && service.Name == "ssl" && protocol == services.TCP {
+		config := &tls.Config{InsecureSkipVerify: true, ServerName: sd.serverName}
+		if inner, innerBanner := sd.probe(ctx, protocol, address, port.Port, config); inner != nil {
+			inner.Name = "ssl/" + inner.Name
+			service, banner = inner, innerBanner
+		}
+	}
+
+	// A UDP port answering a probe is open, whatever the scan made of it
+	if banner != nil && protocol == services.UDP {
+		port.State = PortOpen
+	}
+	if banner != nil {
+		port.Banner = services.Banner(banner, maxBanner)
+	}
+	if service == nil {
+		return false
+	}
+	port.Service = service.Name
+	port.Product = service.Product
+	port.Version = service.Version
+	port.Info = service.Info
+	port.CPE = service.CPE
+	return !service.Soft
+}
+
+// probe sends the probes for port one after another until an answer is
+// matched. After a soft match only probes that can tell more about that
+// service are sent. It returns the service found and the answer it was
+// found in, or the first answer when nothing matched.
+func (sd *serviceDetector) probe(ctx context.Context, protocol, address string, port int, config *tls.Config) (*services.Service, []byte) {
+	var soft *services.Service
+	var banner, softBanner []byte
+	for _, probe := range sd.db.ProbesFor(protocol, port, sd.intensity) {
+		if ctx.Err() != nil {
+			break
+		}
+		if soft != nil && !probe.HasMatchFor(soft.Name) {
+			continue
+		}
+		// Asking for TLS again inside TLS tells nothing
+		if config != nil && probe.HasMatchFor("ssl") {
+			continue
+		}
+
+		response, err := sd.exchange(ctx, protocol, address, probe, config)
+		if err != nil {
+			// The port went away or cannot be probed this way
+			if errors.Is(err, syscall.ECONNREFUSED) || config != nil {
+				break
+			}
+			continue
+		}
+		if banner == nil && len(response) > 0 {
+			banner = response
+		}
+
+		service := sd.db.Match(probe, response)
+		if service == nil {
+			continue
+		}
+		if !service.Soft {
+			return service, response
+		}
+		if soft == nil {
+			soft, softBanner = service, response
+		}
+	}
+	if soft != nil {
+		return soft, softBanner
+	}
+	return nil, banner
+}
+
+// exchange sends a probe over a new connection and returns the answer,
+// which is empty when the service stays silent
+func (sd *serviceDetector) exchange(ctx context.Context, protocol, address string, probe *services.Probe, config *tls.Config) ([]byte, error) {
+	host, _, _ := net.SplitHostPort(address)
+	if err := sd.limiter.WaitHost(ctx, host); err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: sd.timeout}
+	conn, err := dialer.DialContext(ctx, strings.ToLower(protocol), address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if config != nil {
+		tlsConn := tls.Client(conn, config)
+		tlsConn.SetDeadline(time.Now().Add(sd.timeout))
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	wait := sd.timeout
+	if probe.TotalWait > 0 && probe.TotalWait < wait {
+		wait = probe.TotalWait
+	}
+	deadline := time.Now().Add(wait)
+	conn.SetDeadline(deadline)
+	if len(probe.Payload) > 0 {
+		if _, err := conn.Write(probe.Payload); err != nil {
+			return nil, err
+		}
+	}
+
+	var response []byte
+	buf := make([]byte, 4096)
+	for len(response) < maxServiceResponse {
+		n, err := conn.Read(buf)
+		response = append(response, buf[:n]...)
+		if err != nil {
+			if len(response) == 0 && !errors.Is(err, os.ErrDeadlineExceeded) && !errors.Is(err, net.ErrClosed) {
+				return nil, err
+			}
+			break
+		}
+		// A datagram is the whole answer
+		if protocol == services.UDP {
+			break
+		}
+		if gap := time.Now().Add(responseGap); gap.Before(deadline) {
+			conn.SetReadDeadline(gap)
+		}
+	}
+	return response, ctx.Err()
+}
@@ -0,0 +1,63 @@
+package modules
+
+import (
+	"GoReconX/internal/config"
+	"GoReconX/internal/services"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// startBannerServer accepts connections on a local port, sending banner on
+// each and closing it
+func startBannerServer(t *testing.T, banner string) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte(banner))
+			conn.Close()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestDetectServices(t *testing.T) {
+	db, err := services.Parse([]byte(services.Defaults))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	sd := &serviceDetector{db: db, timeout: time.Second, intensity: 7, logger: quietLogger()}
+
+	ssh := &PortResult{Port: startBannerServer(t, "SSH-2.0-OpenSSH_9.6\r\n"), Protocol: "tcp", State: PortOpen}
+	silent := &PortResult{Port: startBannerServer(t, ""), Protocol: "tcp", State: PortOpen}
+
+	ps := NewPortScanner(config.DefaultConfig(), quietLogger())
+	progress := newProgressReporter(context.Background(), "Port Scanner", "127.0.0.1", 0)
+	if identified := ps.detectServices(context.Background(), "127.0.0.1", []*PortResult{ssh, silent}, 2, sd, progress); identified != 1 {
+		t.Errorf("identified %d services, want 1", identified)
+	}
+
+	if ssh.Service != "ssh" || ssh.Product != "OpenSSH" || ssh.Version != "9.6" {
+		t.Errorf("SSH port = %+v, want OpenSSH 9.6", ssh)
+	}
+	if ssh.Banner != `SSH-2.0-OpenSSH_9.6\r\n` {
+		t.Errorf("SSH banner = %q", ssh.Banner)
+	}
+	if len(ssh.CPE) == 0 || ssh.CPE[0] != "cpe:/a:openbsd:openssh:9.6" {
+		t.Errorf("SSH CPE = %v", ssh.CPE)
+	}
+	if silent.Service != "" || silent.Banner != "" {
+		t.Errorf("silent port = %+v, want nothing identified", silent)
+	}
+}
@@ -0,0 +1,32 @@
+package modules
+
+// Severities of findings that need attention
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// SeverityFinding is implemented by findings that point to a weakness and
+// are highlighted in reports, e.g. a nameserver that allows zone transfers
+type SeverityFinding interface {
+	Severity() string
+	String() string
+}
+
+// SeverityRank orders severities from low (1) to critical (4). Unknown
+// severities rank 0.
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityLow:
+		return 1
+	case SeverityMedium:
+		return 2
+	case SeverityHigh:
+		return 3
+	case SeverityCritical:
+		return 4
+	}
+	return 0
+}
@@ -2,18 +2,39 @@ package modules
 
 import (
 	"GoReconX/internal/config"
-	"bufio"
+	"GoReconX/internal/dns"
+	"GoReconX/internal/engine"
+	"GoReconX/internal/ratelimit"
+	"GoReconX/internal/scope"
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
 )
 
+func init() {
+	Register(ModuleInfo{
+		ID:             "subdomain_enumeration",
+		Name:           "Subdomain Enumerator",
+		Category:       CategoryPassive,
+		Description:    "Enumerates subdomains by wordlist-based DNS resolution and passive sources",
+		RequiredInputs: []string{InputDomain},
+	}, func(cfg *config.Config, logger *logrus.Logger) ModuleInterface {
+		return NewSubdomainEnumerator(cfg, logger)
+	})
+}
+
+// systemResolver names the operating system's resolver for rate limiting
+const systemResolver = "system"
+
 // SubdomainEnumerator handles subdomain enumeration
 type SubdomainEnumerator struct {
 	config *config.Config
@@ -25,6 +46,61 @@ type SubdomainResult struct {
 	Subdomain string   `json:"subdomain"`
 	IPs       []string `json:"ips"`
 	Resolved  bool     `json:"resolved"`
+	// OutOfScope marks subdomains that must not be probed actively
+	OutOfScope bool `json:"out_of_scope,omitempty"`
+	// Wildcard marks subdomains that only resolve to the answers of a
+	// wildcard record and may not exist at all
+	Wildcard bool `json:"wildcard,omitempty"`
+	// Records and the CNAME chain of the subdomain, if requested
+	Records    []dns.Record `json:"records,omitempty"`
+	CNAMEChain []string     `json:"cname_chain,omitempty"`
+	// Parent is the closest name above the subdomain that was found too,
+	// or the target domain
+	Parent string `json:"parent,omitempty"`
+	// Sources are the ways the subdomain was found, e.g. bruteforce or
+	// crtsh
+	Sources []string `json:"sources,omitempty"`
+}
+
+// Sources of the subdomains found by querying DNS rather than a passive
+// source
+const (
+	SourceBruteForce   = "bruteforce"
+	SourcePermutation  = "permutation"
+	SourceRecursion    = "recursion"
+	SourceZoneTransfer = "zone_transfer"
+	SourceNSEC         = "nsec"
+	SourceNSEC3        = "nsec3"
+	SourceReverseDNS   = "reverse_dns"
+	// SourceTLSCertificate marks names found in the certificates of TLS
+	// services
+	SourceTLSCertificate = "tls_certificate"
+)
+
+// String returns a short human readable form of the result
+func (sr *SubdomainResult) String() string {
+	s := sr.Subdomain
+	if len(sr.IPs) > 0 {
+		s = fmt.Sprintf("%s [%s]", sr.Subdomain, strings.Join(sr.IPs, ", "))
+	}
+	if len(sr.CNAMEChain) > 0 {
+		s += " -> " + strings.Join(sr.CNAMEChain, " -> ")
+	}
+	if !sr.Resolved {
+		s += " (unresolved)"
+	}
+	if sr.Wildcard {
+		s += " (wildcard)"
+	}
+	if sr.OutOfScope {
+		s += " (out of scope)"
+	}
+	return s
+}
+
+// ResultType identifies subdomain findings in the results table
+func (sr *SubdomainResult) ResultType() string {
+	return "subdomain"
 }
 
 // NewSubdomainEnumerator creates a new subdomain enumerator
@@ -42,7 +118,7 @@ func (se *SubdomainEnumerator) GetName() string {
 
 // GetDescription returns the module description
 func (se *SubdomainEnumerator) GetDescription() string {
-	return "Enumerates subdomains using wordlist-based DNS resolution"
+	return "Enumerates subdomains by wordlist-based DNS resolution and passive sources"
 }
 
 // Validate validates the target domain
@@ -59,66 +135,248 @@ func (se *SubdomainEnumerator) Validate(target string) error {
 	return nil
 }
 
-// GetDefaultOptions returns default options for the module
-func (se *SubdomainEnumerator) GetDefaultOptions() map[string]interface{} {
-	return map[string]interface{}{
-		"wordlist":    se.config.Wordlists.Subdomains,
-		"threads":     50,
-		"timeout":     5,
-		"resolve_ips": true,
+// GetOptionSchema returns the options accepted by the module
+func (se *SubdomainEnumerator) GetOptionSchema() OptionSchema {
+	return OptionSchema{
+		{Name: "wordlist", Type: OptionString, Default: se.config.Wordlists.Subdomains, Description: "Path to the subdomain wordlist"},
+		{Name: "bruteforce", Type: OptionBool, Default: true, Description: "Resolve every word of the wordlist; turn off to only use passive sources"},
+		{Name: "threads", Type: OptionInt, Default: 50, Range: &OptionRange{Min: 1, Max: 1000}, Description: "Number of concurrent DNS lookups"},
+		{Name: "timeout", Type: OptionInt, Default: 5, Range: &OptionRange{Min: 1, Max: 60}, Description: "DNS lookup timeout in seconds"},
+		{Name: "resolve_ips", Type: OptionBool, Default: true, Description: "Record the IP addresses of each subdomain"},
+		{Name: "filter_wildcards", Type: OptionBool, Default: true, Description: "Drop subdomains that only resolve to wildcard DNS answers instead of flagging them"},
+		{Name: "passive_sources", Type: OptionString, Default: "", Description: "Web services to ask for the subdomains they know of, by name from the sources config, or all"},
+		{Name: "import_files", Type: OptionString, Default: "", Description: "Comma-separated crt.sh, passive DNS, amass or subfinder output files to import subdomains from"},
+		{Name: "source_timeout", Type: OptionInt, Default: 60, Range: &OptionRange{Min: 1, Max: 600}, Description: "Timeout in seconds for each passive source"},
+		{Name: "records", Type: OptionString, Default: "", Description: "DNS record types to collect for each subdomain found, e.g. CNAME,MX,TXT, or all"},
+		{Name: "permutations", Type: OptionBool, Default: false, Description: "Resolve permutations of the subdomains found, such as dev-api, api2 or api-staging"},
+		{Name: "permutation_wordlist", Type: OptionString, Default: se.config.Wordlists.Permutations, Description: "Path to the words inserted into permutations"},
+		{Name: "permutation_depth", Type: OptionInt, Default: 2, Range: &OptionRange{Min: 1, Max: 10}, Description: "Rounds of permutations of the names each round finds"},
+		{Name: "recursive", Type: OptionBool, Default: false, Description: "Brute-force the levels below each subdomain found, e.g. *.corp.example.com"},
+		{Name: "recursive_wordlist", Type: OptionString, Default: se.config.Wordlists.Recursive, Description: "Path to the smaller wordlist used below found subdomains"},
+		{Name: "recursion_depth", Type: OptionInt, Default: 1, Range: &OptionRange{Min: 1, Max: 5}, Description: "Number of levels to brute-force below found subdomains"},
 	}
 }
 
 // Execute performs subdomain enumeration
-func (se *SubdomainEnumerator) Execute(target string, options map[string]interface{}) (*ScanResult, error) {
+func (se *SubdomainEnumerator) Execute(ctx context.Context, target string, options Options) (*ScanResult, error) {
 	startTime := time.Now()
 	se.logger.WithField("target", target).Info("Starting subdomain enumeration")
 
 	result := &ScanResult{
 		ModuleName: se.GetName(),
 		Target:     target,
-		Status:     "running",
+		Status:     StatusRunning,
 		StartTime:  startTime.Format(time.RFC3339),
 		Metadata:   make(map[string]interface{}),
 	}
 
 	// Get options
-	wordlistPath, _ := options["wordlist"].(string)
-	threads, _ := options["threads"].(int)
-	timeout, _ := options["timeout"].(int)
-	resolveIPs, _ := options["resolve_ips"].(bool)
+	wordlistPath := options.String("wordlist")
+	threads := options.Int("threads")
+	timeout := options.Int("timeout")
 
 	if wordlistPath == "" {
 		wordlistPath = se.config.Wordlists.Subdomains
 	}
 
-	// Load wordlist
-	subdomains, err := se.loadWordlist(wordlistPath)
+	// Open wordlist, unless only passive sources are used
+	words, size := engine.Slice[string](nil), 0
+	var err error
+	if options.Bool("bruteforce") {
+		words, size, err = se.openWordlist(wordlistPath)
+		if err != nil {
+			result.Status = StatusFailed
+			result.ErrorMessage = fmt.Sprintf("Failed to load wordlist: %v", err)
+			result.EndTime = time.Now().Format(time.RFC3339)
+			return result, err
+		}
+
+		se.logger.WithField("wordlist_size", size).Info("Loaded subdomain wordlist")
+	}
+
+	// Continue from the checkpoint of an interrupted run
+	cp, err := newCheckpoint[*SubdomainResult](ctx, func() (string, error) {
+		// Checkpoint keys are line numbers of the wordlist
+		wordlist := ""
+		if options.Bool("bruteforce") {
+			hash, err := hashFile(wordlistPath)
+			if err != nil {
+				return "", fmt.Errorf("failed to read wordlist: %v", err)
+			}
+			wordlist = hash
+		}
+		return checkpointInput(target, options, wordlist)
+	})
+	if err != nil {
+		result.Status = StatusFailed
+		result.ErrorMessage = err.Error()
+		result.EndTime = time.Now().Format(time.RFC3339)
+		return result, err
+	}
+
+	run := &enumeration{
+		domain:          target,
+		threads:         threads,
+		resolveIPs:      options.Bool("resolve_ips"),
+		filterWildcards: options.Bool("filter_wildcards"),
+		engagement:      scope.FromContext(ctx),
+		lookup:          newLookup(ctx, timeout),
+		progress:        newProgressReporter(ctx, se.GetName(), target, size),
+		checkpoint:      cp,
+	}
+	run.wildcards = newWildcardDetector(run.lookup)
+
+	// Collect the records of each subdomain found, if requested
+	if spec := options.String("records"); spec != "" {
+		types, err := parseRecordTypes(spec)
+		if err == nil {
+			run.recordTypes = types
+			run.records = &recordCollector{timeout: time.Duration(timeout) * time.Second, threads: 1}
+			run.records.pool, err = recordPool(ctx, se.config, se.logger)
+		}
+		if err != nil {
+			result.Status = StatusFailed
+			result.ErrorMessage = err.Error()
+			result.EndTime = time.Now().Format(time.RFC3339)
+			return result, err
+		}
+	}
+
+	// Find out whether every name below the target resolves
+	if answers := run.wildcards.Detect(ctx, target); answers != nil {
+		se.logger.WithFields(logrus.Fields{
+			"domain":  target,
+			"answers": len(answers),
+		}).Warn("Wildcard DNS record detected")
+	}
+
+	// Load the permutation and recursion words up front so that a bad path
+	// fails early
+	var permutationWords, recursiveWords []string
+	if options.Bool("permutations") {
+		permutationWords, err = se.loadWords(options.String("permutation_wordlist"), defaultPermutationWords)
+		if err != nil {
+			result.Status = StatusFailed
+			result.ErrorMessage = fmt.Sprintf("Failed to load permutation wordlist: %v", err)
+			result.EndTime = time.Now().Format(time.RFC3339)
+			return result, err
+		}
+	}
+	if options.Bool("recursive") {
+		recursiveWords, err = se.loadWords(options.String("recursive_wordlist"), defaultRecursiveWords)
+		if err != nil {
+			result.Status = StatusFailed
+			result.ErrorMessage = fmt.Sprintf("Failed to load recursive wordlist: %v", err)
+			result.EndTime = time.Now().Format(time.RFC3339)
+			return result, err
+		}
+	}
+
+	selected, err := se.passiveSources(options)
 	if err != nil {
-		result.Status = "failed"
-		result.ErrorMessage = fmt.Sprintf("Failed to load wordlist: %v", err)
+		result.Status = StatusFailed
+		result.ErrorMessage = fmt.Sprintf("Failed to load passive sources: %v", err)
 		result.EndTime = time.Now().Format(time.RFC3339)
 		return result, err
 	}
 
-	se.logger.WithField("wordlist_size", len(subdomains)).Info("Loaded subdomain wordlist")
+	// Ask the passive sources first; the names they list are merged with
+	// what the wordlist turns up
+	var listed map[string][]string
+	if len(selected) > 0 {
+		var statuses map[string]string
+		listed, statuses = se.queryPassive(ctx, target, selected)
+		result.Metadata["passive_sources"] = statuses
+	}
 
 	// Perform enumeration
-	results := se.enumerateSubdomains(target, subdomains, threads, timeout, resolveIPs)
+	found, err := se.enumerateSubdomains(ctx, run, words)
+	cp.Flush()
+	results := append(cp.Restored(), found...)
+
+	// Add what passive sources listed, permute everything found so far,
+	// then brute-force the levels below it all. None of these stages is
+	// checkpointed; a resumed scan runs them again on all of its findings.
+	if listed != nil && err == nil {
+		passive := se.addPassive(ctx, run, results, listed)
+		results = append(results, passive...)
+		result.Metadata["passive_found"] = len(passive)
+	}
+	if permutationWords != nil && err == nil {
+		permuted, rounds := se.resolvePermutations(ctx, run, results, permutationWords, options.Int("permutation_depth"))
+		results = append(results, permuted...)
+		result.Metadata["permutation_rounds"] = rounds
+		result.Metadata["permutations_found"] = len(permuted)
+	}
+	if recursiveWords != nil && err == nil {
+		nested, skipped, levels := se.enumerateRecursively(ctx, run, results, recursiveWords, options.Int("recursion_depth"))
+		results = append(results, nested...)
+		result.Metadata["recursion_levels"] = levels
+		result.Metadata["recursive_found"] = len(nested)
+		result.Metadata["recursion_skipped_wildcards"] = skipped
+	}
+	linkParents(target, results)
+	wildcards := run.wildcards.Wildcards()
+	result.Metadata["wildcard"] = len(wildcards) > 0
+	result.Metadata["wildcard_answers"] = wildcards
+	result.Metadata["wildcard_hits"] = run.wildcardHits
+	result.Metadata["lookup_errors"] = run.lookupErrors
+
+	// Names whose lookups failed were never checked, so the scan must not
+	// pass for one that found nothing
+	if run.noResolvers.Load() && ctx.Err() == nil {
+		err := fmt.Errorf("DNS lookups failed: %v", dns.ErrNoResolvers)
+		result.Status = StatusFailed
+		result.ErrorMessage = err.Error()
+		result.EndTime = time.Now().Format(time.RFC3339)
+		return result, err
+	}
+	if run.lookupErrors > 0 {
+		se.logger.WithFields(logrus.Fields{
+			"target":        target,
+			"lookup_errors": run.lookupErrors,
+		}).Warn("DNS lookups failed, subdomains may have been missed")
+	}
+	if err != nil {
+		result.Status = StatusFailed
+		result.ErrorMessage = fmt.Sprintf("Failed to read wordlist: %v", err)
+		result.EndTime = time.Now().Format(time.RFC3339)
+		return result, err
+	}
 
 	// Convert results to interface slice
 	var interfaceResults []interface{}
+	outOfScope := 0
+	bySource := make(map[string]int)
 	for _, r := range results {
 		interfaceResults = append(interfaceResults, r)
+		if r.OutOfScope {
+			outOfScope++
+		}
+		for _, source := range r.Sources {
+			bySource[source]++
+		}
 	}
 
 	endTime := time.Now()
 	result.Results = interfaceResults
-	result.Status = "completed"
+	result.Status = StatusCompleted
 	result.EndTime = endTime.Format(time.RFC3339)
 	result.Metadata["found_subdomains"] = len(results)
+	result.Metadata["out_of_scope"] = outOfScope
+	result.Metadata["sources"] = bySource
 	result.Metadata["duration_seconds"] = endTime.Sub(startTime).Seconds()
 
+	if err := ctx.Err(); err != nil {
+		result.Status = StatusCancelled
+		result.ErrorMessage = err.Error()
+		se.logger.WithFields(logrus.Fields{
+			"target": target,
+			"found":  len(results),
+		}).Warn("Subdomain enumeration cancelled")
+		return result, err
+	}
+
 	se.logger.WithFields(logrus.Fields{
 		"target":   target,
 		"found":    len(results),
@@ -128,41 +386,27 @@ func (se *SubdomainEnumerator) Execute(target string, options map[string]interfa
 	return result, nil
 }
 
-// loadWordlist loads subdomains from a wordlist file
-func (se *SubdomainEnumerator) loadWordlist(filename string) ([]string, error) {
+// openWordlist returns a source reading the wordlist lazily together with
+// its number of entries
+func (se *SubdomainEnumerator) openWordlist(filename string) (engine.Source[string], int, error) {
 	// Create default wordlist if it doesn't exist
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
 		se.logger.Warn("Wordlist not found, creating default wordlist")
 		if err := se.createDefaultWordlist(filename); err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 	}
 
-	file, err := os.Open(filename)
+	size, err := engine.CountLines(filename)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	defer file.Close()
 
-	var subdomains []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line != "" && !strings.HasPrefix(line, "#") {
-			subdomains = append(subdomains, line)
-		}
-	}
-
-	return subdomains, scanner.Err()
+	return engine.Lines(filename), size, nil
 }
 
 // createDefaultWordlist creates a basic subdomain wordlist
 func (se *SubdomainEnumerator) createDefaultWordlist(filename string) error {
-	// Create directory if it doesn't exist
-	if err := os.MkdirAll("wordlists", 0755); err != nil {
-		return err
-	}
-
 	defaultSubdomains := []string{
 		"www", "mail", "ftp", "localhost", "webmail", "smtp", "pop", "ns1", "ns2",
 		"webdisk", "ns", "test", "blog", "pop3", "dev", "www2", "admin", "forum",
@@ -175,14 +419,44 @@ func (se *SubdomainEnumerator) createDefaultWordlist(filename string) error {
 		"assets", "resources", "analytics", "stats", "reports", "logs", "api2",
 	}
 
+	return writeWordlist(filename, defaultSubdomains)
+}
+
+// loadWords reads a small wordlist into memory, creating it with the given
+// default words if it does not exist
+func (se *SubdomainEnumerator) loadWords(filename string, defaults []string) ([]string, error) {
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		se.logger.WithField("wordlist", filename).Warn("Wordlist not found, creating default wordlist")
+		if err := writeWordlist(filename, defaults); err != nil {
+			return nil, err
+		}
+	}
+
+	var words []string
+	err := engine.Lines(filename)(context.Background(), func(word string) bool {
+		if word = strings.ToLower(word); validRelativeName(word) {
+			words = append(words, word)
+		}
+		return true
+	})
+	return words, err
+}
+
+// writeWordlist writes words to filename, one per line
+func writeWordlist(filename string, words []string) error {
+	// Create directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	for _, subdomain := range defaultSubdomains {
-		if _, err := file.WriteString(subdomain + "\n"); err != nil {
+	for _, word := range words {
+		if _, err := file.WriteString(word + "\n"); err != nil {
 			return err
 		}
 	}
@@ -190,56 +464,173 @@ func (se *SubdomainEnumerator) createDefaultWordlist(filename string) error {
 	return nil
 }
 
-// enumerateSubdomains performs concurrent subdomain enumeration
-func (se *SubdomainEnumerator) enumerateSubdomains(domain string, subdomains []string, threads, timeout int, resolveIPs bool) []*SubdomainResult {
-	var results []*SubdomainResult
-	var resultsMutex sync.Mutex
+// enumeration holds the settings and state of a single enumeration run
+type enumeration struct {
+	domain          string
+	threads         int
+	resolveIPs      bool
+	filterWildcards bool
+	engagement      *scope.Scope
+
+	lookup     lookupFunc
+	wildcards  *wildcardDetector
+	progress   *progressReporter
+	checkpoint *checkpoint[*SubdomainResult]
+
+	// Collects the records of each subdomain found, nil for none
+	records     *recordCollector
+	recordTypes []dnsmessage.Type
+
+	// Number of names found to be wildcard answers, filtered or flagged
+	wildcardHits int64
+	// Number of lookups that failed for another reason than the name not
+	// existing, and whether one failed as no resolver was left
+	lookupErrors int64
+	noResolvers  atomic.Bool
+}
 
-	// Create worker pool
-	semaphore := make(chan struct{}, threads)
-	var wg sync.WaitGroup
+// newLookup returns a rate limited lookup with the given timeout in seconds.
+// Names are resolved through the configured resolver pool, or the system
+// resolver if there is none.
+func newLookup(ctx context.Context, timeout int) lookupFunc {
+	if pool := dns.FromContext(ctx); pool != nil {
+		return func(ctx context.Context, name string) ([]net.IPAddr, error) {
+			lookupCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+			defer cancel()
+			return pool.LookupIPAddr(lookupCtx, name)
+		}
+	}
 
-	for _, subdomain := range subdomains {
-		wg.Add(1)
-		go func(sub string) {
-			defer wg.Done()
-			semaphore <- struct{}{}        // Acquire semaphore
-			defer func() { <-semaphore }() // Release semaphore
+	resolver := &net.Resolver{}
+	limiter := ratelimit.FromContext(ctx)
 
-			fullDomain := fmt.Sprintf("%s.%s", sub, domain)
+	return func(ctx context.Context, name string) ([]net.IPAddr, error) {
+		if err := limiter.WaitResolver(ctx, systemResolver); err != nil {
+			return nil, err
+		}
 
-			// Create context with timeout
-			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
-			defer cancel()
+		lookupCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+		return resolver.LookupIPAddr(lookupCtx, name)
+	}
+}
 
-			// Resolve domain
-			resolver := &net.Resolver{}
-			ips, err := resolver.LookupIPAddr(ctx, fullDomain)
-
-			if err == nil && len(ips) > 0 {
-				var ipStrings []string
-				if resolveIPs {
-					for _, ip := range ips {
-						ipStrings = append(ipStrings, ip.IP.String())
-					}
-				}
-
-				resultsMutex.Lock()
-				results = append(results, &SubdomainResult{
-					Subdomain: fullDomain,
-					IPs:       ipStrings,
-					Resolved:  true,
-				})
-				resultsMutex.Unlock()
-
-				se.logger.WithFields(logrus.Fields{
-					"subdomain": fullDomain,
-					"ips":       ipStrings,
-				}).Debug("Found subdomain")
+// resolve looks up name and returns it as a finding of source, or nil if it
+// does not resolve or only resolves to wildcard answers that are filtered
+func (run *enumeration) resolve(ctx context.Context, name, source string) *SubdomainResult {
+	ips, err := run.lookup(ctx, name)
+	if err != nil {
+		run.lookupFailed(ctx, err)
+		return nil
+	}
+	if len(ips) == 0 {
+		return nil
+	}
+	result := run.found(ctx, name, ips)
+	if result != nil {
+		result.Sources = []string{source}
+	}
+	return result
+}
+
+// lookupFailed counts a failed lookup unless the name does not exist, as
+// such a failure may hide a name that does
+func (run *enumeration) lookupFailed(ctx context.Context, err error) {
+	var dnsErr *net.DNSError
+	if ctx.Err() != nil || errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return
+	}
+	atomic.AddInt64(&run.lookupErrors, 1)
+	if errors.Is(err, dns.ErrNoResolvers) {
+		run.noResolvers.Store(true)
+	}
+}
+
+// found returns name, which resolved to ips, as a finding, or nil if ips
+// are wildcard answers that are filtered
+func (run *enumeration) found(ctx context.Context, name string, ips []net.IPAddr) *SubdomainResult {
+	wildcard := run.wildcards.Matches(ctx, run.domain, name, ips)
+	if ctx.Err() != nil {
+		return nil
+	}
+	if wildcard {
+		atomic.AddInt64(&run.wildcardHits, 1)
+		if run.filterWildcards {
+			return nil
+		}
+	}
+
+	var ipStrings []string
+	if run.resolveIPs {
+		for _, ip := range ips {
+			ipStrings = append(ipStrings, ip.IP.String())
+		}
+	}
+
+	result := &SubdomainResult{
+		Subdomain:  name,
+		IPs:        ipStrings,
+		Resolved:   true,
+		OutOfScope: !inScope(run.engagement, name, ips),
+		Wildcard:   wildcard,
+	}
+	if run.records != nil {
+		result.Records, _ = run.records.collect(ctx, name, run.recordTypes)
+		result.CNAMEChain = dns.Chain(result.Records, name)
+	}
+	return result
+}
+
+// enumerateSubdomains resolves every word of the wordlist as a subdomain
+// of run.domain using a fixed pool of workers, skipping the words the
+// checkpoint has seen finished. Names that only resolve to wildcard answers
+// are flagged or dropped. It stops as soon as ctx is cancelled and returns
+// what was found.
+func (se *SubdomainEnumerator) enumerateSubdomains(ctx context.Context, run *enumeration, words engine.Source[string]) ([]*SubdomainResult, error) {
+	cp := run.checkpoint
+	progress := run.progress
+
+	// Number the words by their position in the wordlist, which is how the
+	// checkpoint refers to them
+	numbered := func(ctx context.Context, yield func(engine.Pair[int, string]) bool) error {
+		line := 0
+		return words(ctx, func(word string) bool {
+			line++
+			if cp.Finished(line) {
+				progress.Advance(1)
+				return true
 			}
-		}(subdomain)
+			return yield(engine.Pair[int, string]{First: line, Second: word})
+		})
 	}
 
-	wg.Wait()
-	return results
+	job := engine.Run(ctx, run.threads, numbered, func(ctx context.Context, word engine.Pair[int, string]) (*SubdomainResult, bool) {
+		defer progress.Advance(1)
+
+		// A lookup cut short by cancellation is not finished and is
+		// repeated on resume
+		result := run.resolve(ctx, fmt.Sprintf("%s.%s", word.Second, run.domain), SourceBruteForce)
+		if ctx.Err() != nil {
+			return nil, false
+		}
+		if result == nil {
+			cp.Done(word.First)
+			return nil, false
+		}
+		cp.Done(word.First, result)
+		return result, true
+	})
+
+	var results []*SubdomainResult
+	err := job.Collect(func(result *SubdomainResult) {
+		results = append(results, result)
+		progress.Finding(result)
+
+		se.logger.WithFields(logrus.Fields{
+			"subdomain": result.Subdomain,
+			"ips":       result.IPs,
+		}).Debug("Found subdomain")
+	})
+
+	return results, err
 }
@@ -0,0 +1,75 @@
+package modules
+
+import (
+	"GoReconX/internal/config"
+	"GoReconX/internal/dns"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// closedResolver returns a pool of a single resolver whose port is closed,
+// so that every lookup fails
+func closedResolver(t *testing.T) *dns.Pool {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	address := conn.LocalAddr().String()
+	conn.Close()
+
+	pool, err := dns.NewPool([]string{address}, 0, quietLogger())
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	return pool
+}
+
+func TestSubdomainEnumerationLookupErrors(t *testing.T) {
+	wordlist := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(wordlist, []byte("www\napi\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		pool       *dns.Pool
+		wantFound  int
+		wantErrors int64
+	}{
+		{
+			name: "answering resolver",
+			pool: startResolver(t, map[string]standInAnswer{
+				"www.example.com": {addresses: []string{"192.0.2.1"}},
+			}),
+			wantFound: 1,
+		},
+		{name: "failing resolver", pool: closedResolver(t), wantErrors: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			se := NewSubdomainEnumerator(config.DefaultConfig(), quietLogger())
+			options, err := se.GetOptionSchema().Validate(map[string]interface{}{
+				"wordlist": wordlist,
+				"timeout":  1,
+			})
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+
+			result, err := se.Execute(dns.NewContext(context.Background(), tt.pool), "example.com", options)
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			if len(result.Results) != tt.wantFound {
+				t.Errorf("found %d subdomains, want %d", len(result.Results), tt.wantFound)
+			}
+			if got := result.Metadata["lookup_errors"]; got != tt.wantErrors {
+				t.Errorf("lookup_errors = %v, want %d", got, tt.wantErrors)
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package modules
+
+import (
+	"GoReconX/internal/config"
+	"GoReconX/internal/dns"
+	"GoReconX/internal/ratelimit"
+	"GoReconX/internal/scope"
+	"GoReconX/internal/takeover"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func init() {
+	Register(ModuleInfo{
+		ID:             "subdomain_takeover",
+		Name:           "Subdomain Takeover Checker",
+		Category:       CategoryActive,
+		Description:    "Follows CNAME chains and matches dangling records against takeover fingerprints",
+		RequiredInputs: []string{InputDomain},
+	}, func(cfg *config.Config, logger *logrus.Logger) ModuleInterface {
+		return NewTakeoverChecker(cfg, logger)
+	})
+}
+
+// Verdicts of takeover findings
+const (
+	// TakeoverVulnerable means the service's signature for unclaimed names
+	// was seen, so the name can most likely be claimed
+	TakeoverVulnerable = "vulnerable"
+	// TakeoverLikely means the CNAME chain dangles but the service could
+	// not be confirmed
+	TakeoverLikely = "likely"
+)
+
+// maxTakeoverBody bounds the part of an HTTP response searched for
+// fingerprints
+const maxTakeoverBody = 1 << 20
+
+// TakeoverResult is a subdomain whose CNAME points at something that may be
+// claimed by anyone
+type TakeoverResult struct {
+	Subdomain  string   `json:"subdomain"`
+	CNAMEChain []string `json:"cname_chain"`
+	// Service is the hosted service the chain leads to, if known
+	Service  string   `json:"service,omitempty"`
+	Status   string   `json:"status"`
+	Evidence []string `json:"evidence"`
+}
+
+// String returns a short human readable form of the result
+func (tr *TakeoverResult) String() string {
+	s := fmt.Sprintf("%s -> %s: %s", tr.Subdomain, strings.Join(tr.CNAMEChain, " -> "), tr.Status)
+	if tr.Service != "" {
+		s += " (" + tr.Service + ")"
+	}
+	return s
+}
+
+// ResultType identifies takeover findings in the results table
+func (tr *TakeoverResult) ResultType() string {
+	return "takeover"
+}
+
+// Severity rates confirmed takeovers high and dangling records medium
+func (tr *TakeoverResult) Severity() string {
+	if tr.Status == TakeoverVulnerable {
+		return SeverityHigh
+	}
+	return SeverityMedium
+}
+
+// TakeoverChecker looks for subdomains that can be taken over
+type TakeoverChecker struct {
+	config *config.Config
+	logger *logrus.Logger
+}
+
+// NewTakeoverChecker creates a new takeover checker
+func NewTakeoverChecker(cfg *config.Config, logger *logrus.Logger) *TakeoverChecker {
+	return &TakeoverChecker{config: cfg, logger: logger}
+}
+
+// GetName returns the module name
+func (tc *TakeoverChecker) GetName() string {
+	return "Subdomain Takeover Checker"
+}
+
+// GetDescription returns the module description
+func (tc *TakeoverChecker) GetDescription() string {
+	return "Follows CNAME chains and matches dangling records against takeover fingerprints"
+}
+
+// Validate validates the target host name
+func (tc *TakeoverChecker) Validate(target string) error {
+	if target == "" {
+		return fmt.Errorf("target cannot be empty")
+	}
+	if net.ParseIP(target) != nil || !strings.Contains(target, ".") {
+		return fmt.Errorf("invalid domain format")
+	}
+	return nil
+}
+
+// GetOptionSchema returns the options accepted by the module
+func (tc *TakeoverChecker) GetOptionSchema() OptionSchema {
+	return OptionSchema{
+		{Name: "fingerprints", Type: OptionString, Default: tc.config.Takeover.Fingerprints, Description: "Path to the YAML takeover fingerprints"},
+		{Name: "http", Type: OptionBool, Default: true, Description: "Fetch the subdomain over HTTP and HTTPS to match service error pages"},
+		{Name: "http_port", Type: OptionInt, Default: 80, Range: &OptionRange{Min: 1, Max: 65535}, Description: "Port of plain HTTP requests"},
+		{Name: "https_port", Type: OptionInt, Default: 443, Range: &OptionRange{Min: 1, Max: 65535}, Description: "Port of HTTPS requests"},
+		{Name: "timeout", Type: OptionInt, Default: 10, Range: &OptionRange{Min: 1, Max: 120}, Description: "DNS and HTTP timeout in seconds"},
+	}
+}
+
+// Execute follows the CNAME chain of the target and checks whether it ends
+// at a service that would let anyone claim the name
+func (tc *TakeoverChecker) Execute(ctx context.Context, target string, options Options) (*ScanResult, error) {
+	startTime := time.Now()
+	tc.logger.WithField("target", target).Info("Starting takeover check")
+
+	result := &ScanResult{
+		ModuleName: tc.GetName(),
+		Target:     target,
+		Status:     StatusRunning,
+		StartTime:  startTime.Format(time.RFC3339),
+		Metadata:   make(map[string]interface{}),
+	}
+	fail := func(err error) (*ScanResult, error) {
+		result.Status = StatusFailed
+		result.ErrorMessage = err.Error()
+		result.EndTime = time.Now().Format(time.RFC3339)
+		return result, err
+	}
+
+	fingerprints, err := tc.loadFingerprints(options.String("fingerprints"))
+	if err != nil {
+		return fail(err)
+	}
+	pool, err := recordPool(ctx, tc.config, tc.logger)
+	if err != nil {
+		return fail(err)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(target, "."))
+	timeout := time.Duration(options.Int("timeout")) * time.Second
+	lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+	answer, err := pool.Exchange(lookupCtx, host, dnsmessage.TypeA)
+	cancel()
+	if err != nil {
+		return fail(fmt.Errorf("failed to resolve %s: %v", host, err))
+	}
+
+	records := dns.Records(answer)
+	chain := dns.Chain(records, host)
+	dangling := len(chain) > 0 && answer.RCode == dnsmessage.RCodeNameError
+	result.Metadata["cname_chain"] = chain
+	result.Metadata["dangling"] = dangling
+
+	if len(chain) > 0 {
+		finding := &TakeoverResult{Subdomain: host, CNAMEChain: chain}
+		end := chain[len(chain)-1]
+		fp, name := takeover.Match(fingerprints, chain)
+		if fp != nil {
+			finding.Service = fp.Service
+			result.Metadata["service"] = fp.Service
+		}
+
+		switch {
+		case fp != nil && dangling && fp.NXDomain:
+			finding.Status = TakeoverVulnerable
+			finding.Evidence = append(finding.Evidence,
+				fmt.Sprintf("%s does not exist (NXDOMAIN)", end),
+				fmt.Sprintf("%s is a %s name, which can be registered by anyone", name, fp.Service))
+		case dangling:
+			finding.Status = TakeoverLikely
+			finding.Evidence = append(finding.Evidence, fmt.Sprintf("%s does not exist (NXDOMAIN)", end))
+			if fp != nil {
+				finding.Evidence = append(finding.Evidence, fmt.Sprintf("%s belongs to %s", name, fp.Service))
+			}
+		case fp != nil && len(fp.Body) > 0 && options.Bool("http"):
+			ports := map[string]int{"http": options.Int("http_port"), "https": options.Int("https_port")}
+			evidence, err := tc.checkResponses(ctx, host, addresses(records), ports, fp, timeout)
+			if err != nil {
+				result.Metadata["http_error"] = err.Error()
+			}
+			if evidence != "" {
+				finding.Status = TakeoverVulnerable
+				finding.Evidence = append(finding.Evidence,
+					fmt.Sprintf("%s belongs to %s", name, fp.Service), evidence)
+			}
+		}
+
+		if finding.Status != "" {
+			tc.logger.WithFields(logrus.Fields{
+				"subdomain": host,
+				"status":    finding.Status,
+				"service":   finding.Service,
+			}).Warn("Possible subdomain takeover")
+			result.Results = append(result.Results, finding)
+		}
+	}
+
+	endTime := time.Now()
+	result.Status = StatusCompleted
+	result.EndTime = endTime.Format(time.RFC3339)
+	result.Metadata["duration_seconds"] = endTime.Sub(startTime).Seconds()
+
+	if err := ctx.Err(); err != nil {
+		result.Status = StatusCancelled
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+
+	tc.logger.WithFields(logrus.Fields{
+		"target":   target,
+		"findings": len(result.Results),
+		"duration": endTime.Sub(startTime),
+	}).Info("Takeover check completed")
+
+	return result, nil
+}
+
+// loadFingerprints reads the fingerprint file, creating it with the
+// built-in fingerprints if it does not exist
+func (tc *TakeoverChecker) loadFingerprints(path string) ([]*takeover.Fingerprint, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		tc.logger.WithField("file", path).Warn("Takeover fingerprints not found, creating defaults")
+		if err := takeover.Save(path, takeover.Defaults); err != nil {
+			return nil, fmt.Errorf("failed to create takeover fingerprints: %v", err)
+		}
+	}
+
+	fingerprints, err := takeover.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load takeover fingerprints: %v", err)
+	}
+	return fingerprints, nil
+}
+
+// checkResponses fetches the root page of host over HTTP and HTTPS and
+// returns evidence if one of them is the fingerprinted page for unclaimed
+// names. Requests go to the addresses the chain resolved to, and only if
+// they are in scope.
+func (tc *TakeoverChecker) checkResponses(ctx context.Context, host string, ips []net.IPAddr, ports map[string]int, fp *takeover.Fingerprint, timeout time.Duration) (string, error) {
+	if len(ips) == 0 {
+		return "", fmt.Errorf("%s has no addresses", host)
+	}
+	engagement := scope.FromContext(ctx)
+	if !inScope(engagement, host, ips) {
+		return "", fmt.Errorf("%s resolves to out-of-scope addresses", host)
+	}
+
+	// Connect to the addresses found through the resolver pool rather than
+	// resolving host again with the system resolver
+	dialer := &net.Dialer{Timeout: timeout}
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				_, port, err := net.SplitHostPort(address)
+				if err != nil {
+					return nil, err
+				}
+				var lastErr error
+				for _, ip := range ips {
+					conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+					if err == nil {
+						return conn, nil
+					}
+					lastErr = err
+				}
+				return nil, lastErr
+			},
+			// Unclaimed names rarely have a matching certificate
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true, ServerName: host},
+		},
+		// The page for unclaimed names is served directly
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	limiter := ratelimit.FromContext(ctx)
+	var lastErr error
+	fetched := false
+	for _, scheme := range []string{"https", "http"} {
+		port := ports[scheme]
+		if !engagement.ContainsPort(port) {
+			continue
+		}
+		if err := limiter.WaitHost(ctx, host); err != nil {
+			return "", err
+		}
+
+		url := fmt.Sprintf("%s://%s/", scheme, net.JoinHostPort(host, strconv.Itoa(port)))
+		status, body, err := tc.fetch(ctx, client, url)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if text, ok := fp.MatchResponse(status, body); ok {
+			return fmt.Sprintf("HTTP %d from %s contains %q", status, url, text), nil
+		}
+		fetched = true
+	}
+
+	// A failed request only matters if no response could be checked
+	if fetched {
+		return "", nil
+	}
+	return "", lastErr
+}
+
+// fetch returns the status and the beginning of the body of a GET request
+func (tc *TakeoverChecker) fetch(ctx context.Context, client *http.Client, url string) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("User-Agent", tc.config.Network.UserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTakeoverBody))
+	if err != nil {
+		return 0, "", err
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// addresses returns the A and AAAA records among records as addresses
+func addresses(records []dns.Record) []net.IPAddr {
+	var ips []net.IPAddr
+	for _, record := range records {
+		if record.Type == "A" || record.Type == "AAAA" {
+			if ip := net.ParseIP(record.Value); ip != nil {
+				ips = append(ips, net.IPAddr{IP: ip})
+			}
+		}
+	}
+	return ips
+}
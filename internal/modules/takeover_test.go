@@ -0,0 +1,203 @@
+package modules
+
+import (
+	"GoReconX/internal/config"
+	"GoReconX/internal/dns"
+	"GoReconX/internal/scope"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// standInAnswer is what the stand-in resolver answers for a name: the
+// CNAME chain followed, the addresses at its end and the response code
+type standInAnswer struct {
+	chain     []string
+	addresses []string
+	rcode     dnsmessage.RCode
+}
+
+// startNameserver starts a local DNS server answering UDP queries with
+// answer, dropping those it returns nil for, and returns a pool using it
+func startNameserver(t *testing.T, answer func(query *dnsmessage.Message) *dnsmessage.Message) *dns.Pool {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buffer := make([]byte, 1500)
+		for {
+			n, peer, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if query.Unpack(buffer[:n]) != nil || len(query.Questions) != 1 {
+				continue
+			}
+			response := answer(&query)
+			if response == nil {
+				continue
+			}
+			if packed, err := response.Pack(); err == nil {
+				conn.WriteTo(packed, peer)
+			}
+		}
+	}()
+
+	pool, err := dns.NewPool([]string{conn.LocalAddr().String()}, 0, quietLogger())
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	return pool
+}
+
+// startResolver starts a local DNS resolver answering queries from
+// answers, with the addresses only for A queries, NXDOMAIN for other
+// names, and returns a pool using it
+func startResolver(t *testing.T, answers map[string]standInAnswer) *dns.Pool {
+	t.Helper()
+	return startNameserver(t, func(query *dnsmessage.Message) *dnsmessage.Message {
+		question := query.Questions[0]
+		answer, ok := answers[strings.TrimSuffix(strings.ToLower(question.Name.String()), ".")]
+		if !ok {
+			answer.rcode = dnsmessage.RCodeNameError
+		}
+
+		response := &dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true, RCode: answer.rcode},
+			Questions: query.Questions,
+		}
+		owner := question.Name
+		for _, target := range answer.chain {
+			name := dnsmessage.MustNewName(target + ".")
+			response.Answers = append(response.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: owner, Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.CNAMEResource{CNAME: name},
+			})
+			owner = name
+		}
+		if question.Type != dnsmessage.TypeA {
+			return response
+		}
+		for _, address := range answer.addresses {
+			var a [4]byte
+			copy(a[:], net.ParseIP(address).To4())
+			response.Answers = append(response.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: owner, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.AResource{A: a},
+			})
+		}
+		return response
+	})
+}
+
+func quietLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+const unclaimedPage = "There isn't a GitHub Pages site here."
+
+func TestTakeoverChecker(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if strings.HasPrefix(r.Host, "claimed.") {
+			w.Write([]byte("Welcome to our blog"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(unclaimedPage))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	httpPort, _ := strconv.Atoi(port)
+
+	pool := startResolver(t, map[string]standInAnswer{
+		"azure.example.com":     {chain: []string{"gone.azurewebsites.net"}, rcode: dnsmessage.RCodeNameError},
+		"dangling.example.com":  {chain: []string{"app.unknown-host.test"}, rcode: dnsmessage.RCodeNameError},
+		"pages.example.com":     {chain: []string{"acme.github.io"}, addresses: []string{"127.0.0.1"}},
+		"claimed.example.com":   {chain: []string{"claimed.github.io"}, addresses: []string{"127.0.0.1"}},
+		"plain.example.com":     {addresses: []string{"127.0.0.1"}},
+		"offlimits.example.com": {chain: []string{"other.github.io"}, addresses: []string{"127.0.0.1"}},
+	})
+
+	tests := []struct {
+		name        string
+		target      string
+		scope       *scope.Definition
+		wantStatus  string
+		wantService string
+		wantFetch   bool
+	}{
+		{name: "dangling CNAME to an nxdomain service", target: "azure.example.com", wantStatus: TakeoverVulnerable, wantService: "Microsoft Azure"},
+		{name: "dangling CNAME without a fingerprint", target: "dangling.example.com", wantStatus: TakeoverLikely},
+		{name: "body and status match", target: "pages.example.com", wantStatus: TakeoverVulnerable, wantService: "GitHub Pages", wantFetch: true},
+		{name: "claimed name", target: "claimed.example.com", wantFetch: true},
+		{name: "no CNAME", target: "plain.example.com"},
+		{
+			name:   "out-of-scope address is not fetched",
+			target: "offlimits.example.com",
+			scope:  &scope.Definition{Include: []string{"*.example.com"}, Exclude: []string{"127.0.0.0/8"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			checker := NewTakeoverChecker(cfg, quietLogger())
+			ctx := dns.NewContext(context.Background(), pool)
+			if tt.scope != nil {
+				engagement, err := scope.New(*tt.scope)
+				if err != nil {
+					t.Fatalf("scope: %v", err)
+				}
+				ctx = scope.NewContext(ctx, engagement)
+			}
+			before := requests.Load()
+
+			result, err := checker.Execute(ctx, tt.target, Options{
+				"fingerprints": filepath.Join(t.TempDir(), "takeover.yaml"),
+				"http":         true,
+				"http_port":    httpPort,
+				"https_port":   httpPort,
+				"timeout":      2,
+			})
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+
+			var finding *TakeoverResult
+			for _, item := range result.Results {
+				finding = item.(*TakeoverResult)
+			}
+			status, service := "", ""
+			if finding != nil {
+				status, service = finding.Status, finding.Service
+			}
+			if status != tt.wantStatus || service != tt.wantService {
+				t.Errorf("finding = %q (%q), want %q (%q)", status, service, tt.wantStatus, tt.wantService)
+			}
+			if fetched := requests.Load() > before; fetched != tt.wantFetch {
+				t.Errorf("fetched = %v, want %v", fetched, tt.wantFetch)
+			}
+			if tt.scope != nil && !strings.Contains(result.Metadata["http_error"].(string), "out-of-scope") {
+				t.Errorf("http_error = %v, want the addresses reported out of scope", result.Metadata["http_error"])
+			}
+		})
+	}
+}
@@ -0,0 +1,413 @@
+package modules
+
+import (
+	"GoReconX/internal/dns"
+	"GoReconX/internal/engine"
+	"GoReconX/internal/ratelimit"
+	"GoReconX/internal/scope"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Weaknesses of a TLS service
+const (
+	WeaknessExpired    = "expired_certificate"
+	WeaknessSelfSigned = "self_signed_certificate"
+	WeaknessTLS10      = "tls_1.0"
+	WeaknessWeakKey    = "weak_rsa_key"
+	WeaknessSHA1       = "sha1_signature"
+)
+
+// minRSABits is the smallest RSA key not flagged as weak
+const minRSABits = 2048
+
+// tlsVersions are the protocol versions tried, oldest first. SSL 3.0 is
+// left out as crypto/tls cannot speak it.
+var tlsVersions = []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13}
+
+// TLSCertificate describes a certificate of the chain a TLS service
+// presents
+type TLSCertificate struct {
+	Subject string `json:"subject"`
+	// SANs are the DNS names and addresses the certificate is valid for
+	SANs               []string `json:"sans,omitempty"`
+	Issuer             string   `json:"issuer"`
+	NotBefore          string   `json:"not_before"`
+	NotAfter           string   `json:"not_after"`
+	KeyType            string   `json:"key_type"`
+	KeySize            int      `json:"key_size"`
+	SignatureAlgorithm string   `json:"signature_algorithm"`
+	SelfSigned         bool     `json:"self_signed,omitempty"`
+}
+
+// TLSResult is the certificate chain and configuration of a TLS service
+type TLSResult struct {
+	Port     int      `json:"port"`
+	Versions []string `json:"versions"`
+	// CipherSuites are the suites accepted with each version. For TLS 1.3
+	// only the suite the server chose is known, as crypto/tls always
+	// offers all of them.
+	CipherSuites map[string][]string `json:"cipher_suites,omitempty"`
+	// Chain is the leaf certificate first
+	Chain      []TLSCertificate `json:"chain"`
+	Weaknesses []string         `json:"weaknesses,omitempty"`
+}
+
+// String returns a short human readable form of the result
+func (tr *TLSResult) String() string {
+	s := fmt.Sprintf("%d/tcp %s", tr.Port, strings.Join(tr.Versions, ", "))
+	if len(tr.Chain) > 0 {
+		s += fmt.Sprintf(": %s (issuer %s)", tr.Chain[0].Subject, tr.Chain[0].Issuer)
+	}
+	if len(tr.Weaknesses) > 0 {
+		s += fmt.Sprintf(", %d weaknesses", len(tr.Weaknesses))
+	}
+	return s
+}
+
+// ResultType identifies TLS findings in the results table
+func (tr *TLSResult) ResultType() string {
+	return "tls"
+}
+
+// TLSWeaknessResult is a weak certificate or configuration of a TLS
+// service
+type TLSWeaknessResult struct {
+	Port     int    `json:"port"`
+	Weakness string `json:"weakness"`
+	Detail   string `json:"detail"`
+}
+
+// String returns a short human readable form of the result
+func (wr *TLSWeaknessResult) String() string {
+	return fmt.Sprintf("%d/tcp %s: %s", wr.Port, wr.Weakness, wr.Detail)
+}
+
+// ResultType identifies TLS weakness findings in the results table
+func (wr *TLSWeaknessResult) ResultType() string {
+	return "tls_weakness"
+}
+
+// Severity rates expired certificates high, as clients refuse them or
+// users learn to click through warnings, and other weaknesses medium
+func (wr *TLSWeaknessResult) Severity() string {
+	if wr.Weakness == WeaknessExpired {
+		return SeverityHigh
+	}
+	return SeverityMedium
+}
+
+// tlsInspector reads the certificate chains and configurations of TLS
+// services
+type tlsInspector struct {
+	timeout time.Duration
+	// ciphers enables testing every cipher suite one by one
+	ciphers    bool
+	serverName string
+	limiter    *ratelimit.Limiter
+}
+
+// inspectTLS inspects the ports that speak TLS and returns what was found
+// about each, with their weaknesses. Besides the ports service detection
+// found TLS on, open TCP ports that neither sent anything nor were
+// identified are tried, which is every port without service detection.
+func (ps *PortScanner) inspectTLS(ctx context.Context, target string, ports []*PortResult, threads int, ti *tlsInspector, progress *progressReporter) ([]*TLSResult, []*TLSWeaknessResult) {
+	var candidates []*PortResult
+	for _, port := range ports {
+		if port.Protocol == "tcp" && port.State == PortOpen && (port.Service == "ssl" || strings.HasPrefix(port.Service, "ssl/") || port.Product == "" && port.Banner == "") {
+			candidates = append(candidates, port)
+		}
+	}
+
+	progress.Grow(len(candidates))
+	job := engine.Run(ctx, threads, engine.Slice(candidates), func(ctx context.Context, port *PortResult) (*TLSResult, bool) {
+		defer progress.Advance(1)
+		result := ti.inspect(ctx, target, port.Port)
+		return result, result != nil
+	})
+
+	var results []*TLSResult
+	var weaknesses []*TLSWeaknessResult
+	job.Collect(func(result *TLSResult) {
+		results = append(results, result)
+		progress.Finding(result)
+		for _, weakness := range tlsWeaknesses(result, time.Now()) {
+			weaknesses = append(weaknesses, weakness)
+			result.Weaknesses = append(result.Weaknesses, weakness.Weakness)
+			progress.Finding(weakness)
+		}
+
+		ps.logger.WithFields(logrus.Fields{
+			"target":     target,
+			"port":       result.Port,
+			"versions":   result.Versions,
+			"weaknesses": result.Weaknesses,
+		}).Debug("Inspected TLS service")
+	})
+	sort.Slice(results, func(i, j int) bool { return results[i].Port < results[j].Port })
+	sort.SliceStable(weaknesses, func(i, j int) bool { return weaknesses[i].Port < weaknesses[j].Port })
+	return results, weaknesses
+}
+
+// inspect finds the protocol versions and cipher suites a port accepts and
+// the certificate chain it presents with the newest version. It returns
+// nil if the port does not speak TLS.
+func (ti *tlsInspector) inspect(ctx context.Context, host string, port int) *TLSResult {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	result := &TLSResult{Port: port, CipherSuites: make(map[string][]string)}
+
+	var chain []*x509.Certificate
+	var accepted []uint16
+	for _, version := range tlsVersions {
+		state, err := ti.handshake(ctx, address, version, nil)
+		if err != nil {
+			continue
+		}
+		accepted = append(accepted, version)
+		result.Versions = append(result.Versions, tls.VersionName(version))
+		chain = state.PeerCertificates
+		if version == tls.VersionTLS13 || !ti.ciphers {
+			result.CipherSuites[tls.VersionName(version)] = []string{tls.CipherSuiteName(state.CipherSuite)}
+		}
+	}
+	if len(accepted) == 0 || ctx.Err() != nil {
+		return nil
+	}
+
+	if ti.ciphers {
+		suites := allCipherSuites()
+		for _, version := range accepted {
+			if version == tls.VersionTLS13 {
+				continue
+			}
+			name := tls.VersionName(version)
+			for _, suite := range suites {
+				if !supportsVersion(suite, version) {
+					continue
+				}
+				if _, err := ti.handshake(ctx, address, version, []uint16{suite.ID}); err == nil {
+					result.CipherSuites[name] = append(result.CipherSuites[name], suite.Name)
+				}
+			}
+		}
+	}
+
+	for _, cert := range chain {
+		result.Chain = append(result.Chain, describeCertificate(cert))
+	}
+	return result
+}
+
+// handshake completes a TLS handshake with address using only version and
+// the cipher suites, or every suite crypto/tls knows if none are given
+func (ti *tlsInspector) handshake(ctx context.Context, address string, version uint16, suites []uint16) (*tls.ConnectionState, error) {
+	if suites == nil {
+		for _, suite := range allCipherSuites() {
+			suites = append(suites, suite.ID)
+		}
+	}
+
+	host, _, _ := net.SplitHostPort(address)
+	if err := ti.limiter.WaitHost(ctx, host); err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: ti.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	client := tls.Client(conn, &tls.Config{
+		// The chain is inspected, not trusted
+		InsecureSkipVerify: true,
+		ServerName:         ti.serverName,
+		MinVersion:         version,
+		MaxVersion:         version,
+		CipherSuites:       suites,
+	})
+	client.SetDeadline(time.Now().Add(ti.timeout))
+	if err := client.HandshakeContext(ctx); err != nil {
+		return nil, err
+	}
+	state := client.ConnectionState()
+	return &state, nil
+}
+
+// allCipherSuites returns the secure and insecure cipher suites of
+// crypto/tls; TLS 1.3 suites cannot be chosen and are always offered
+func allCipherSuites() []*tls.CipherSuite {
+	return append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+}
+
+// supportsVersion reports whether suite can be used with version
+func supportsVersion(suite *tls.CipherSuite, version uint16) bool {
+	for _, v := range suite.SupportedVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// describeCertificate summarises a certificate
+func describeCertificate(cert *x509.Certificate) TLSCertificate {
+	description := TLSCertificate{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		NotBefore:          cert.NotBefore.UTC().Format(time.RFC3339),
+		NotAfter:           cert.NotAfter.UTC().Format(time.RFC3339),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		SelfSigned:         selfSigned(cert),
+	}
+	description.SANs = append(description.SANs, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		description.SANs = append(description.SANs, ip.String())
+	}
+
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		description.KeyType = "RSA"
+		description.KeySize = key.N.BitLen()
+	case *ecdsa.PublicKey:
+		description.KeyType = "ECDSA"
+		description.KeySize = key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		description.KeyType = "Ed25519"
+		description.KeySize = 256
+	default:
+		description.KeyType = cert.PublicKeyAlgorithm.String()
+	}
+	return description
+}
+
+// selfSigned reports whether cert is signed with its own key. Signatures
+// crypto/x509 refuses to check, such as SHA-1 ones, are taken to be, as
+// long as the certificate names itself as the issuer.
+func selfSigned(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return false
+	}
+	err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature)
+	var insecure x509.InsecureAlgorithmError
+	return err == nil || errors.As(err, &insecure)
+}
+
+// tlsWeaknesses flags the weak configuration and certificates of a TLS
+// service as of now. The signatures of self-signed roots are not checked,
+// as clients trust those by the certificate itself.
+func tlsWeaknesses(result *TLSResult, now time.Time) []*TLSWeaknessResult {
+	var weaknesses []*TLSWeaknessResult
+	add := func(weakness, detail string) {
+		weaknesses = append(weaknesses, &TLSWeaknessResult{Port: result.Port, Weakness: weakness, Detail: detail})
+	}
+
+	for _, version := range result.Versions {
+		if version == tls.VersionName(tls.VersionTLS10) {
+			add(WeaknessTLS10, "TLS 1.0 is accepted")
+		}
+	}
+	for i, cert := range result.Chain {
+		if notAfter, err := time.Parse(time.RFC3339, cert.NotAfter); err == nil && notAfter.Before(now) {
+			add(WeaknessExpired, fmt.Sprintf("%s expired on %s", cert.Subject, cert.NotAfter))
+		}
+		if cert.KeyType == "RSA" && cert.KeySize < minRSABits {
+			add(WeaknessWeakKey, fmt.Sprintf("%s has a %d-bit RSA key", cert.Subject, cert.KeySize))
+		}
+		if strings.Contains(cert.SignatureAlgorithm, "SHA1") && (i == 0 || !cert.SelfSigned) {
+			add(WeaknessSHA1, fmt.Sprintf("%s is signed with %s", cert.Subject, cert.SignatureAlgorithm))
+		}
+		if i == 0 && cert.SelfSigned {
+			add(WeaknessSelfSigned, fmt.Sprintf("%s is self-signed", cert.Subject))
+		}
+	}
+	return weaknesses
+}
+
+// certificateHostnames returns the DNS names the leaf certificates of
+// results are valid for, lower case, with wildcards reduced to the domain
+// they cover
+func certificateHostnames(results []*TLSResult) []string {
+	seen := make(map[string]bool)
+	var hostnames []string
+	for _, result := range results {
+		if len(result.Chain) == 0 {
+			continue
+		}
+		for _, san := range result.Chain[0].SANs {
+			name := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(san, "*."), "."))
+			if net.ParseIP(name) != nil || !strings.Contains(name, ".") || seen[name] {
+				continue
+			}
+			seen[name] = true
+			hostnames = append(hostnames, name)
+		}
+	}
+	sort.Slice(hostnames, func(i, j int) bool {
+		return dns.CompareNames(hostnames[i], hostnames[j]) < 0
+	})
+	return hostnames
+}
+
+// resolveCertificateNames resolves the hostnames found in certificates and
+// returns them as subdomains, linked to their parents below the domains.
+// Hostnames that do not resolve are kept, as a certificate was issued for
+// them.
+func (ps *PortScanner) resolveCertificateNames(ctx context.Context, hostnames, domains []string, threads int, lookup lookupFunc, engagement *scope.Scope, progress *progressReporter) []*SubdomainResult {
+	progress.Grow(len(hostnames))
+	job := engine.Run(ctx, threads, engine.Slice(hostnames), func(ctx context.Context, name string) (*SubdomainResult, bool) {
+		defer progress.Advance(1)
+
+		ips, err := lookup(ctx, name)
+		if ctx.Err() != nil {
+			return nil, false
+		}
+		result := &SubdomainResult{
+			Subdomain:  name,
+			OutOfScope: !inScope(engagement, name, ips),
+			Sources:    []string{SourceTLSCertificate},
+		}
+		if err == nil && len(ips) > 0 {
+			result.Resolved = true
+			for _, ip := range ips {
+				result.IPs = append(result.IPs, ip.IP.String())
+			}
+		}
+		return result, true
+	})
+
+	var results []*SubdomainResult
+	job.Collect(func(result *SubdomainResult) {
+		results = append(results, result)
+		progress.Finding(result)
+	})
+	sort.Slice(results, func(i, j int) bool {
+		return dns.CompareNames(results[i].Subdomain, results[j].Subdomain) < 0
+	})
+
+	byDomain := make(map[string][]*SubdomainResult)
+	for _, result := range results {
+		if domain := ownerDomain(result.Subdomain, domains); domain != "" {
+			byDomain[domain] = append(byDomain[domain], result)
+		}
+	}
+	for domain, found := range byDomain {
+		linkParents(domain, found)
+	}
+	return results
+}
@@ -0,0 +1,214 @@
+package modules
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// certificate issues a certificate for key, signed by parent with
+// parentKey, or self-signed if parent is nil
+func certificate(t *testing.T, template *x509.Certificate, key crypto.Signer, parent *x509.Certificate, parentKey crypto.Signer) *x509.Certificate {
+	t.Helper()
+	if template.SerialNumber == nil {
+		template.SerialNumber = big.NewInt(1)
+	}
+	if template.NotBefore.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Hour)
+	}
+	if template.NotAfter.IsZero() {
+		template.NotAfter = time.Now().Add(24 * time.Hour)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	return cert
+}
+
+func ecdsaKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func rsaKey(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// chainOf describes the certificates of a chain, leaf first
+func chainOf(certs ...*x509.Certificate) []TLSCertificate {
+	var chain []TLSCertificate
+	for _, cert := range certs {
+		chain = append(chain, describeCertificate(cert))
+	}
+	return chain
+}
+
+func TestSelfSigned(t *testing.T) {
+	caKey := ecdsaKey(t)
+	ca := certificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Test CA"}, IsCA: true, BasicConstraintsValid: true}, caKey, nil, nil)
+	leaf := certificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "www.example.com"}}, ecdsaKey(t), ca, caKey)
+	// Named like its issuer, but signed with the key of another
+	impostor := certificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Test CA"}}, ecdsaKey(t), ca, caKey)
+
+	rootKey := rsaKey(t, 2048)
+	sha1Root := certificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "SHA-1 Root"}, SignatureAlgorithm: x509.SHA1WithRSA, IsCA: true, BasicConstraintsValid: true}, rootKey, nil, nil)
+
+	tests := []struct {
+		name string
+		cert *x509.Certificate
+		want bool
+	}{
+		{"root", ca, true},
+		{"leaf", leaf, false},
+		{"issuer named like the subject", impostor, false},
+		{"SHA-1 root", sha1Root, true},
+	}
+	for _, tt := range tests {
+		if got := selfSigned(tt.cert); got != tt.want {
+			t.Errorf("%s: selfSigned = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTLSWeaknesses(t *testing.T) {
+	caKey := rsaKey(t, 2048)
+	ca := certificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Test CA"}, IsCA: true, BasicConstraintsValid: true}, caKey, nil, nil)
+	sha1CA := certificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "SHA-1 CA"}, SignatureAlgorithm: x509.SHA1WithRSA, IsCA: true, BasicConstraintsValid: true}, caKey, nil, nil)
+
+	healthy := certificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "www.example.com"}}, ecdsaKey(t), ca, caKey)
+	expired := certificate(t, &x509.Certificate{
+		Subject:   pkix.Name{CommonName: "old.example.com"},
+		NotBefore: time.Now().Add(-48 * time.Hour),
+		NotAfter:  time.Now().Add(-24 * time.Hour),
+	}, ecdsaKey(t), nil, nil)
+	weakKey := certificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "weak.example.com"}}, rsaKey(t, 1024), ca, caKey)
+	sha1Leaf := certificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "sha1.example.com"}, SignatureAlgorithm: x509.SHA1WithRSA}, ecdsaKey(t), sha1CA, caKey)
+
+	tests := []struct {
+		name   string
+		result *TLSResult
+		want   []string
+	}{
+		{
+			name:   "healthy",
+			result: &TLSResult{Versions: []string{"TLS 1.2", "TLS 1.3"}, Chain: chainOf(healthy, ca)},
+		},
+		{
+			name:   "TLS 1.0",
+			result: &TLSResult{Versions: []string{"TLS 1.0", "TLS 1.2"}, Chain: chainOf(healthy, ca)},
+			want:   []string{WeaknessTLS10},
+		},
+		{
+			name:   "expired and self-signed",
+			result: &TLSResult{Versions: []string{"TLS 1.3"}, Chain: chainOf(expired)},
+			want:   []string{WeaknessExpired, WeaknessSelfSigned},
+		},
+		{
+			name:   "RSA-1024",
+			result: &TLSResult{Versions: []string{"TLS 1.3"}, Chain: chainOf(weakKey, ca)},
+			want:   []string{WeaknessWeakKey},
+		},
+		{
+			// The SHA-1 signature of a root is never checked by clients
+			name:   "SHA-1 leaf below a SHA-1 root",
+			result: &TLSResult{Versions: []string{"TLS 1.3"}, Chain: chainOf(sha1Leaf, sha1CA)},
+			want:   []string{WeaknessSHA1},
+		},
+		{
+			name:   "self-signed SHA-1 leaf",
+			result: &TLSResult{Versions: []string{"TLS 1.3"}, Chain: chainOf(sha1CA)},
+			want:   []string{WeaknessSHA1, WeaknessSelfSigned},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, weakness := range tlsWeaknesses(tt.result, time.Now()) {
+				got = append(got, weakness.Weakness)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("weaknesses = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{MinVersion: tls.VersionTLS10}
+	server.StartTLS()
+	defer server.Close()
+
+	ti := &tlsInspector{timeout: 2 * time.Second}
+	result := ti.inspect(context.Background(), "127.0.0.1", server.Listener.Addr().(*net.TCPAddr).Port)
+	if result == nil {
+		t.Fatal("inspect found no TLS")
+	}
+	if want := []string{"TLS 1.0", "TLS 1.1", "TLS 1.2", "TLS 1.3"}; !reflect.DeepEqual(result.Versions, want) {
+		t.Errorf("versions = %v, want %v", result.Versions, want)
+	}
+	if len(result.Chain) == 0 || !result.Chain[0].SelfSigned {
+		t.Fatalf("chain = %+v, want the self-signed test certificate", result.Chain)
+	}
+
+	var weaknesses []string
+	for _, weakness := range tlsWeaknesses(result, time.Now()) {
+		weaknesses = append(weaknesses, weakness.Weakness)
+	}
+	if want := []string{WeaknessTLS10, WeaknessSelfSigned}; !reflect.DeepEqual(weaknesses, want) {
+		t.Errorf("weaknesses = %v, want %v", weaknesses, want)
+	}
+
+	// A port that does not speak TLS is no finding
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+	if result := ti.inspect(context.Background(), "127.0.0.1", plain.Listener.Addr().(*net.TCPAddr).Port); result != nil {
+		t.Errorf("plain HTTP inspected as %+v, want nil", result)
+	}
+}
+
+func TestCertificateHostnames(t *testing.T) {
+	results := []*TLSResult{
+		{Chain: []TLSCertificate{
+			{SANs: []string{"*.example.com", "www.example.com", "WWW.Example.com.", "192.0.2.1", "2001:db8::1", "localhost"}},
+			// Names of issuers are not hostnames of the service
+			{SANs: []string{"ca.example.net"}},
+		}},
+		{Chain: []TLSCertificate{{SANs: []string{"example.com", "*.api.example.org"}}}},
+		{},
+	}
+	want := []string{"example.com", "www.example.com", "api.example.org"}
+	if got := certificateHostnames(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("certificateHostnames = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,223 @@
+package modules
+
+import (
+	"GoReconX/internal/engine"
+	"GoReconX/internal/ratelimit"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// States of a scanned port. UDP ports that do not answer cannot be told
+// apart from ports behind a firewall dropping the probe.
+const (
+	PortOpen         = "open"
+	PortOpenFiltered = "open|filtered"
+	PortClosed       = "closed"
+	PortFiltered     = "filtered"
+)
+
+// udpKeyOffset separates the checkpoint keys of UDP ports from those of
+// TCP ports, which are the port numbers themselves
+const udpKeyOffset = 1 << 16
+
+// udpProbe is a payload that makes a UDP service answer
+type udpProbe struct {
+	service string
+	ports   []int
+	payload []byte
+}
+
+// udpProbes are sent to their ports; every other port gets an empty
+// datagram, which few services answer
+var udpProbes = []udpProbe{
+	// Standard query for version.bind TXT in class CHAOS
+	{"dns", []int{53}, []byte("\x13\x37\x01\x00\x00\x01\x00\x00\x00\x00\x00\x00" +
+		"\x07version\x04bind\x00\x00\x10\x00\x03")},
+	// Read request, answered with an error for the missing file
+	{"tftp", []int{69}, []byte("\x00\x01goreconx.txt\x00octet\x00")},
+	// RPC call of the portmapper's NULL procedure
+	{"rpcbind", []int{111}, []byte("\x72\xfe\x1d\x13\x00\x00\x00\x00\x00\x00\x00\x02" +
+		"\x00\x01\x86\xa0\x00\x00\x00\x02\x00\x00\x00\x00" +
+		"\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00")},
+	// Version 3 client request
+	{"ntp", []int{123}, append([]byte{0x1b}, make([]byte, 47)...)},
+	// Node status request for the wildcard name *
+	{"netbios-ns", []int{137}, []byte("\x80\xf0\x00\x10\x00\x01\x00\x00\x00\x00\x00\x00" +
+		"\x20CKAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA\x00\x00\x21\x00\x01")},
+	// SNMPv2c get-request for sysDescr.0 with the community public
+	{"snmp", []int{161}, []byte("\x30\x29\x02\x01\x01\x04\x06public" +
+		"\xa0\x1c\x02\x04\x71\xb4\xb5\x68\x02\x01\x00\x02\x01\x00" +
+		"\x30\x0e\x30\x0c\x06\x08\x2b\x06\x01\x02\x01\x01\x01\x00\x05\x00")},
+	// IKEv1 main mode proposal: 3DES, SHA-1, pre-shared key, group 2
+	{"isakmp", []int{500}, []byte("\x5c\x72\x65\x63\x6f\x6e\x78\x31\x00\x00\x00\x00\x00\x00\x00\x00" +
+		"\x01\x10\x02\x00\x00\x00\x00\x00\x00\x00\x00\x50" +
+		"\x00\x00\x00\x34\x00\x00\x00\x01\x00\x00\x00\x01" +
+		"\x00\x00\x00\x28\x01\x01\x00\x01" +
+		"\x00\x00\x00\x20\x01\x01\x00\x00" +
+		"\x80\x01\x00\x05\x80\x02\x00\x02\x80\x03\x00\x01" +
+		"\x80\x04\x00\x02\x80\x0b\x00\x01\x80\x0c\x70\x80")},
+	// Instance enumeration of the SQL Server Browser
+	{"ms-sql-m", []int{1434}, []byte{0x02}},
+	// Search for every device and service
+	{"ssdp", []int{1900}, []byte("M-SEARCH * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\n" +
+		"MAN: \"ssdp:discover\"\r\nMX: 1\r\nST: ssdp:all\r\n\r\n")},
+	// Query for the services announced over DNS-SD
+	{"mdns", []int{5353}, []byte("\x00\x00\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00" +
+		"\x09_services\x07_dns-sd\x04_udp\x05local\x00\x00\x0c\x00\x01")},
+	// Version command behind the UDP frame header
+	{"memcached", []int{11211}, []byte("\x00\x01\x00\x00\x00\x01\x00\x00version\r\n")},
+}
+
+// defaultUDPPorts lists the ports that have a probe
+func defaultUDPPorts() string {
+	var ports []int
+	for _, probe := range udpProbes {
+		ports = append(ports, probe.ports...)
+	}
+	sort.Ints(ports)
+
+	list := make([]string, len(ports))
+	for i, port := range ports {
+		list[i] = strconv.Itoa(port)
+	}
+	return strings.Join(list, ",")
+}
+
+// probeFor returns the probe of port, or an empty one
+func probeFor(port int) udpProbe {
+	for _, probe := range udpProbes {
+		for _, p := range probe.ports {
+			if p == port {
+				return probe
+			}
+		}
+	}
+	return udpProbe{}
+}
+
+// scanUDPPorts probes UDP ports with a fixed pool of workers, recording each
+// scanned port in cp. Ports that answer are open, those that do not after
+// the retries are open|filtered; both are returned. Ports the host reports
+// unreachable over ICMP are closed or filtered and only counted.
+func (ps *PortScanner) scanUDPPorts(ctx context.Context, target string, ports []int, threads, timeout, retries int, progress *progressReporter, cp *checkpoint[*PortResult]) ([]*PortResult, map[string]int) {
+	limiter := ratelimit.FromContext(ctx)
+
+	job := engine.Run(ctx, threads, engine.Slice(ports), func(ctx context.Context, p int) (*PortResult, bool) {
+		defer progress.Advance(1)
+
+		state, err := ps.probeUDP(ctx, limiter, target, p, time.Duration(timeout)*time.Second, retries)
+		// A probe cut short by cancellation is repeated on resume
+		if ctx.Err() != nil {
+			return nil, false
+		}
+		if err != nil {
+			ps.logger.WithFields(logrus.Fields{
+				"target": target,
+				"port":   p,
+				"error":  err,
+			}).Debug("UDP probe failed")
+			cp.Done(udpKeyOffset + p)
+			return nil, false
+		}
+
+		service := probeFor(p).service
+		if service == "" {
+			service = ps.getServiceName(p)
+		}
+		result := &PortResult{
+			Port:     p,
+			Protocol: "udp",
+			State:    state,
+			Service:  service,
+		}
+		if state != PortOpen && state != PortOpenFiltered {
+			cp.Done(udpKeyOffset + p)
+			return result, true
+		}
+		cp.Done(udpKeyOffset+p, result)
+		return result, true
+	})
+
+	var results []*PortResult
+	states := make(map[string]int)
+	job.Collect(func(result *PortResult) {
+		states[result.State]++
+		if result.State != PortOpen && result.State != PortOpenFiltered {
+			return
+		}
+		results = append(results, result)
+		progress.Finding(result)
+
+		ps.logger.WithFields(logrus.Fields{
+			"target": target,
+			"port":   result.Port,
+			"state":  result.State,
+		}).Debug("Found UDP port")
+	})
+
+	return results, states
+}
+
+// probeUDP sends the probe of port to target, again after each timeout up
+// to retries times, and classifies the port by the answer. ICMP errors are
+// only seen where the operating system reports them on connected sockets.
+func (ps *PortScanner) probeUDP(ctx context.Context, limiter *ratelimit.Limiter, target string, port int, timeout time.Duration, retries int) (string, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(target, strconv.Itoa(port)))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	payload := probeFor(port).payload
+	buf := make([]byte, 1500)
+	for attempt := 0; attempt <= retries; attempt++ {
+		if err := limiter.WaitHost(ctx, target); err != nil {
+			return "", err
+		}
+		if _, err := conn.Write(payload); err != nil {
+			if state, ok := icmpState(err); ok {
+				return state, nil
+			}
+			return "", err
+		}
+
+		conn.SetReadDeadline(time.Now().Add(timeout))
+		_, err := conn.Read(buf)
+		if err == nil {
+			return PortOpen, nil
+		}
+		if state, ok := icmpState(err); ok {
+			return state, nil
+		}
+		if !errors.Is(err, os.ErrDeadlineExceeded) {
+			return "", err
+		}
+	}
+	return PortOpenFiltered, nil
+}
+
+// icmpState maps the error an ICMP unreachable message causes on a
+// connected UDP socket to the state of the port
+func icmpState(err error) (string, bool) {
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		// Port unreachable
+		return PortClosed, true
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		// Host, network or administratively prohibited
+		return PortFiltered, true
+	}
+	return "", false
+}
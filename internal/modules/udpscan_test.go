@@ -0,0 +1,196 @@
+package modules
+
+import (
+	"GoReconX/internal/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// udpListener is a local UDP port that counts the datagrams it receives
+// and answers them if echo is set
+type udpListener struct {
+	port     int
+	received atomic.Int32
+}
+
+func startUDPListener(t *testing.T, echo bool) *udpListener {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	l := &udpListener{port: conn.LocalAddr().(*net.UDPAddr).Port}
+	go func() {
+		buffer := make([]byte, 1500)
+		for {
+			_, peer, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			l.received.Add(1)
+			if echo {
+				conn.WriteTo([]byte("pong"), peer)
+			}
+		}
+	}()
+	return l
+}
+
+// closedUDPPort returns a local UDP port nothing listens on
+func closedUDPPort(t *testing.T) int {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+	return port
+}
+
+func TestProbeUDP(t *testing.T) {
+	echoing := startUDPListener(t, true)
+	silent := startUDPListener(t, false)
+
+	tests := []struct {
+		name         string
+		port         int
+		listener     *udpListener
+		want         string
+		wantReceived int32
+	}{
+		{name: "answering port", port: echoing.port, listener: echoing, want: PortOpen, wantReceived: 1},
+		{name: "silent port", port: silent.port, listener: silent, want: PortOpenFiltered, wantReceived: 3},
+		{name: "closed port", port: closedUDPPort(t), want: PortClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := NewPortScanner(config.DefaultConfig(), quietLogger())
+			state, err := ps.probeUDP(context.Background(), nil, "127.0.0.1", tt.port, 100*time.Millisecond, 2)
+			if err != nil {
+				t.Fatalf("probeUDP: %v", err)
+			}
+			if state != tt.want {
+				t.Errorf("state = %s, want %s", state, tt.want)
+			}
+			if tt.listener != nil && tt.listener.received.Load() != tt.wantReceived {
+				t.Errorf("sent %d probes, want %d", tt.listener.received.Load(), tt.wantReceived)
+			}
+		})
+	}
+}
+
+func TestProbeUDPCancelled(t *testing.T) {
+	silent := startUDPListener(t, false)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	ps := NewPortScanner(config.DefaultConfig(), quietLogger())
+	start := time.Now()
+	if _, err := ps.probeUDP(ctx, nil, "127.0.0.1", silent.port, 10*time.Second, 0); err == nil {
+		t.Error("cancelled probe succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancelled probe took %v", elapsed)
+	}
+}
+
+func TestICMPState(t *testing.T) {
+	wrap := func(errno syscall.Errno) error {
+		return &net.OpError{Op: "read", Net: "udp", Err: os.NewSyscallError("recvfrom", errno)}
+	}
+	tests := []struct {
+		err    error
+		want   string
+		wantOK bool
+	}{
+		{wrap(syscall.ECONNREFUSED), PortClosed, true},
+		{wrap(syscall.EHOSTUNREACH), PortFiltered, true},
+		{wrap(syscall.ENETUNREACH), PortFiltered, true},
+		{os.ErrDeadlineExceeded, "", false},
+		{errors.New("something else"), "", false},
+	}
+	for _, tt := range tests {
+		if state, ok := icmpState(tt.err); state != tt.want || ok != tt.wantOK {
+			t.Errorf("icmpState(%v) = %q, %v, want %q, %v", tt.err, state, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestPortScannerUDPResume(t *testing.T) {
+	store := newCheckpointStore(t)
+	echoing := startUDPListener(t, true)
+	silent := startUDPListener(t, false)
+	closed := closedUDPPort(t)
+
+	ps := NewPortScanner(config.DefaultConfig(), quietLogger())
+	options, err := ps.GetOptionSchema().Validate(map[string]interface{}{
+		"scan_tcp":          false,
+		"scan_udp":          true,
+		"udp_ports":         fmt.Sprintf("%d,%d,%d", echoing.port, silent.port, closed),
+		"udp_retries":       0,
+		"timeout":           1,
+		"service_detection": false,
+		"tls_inspection":    false,
+	})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	result, err := ps.Execute(withCheckpoints(context.Background(), store), "127.0.0.1", options)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	wantStates := map[string]int{PortOpen: 1, PortOpenFiltered: 1, PortClosed: 1}
+	if states := result.Metadata["udp_states"]; !reflect.DeepEqual(states, wantStates) {
+		t.Errorf("udp_states = %v, want %v", states, wantStates)
+	}
+	if len(result.Results) != 2 {
+		t.Errorf("%d results, want the open and open|filtered ports", len(result.Results))
+	}
+
+	// UDP ports are checkpointed apart from the TCP ports of the same number
+	var state checkpointState[*PortResult]
+	if err := json.Unmarshal([]byte(stored(t, store)), &state); err != nil {
+		t.Fatalf("checkpoint: %v", err)
+	}
+	completed, err := parseSpans(state.Completed)
+	if err != nil {
+		t.Fatalf("completed: %v", err)
+	}
+	for _, port := range []int{echoing.port, silent.port, closed} {
+		if !completed.contains(udpKeyOffset+port) || completed.contains(port) {
+			t.Errorf("completed = %s, want UDP port %d under key %d", state.Completed, port, udpKeyOffset+port)
+		}
+	}
+	if len(state.Findings) != 2 {
+		t.Errorf("%d findings checkpointed, want 2", len(state.Findings))
+	}
+
+	// A resumed scan restores the ports without probing them again
+	resumed := &checkpointStore{mm: store.mm, scanID: store.scanID, resume: stored(t, store)}
+	result, err = ps.Execute(withCheckpoints(context.Background(), resumed), "127.0.0.1", options)
+	if err != nil {
+		t.Fatalf("resumed Execute: %v", err)
+	}
+	if len(result.Results) != 2 {
+		t.Errorf("%d results after resuming, want 2 restored", len(result.Results))
+	}
+	if echoing.received.Load() != 1 || silent.received.Load() != 1 {
+		t.Errorf("ports probed %d and %d times, want once each", echoing.received.Load(), silent.received.Load())
+	}
+	if _, ok := result.Metadata["udp_states"]; ok {
+		t.Error("resumed scan reports UDP states, want none as nothing was probed")
+	}
+}
@@ -0,0 +1,164 @@
+package modules
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// wildcardProbes is the number of random labels resolved per domain level.
+// Wildcards served by load balancers answer with different addresses each
+// time, so several answers are collected.
+const wildcardProbes = 3
+
+// lookupFunc resolves a name to its addresses
+type lookupFunc func(ctx context.Context, name string) ([]net.IPAddr, error)
+
+// wildcardDetector finds wildcard DNS records by resolving random labels
+// that cannot exist, e.g. x7k2p9q4m1z8.example.com. Each domain level is
+// probed once, the first time a name below it is checked.
+type wildcardDetector struct {
+	lookup lookupFunc
+
+	mu     sync.Mutex
+	levels map[string]*wildcardLevel
+}
+
+// wildcardLevel holds the wildcard answers of a single domain
+type wildcardLevel struct {
+	ready   chan struct{}
+	answers map[string]bool
+	// abandoned is set when the probes were cancelled. The level is then
+	// removed, and those waiting for it probe again.
+	abandoned bool
+}
+
+// newWildcardDetector creates a detector resolving names with lookup
+func newWildcardDetector(lookup lookupFunc) *wildcardDetector {
+	return &wildcardDetector{
+		lookup: lookup,
+		levels: make(map[string]*wildcardLevel),
+	}
+}
+
+// Detect returns the wildcard answers of domain, or nil if it has none or
+// ctx was cancelled before they were known
+func (wd *wildcardDetector) Detect(ctx context.Context, domain string) map[string]bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+
+	for {
+		wd.mu.Lock()
+		level, ok := wd.levels[domain]
+		if !ok {
+			level = &wildcardLevel{ready: make(chan struct{})}
+			wd.levels[domain] = level
+		}
+		wd.mu.Unlock()
+
+		if !ok {
+			return wd.probe(ctx, domain, level)
+		}
+		select {
+		case <-level.ready:
+		case <-ctx.Done():
+			return nil
+		}
+		if !level.abandoned {
+			return level.answers
+		}
+	}
+}
+
+// probe resolves random labels below domain and publishes their answers
+// in level. Answers cut short by cancellation are not published, as they
+// may miss a wildcard.
+func (wd *wildcardDetector) probe(ctx context.Context, domain string, level *wildcardLevel) map[string]bool {
+	for i := 0; i < wildcardProbes; i++ {
+		ips, err := wd.lookup(ctx, randomLabel()+"."+domain)
+		if err != nil {
+			continue
+		}
+		for _, ip := range ips {
+			if level.answers == nil {
+				level.answers = make(map[string]bool)
+			}
+			level.answers[ip.IP.String()] = true
+		}
+	}
+
+	if ctx.Err() != nil {
+		wd.mu.Lock()
+		delete(wd.levels, domain)
+		wd.mu.Unlock()
+		level.abandoned = true
+		close(level.ready)
+		return nil
+	}
+	close(level.ready)
+	return level.answers
+}
+
+// Matches reports whether the addresses name resolved to could be the answer
+// of a wildcard at any level between name and root, i.e. whether name may
+// not exist at all
+func (wd *wildcardDetector) Matches(ctx context.Context, root, name string, ips []net.IPAddr) bool {
+	root = strings.ToLower(strings.TrimSuffix(root, "."))
+	level := strings.ToLower(strings.TrimSuffix(name, "."))
+
+	for level != root && strings.HasSuffix(level, "."+root) {
+		_, level, _ = strings.Cut(level, ".")
+		if answers := wd.Detect(ctx, level); answers != nil && containsAll(answers, ips) {
+			return true
+		}
+	}
+	return false
+}
+
+// Wildcards returns the sorted wildcard answers of every level probed so
+// far that has a wildcard, keyed by domain
+func (wd *wildcardDetector) Wildcards() map[string][]string {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+
+	wildcards := make(map[string][]string)
+	for domain, level := range wd.levels {
+		select {
+		case <-level.ready:
+		default:
+			continue
+		}
+		if len(level.answers) == 0 {
+			continue
+		}
+		answers := make([]string, 0, len(level.answers))
+		for ip := range level.answers {
+			answers = append(answers, ip)
+		}
+		sort.Strings(answers)
+		wildcards[domain] = answers
+	}
+	return wildcards
+}
+
+// containsAll reports whether every address is in answers
+func containsAll(answers map[string]bool, ips []net.IPAddr) bool {
+	for _, ip := range ips {
+		if !answers[ip.IP.String()] {
+			return false
+		}
+	}
+	return len(ips) > 0
+}
+
+// randomLabel returns a label that is practically certain not to exist
+func randomLabel() string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	label := make([]byte, 16)
+	for i := range label {
+		label[i] = alphabet[rand.Intn(len(alphabet))]
+	}
+	return string(label)
+}
@@ -0,0 +1,74 @@
+package modules
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// wildcardLookup answers every name with 192.0.2.1, except that lookups
+// block until their context is cancelled while blocking is set
+type wildcardLookup struct {
+	blocking atomic.Bool
+	lookups  atomic.Int32
+}
+
+func (l *wildcardLookup) lookup(ctx context.Context, name string) ([]net.IPAddr, error) {
+	l.lookups.Add(1)
+	if l.blocking.Load() {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return []net.IPAddr{{IP: net.ParseIP("192.0.2.1")}}, nil
+}
+
+func TestWildcardDetectProbesOnce(t *testing.T) {
+	l := &wildcardLookup{}
+	wd := newWildcardDetector(l.lookup)
+
+	for i := 0; i < 3; i++ {
+		if answers := wd.Detect(context.Background(), "Example.com."); !answers["192.0.2.1"] {
+			t.Fatalf("answers = %v, want the wildcard", answers)
+		}
+	}
+	if got := l.lookups.Load(); got != wildcardProbes {
+		t.Errorf("%d lookups, want %d", got, wildcardProbes)
+	}
+}
+
+func TestWildcardDetectCancelled(t *testing.T) {
+	l := &wildcardLookup{}
+	l.blocking.Store(true)
+	wd := newWildcardDetector(l.lookup)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	probed := make(chan map[string]bool)
+	go func() { probed <- wd.Detect(ctx, "example.com") }()
+
+	// A second caller waits for the first one's probes
+	for l.lookups.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	waited := make(chan map[string]bool)
+	go func() { waited <- wd.Detect(context.Background(), "example.com") }()
+	time.Sleep(10 * time.Millisecond)
+
+	l.blocking.Store(false)
+	cancel()
+	if answers := <-probed; answers != nil {
+		t.Errorf("cancelled probe returned %v, want nil", answers)
+	}
+
+	// The cancelled probes are not taken as the answer of the level
+	if answers := <-waited; !answers["192.0.2.1"] {
+		t.Errorf("waiting caller got %v, want the wildcard probed again", answers)
+	}
+	if answers := wd.Detect(context.Background(), "example.com"); !answers["192.0.2.1"] {
+		t.Errorf("later caller got %v, want the wildcard", answers)
+	}
+	if wildcards := wd.Wildcards(); len(wildcards["example.com"]) != 1 {
+		t.Errorf("wildcards = %v, want example.com", wildcards)
+	}
+}
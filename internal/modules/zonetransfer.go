@@ -0,0 +1,388 @@
+package modules
+
+import (
+	"GoReconX/internal/config"
+	"GoReconX/internal/dns"
+	"GoReconX/internal/ratelimit"
+	"GoReconX/internal/scope"
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func init() {
+	Register(ModuleInfo{
+		ID:             "zone_transfer",
+		Name:           "Zone Transfer",
+		Category:       CategoryActive,
+		Description:    "Attempts AXFR and IXFR zone transfers against the domain's nameservers",
+		RequiredInputs: []string{InputDomain},
+	}, func(cfg *config.Config, logger *logrus.Logger) ModuleInterface {
+		return NewZoneTransfer(cfg, logger)
+	})
+}
+
+// ZoneTransferResult is a nameserver that handed out the whole zone
+type ZoneTransferResult struct {
+	Zone       string `json:"zone"`
+	Nameserver string `json:"nameserver"`
+	Address    string `json:"address"`
+	// Type is the query that was answered, AXFR or IXFR
+	Type    string `json:"type"`
+	Records int    `json:"records"`
+}
+
+// String returns a short human readable form of the result
+func (zr *ZoneTransferResult) String() string {
+	return fmt.Sprintf("%s allows %s of %s: %d records", nameserverLabel(zr.Nameserver, zr.Address), zr.Type, zr.Zone, zr.Records)
+}
+
+// ResultType identifies zone transfer findings in the results table
+func (zr *ZoneTransferResult) ResultType() string {
+	return "zone_transfer"
+}
+
+// Severity rates a zone transfer high, as it discloses every host in the
+// zone at once
+func (zr *ZoneTransferResult) Severity() string {
+	return SeverityHigh
+}
+
+// ZoneTransfer tries to transfer a domain's zone from its nameservers
+type ZoneTransfer struct {
+	config *config.Config
+	logger *logrus.Logger
+}
+
+// NewZoneTransfer creates a new zone transfer module
+func NewZoneTransfer(cfg *config.Config, logger *logrus.Logger) *ZoneTransfer {
+	return &ZoneTransfer{config: cfg, logger: logger}
+}
+
+// GetName returns the module name
+func (zt *ZoneTransfer) GetName() string {
+	return "Zone Transfer"
+}
+
+// GetDescription returns the module description
+func (zt *ZoneTransfer) GetDescription() string {
+	return "Attempts AXFR and IXFR zone transfers against the domain's nameservers"
+}
+
+// Validate validates the target domain
+func (zt *ZoneTransfer) Validate(target string) error {
+	if target == "" {
+		return fmt.Errorf("target domain cannot be empty")
+	}
+	if net.ParseIP(target) != nil || !strings.Contains(target, ".") {
+		return fmt.Errorf("invalid domain format")
+	}
+	return nil
+}
+
+// GetOptionSchema returns the options accepted by the module
+func (zt *ZoneTransfer) GetOptionSchema() OptionSchema {
+	return OptionSchema{
+		{Name: "nameservers", Type: OptionString, Default: "", Description: "Nameservers to try instead of the domain's NS records, e.g. ns1.example.com,192.0.2.53:5353"},
+		{Name: "ixfr", Type: OptionBool, Default: true, Description: "Try an IXFR transfer when AXFR is refused"},
+		{Name: "timeout", Type: OptionInt, Default: 15, Range: &OptionRange{Min: 1, Max: 300}, Description: "Timeout of each transfer in seconds"},
+	}
+}
+
+// Execute looks up the nameservers of the target and asks each of its
+// addresses for the whole zone. The records of the first transfer are
+// reported along with a subdomain for every name in the zone.
+func (zt *ZoneTransfer) Execute(ctx context.Context, target string, options Options) (*ScanResult, error) {
+	startTime := time.Now()
+	zt.logger.WithField("target", target).Info("Starting zone transfer attempts")
+
+	result := &ScanResult{
+		ModuleName: zt.GetName(),
+		Target:     target,
+		Status:     StatusRunning,
+		StartTime:  startTime.Format(time.RFC3339),
+		Metadata:   make(map[string]interface{}),
+	}
+	fail := func(err error) (*ScanResult, error) {
+		result.Status = StatusFailed
+		result.ErrorMessage = err.Error()
+		result.EndTime = time.Now().Format(time.RFC3339)
+		return result, err
+	}
+
+	zone := strings.ToLower(strings.TrimSuffix(target, "."))
+	timeout := time.Duration(options.Int("timeout")) * time.Second
+	pool, err := recordPool(ctx, zt.config, zt.logger)
+	if err != nil {
+		return fail(err)
+	}
+
+	nameservers := splitList(options.String("nameservers"))
+	if len(nameservers) == 0 {
+		if nameservers, err = lookupNameservers(ctx, pool, zone, timeout); err != nil {
+			return fail(err)
+		}
+	}
+
+	engagement := scope.FromContext(ctx)
+	limiter := ratelimit.FromContext(ctx)
+	statuses := make(map[string]string)
+	var zoneRecords []dns.Record
+	for _, nameserver := range nameservers {
+		addresses, err := nameserverAddresses(ctx, pool, engagement, nameserver, timeout)
+		if err != nil {
+			statuses[nameserver] = err.Error()
+			continue
+		}
+
+		for _, address := range addresses {
+			if ctx.Err() != nil {
+				break
+			}
+			key := nameserverLabel(nameserver, address)
+			host, _, _ := net.SplitHostPort(address)
+			if err := limiter.WaitHost(ctx, host); err != nil {
+				break
+			}
+
+			qtype, records, err := zt.transfer(ctx, address, zone, options.Bool("ixfr"), timeout)
+			if err != nil {
+				statuses[key] = err.Error()
+				continue
+			}
+
+			statuses[key] = fmt.Sprintf("%s allowed", dns.TypeName(qtype))
+			zt.logger.WithFields(logrus.Fields{
+				"zone":       zone,
+				"nameserver": key,
+				"records":    len(records),
+			}).Warn("Nameserver allows zone transfers")
+			result.Results = append(result.Results, &ZoneTransferResult{
+				Zone:       zone,
+				Nameserver: nameserver,
+				Address:    address,
+				Type:       dns.TypeName(qtype),
+				Records:    len(records),
+			})
+			if zoneRecords == nil {
+				zoneRecords = records
+			}
+		}
+	}
+
+	records := uniqueRecords(zoneRecords)
+	subdomains := zoneSubdomains(engagement, zone, records)
+	counts := make(map[string]int)
+	for _, record := range records {
+		counts[record.Type]++
+		result.Results = append(result.Results, &DNSRecordResult{Record: record})
+	}
+	for _, subdomain := range subdomains {
+		result.Results = append(result.Results, subdomain)
+	}
+
+	transferred := zoneRecords != nil
+	endTime := time.Now()
+	result.Status = StatusCompleted
+	result.EndTime = endTime.Format(time.RFC3339)
+	result.Metadata["nameservers"] = statuses
+	result.Metadata["transfer_allowed"] = transferred
+	if transferred {
+		result.Metadata["severity"] = SeverityHigh
+	}
+	result.Metadata["records"] = counts
+	result.Metadata["subdomains"] = len(subdomains)
+	result.Metadata["duration_seconds"] = endTime.Sub(startTime).Seconds()
+
+	if err := ctx.Err(); err != nil {
+		result.Status = StatusCancelled
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+
+	zt.logger.WithFields(logrus.Fields{
+		"target":           target,
+		"transfer_allowed": transferred,
+		"records":          len(records),
+		"duration":         endTime.Sub(startTime),
+	}).Info("Zone transfer attempts completed")
+
+	return result, nil
+}
+
+// lookupNameservers returns the names of the zone's nameservers
+func lookupNameservers(ctx context.Context, pool *dns.Pool, zone string, timeout time.Duration) ([]string, error) {
+	lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	records, err := pool.Lookup(lookupCtx, zone, dnsmessage.TypeNS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the nameservers of %s: %v", zone, err)
+	}
+	var nameservers []string
+	for _, record := range records {
+		if record.Type == "NS" && strings.EqualFold(record.Name, zone) {
+			nameservers = appendUnique(nameservers, strings.ToLower(record.Value))
+		}
+	}
+	if len(nameservers) == 0 {
+		return nil, fmt.Errorf("no NS records found for %s", zone)
+	}
+	sort.Strings(nameservers)
+	return nameservers, nil
+}
+
+// nameserverAddresses returns the addresses of a nameserver, given as a
+// name or an IP address with an optional port. Nameservers outside the
+// engagement scope, which are often run by a hosting provider, are left
+// alone.
+func nameserverAddresses(ctx context.Context, pool *dns.Pool, engagement *scope.Scope, nameserver string, timeout time.Duration) ([]string, error) {
+	host, port := nameserver, 53
+	if h, p, err := net.SplitHostPort(nameserver); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid port %s", p)
+		}
+		host, port = h, n
+	}
+	if !engagement.ContainsPort(port) {
+		return nil, fmt.Errorf("port %d is out of scope", port)
+	}
+
+	var ips []net.IPAddr
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IPAddr{{IP: ip}}
+	} else {
+		lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		var err error
+		if ips, err = pool.LookupIPAddr(lookupCtx, host); err != nil {
+			return nil, fmt.Errorf("failed to resolve: %v", err)
+		}
+	}
+	if !inScope(engagement, host, ips) {
+		return nil, fmt.Errorf("out of scope")
+	}
+
+	addresses := make([]string, len(ips))
+	for i, ip := range ips {
+		addresses[i] = net.JoinHostPort(ip.IP.String(), strconv.Itoa(port))
+	}
+	return addresses, nil
+}
+
+// transfer asks address for the zone with AXFR and, if that is refused and
+// ixfr is set, with IXFR. It returns the query type that was answered.
+func (zt *ZoneTransfer) transfer(ctx context.Context, address, zone string, ixfr bool, timeout time.Duration) (dnsmessage.Type, []dns.Record, error) {
+	qtypes := []dnsmessage.Type{dnsmessage.TypeAXFR}
+	if ixfr {
+		qtypes = append(qtypes, dns.TypeIXFR)
+	}
+
+	var lastErr error
+	for _, qtype := range qtypes {
+		transferCtx, cancel := context.WithTimeout(ctx, timeout)
+		records, err := dns.Transfer(transferCtx, address, zone, qtype)
+		cancel()
+		// A zone that is unchanged since the serial holds nothing
+		if err == nil && len(records) > 1 {
+			return qtype, records, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("%s answered %s without records", address, dns.TypeName(qtype))
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return 0, nil, lastErr
+}
+
+// uniqueRecords drops the repeated SOA record that closes a transfer and
+// any other duplicates, and sorts the records by name and type
+func uniqueRecords(records []dns.Record) []dns.Record {
+	var unique []dns.Record
+	seen := make(map[dns.Record]bool)
+	for _, record := range records {
+		if !seen[record] {
+			seen[record] = true
+			unique = append(unique, record)
+		}
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		a, b := unique[i], unique[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Type < b.Type
+	})
+	return unique
+}
+
+// zoneSubdomains returns a subdomain finding for every name below zone that
+// has records. Wildcard names such as *.example.com and service names such
+// as _ldap._tcp.example.com are not hosts and only show up among the
+// records.
+func zoneSubdomains(engagement *scope.Scope, zone string, records []dns.Record) []*SubdomainResult {
+	byName := make(map[string]*SubdomainResult)
+	var names []string
+	for _, record := range records {
+		name := strings.ToLower(record.Name)
+		if !strings.HasSuffix(name, "."+zone) || strings.HasPrefix(name, "*.") || strings.HasPrefix(name, "_") {
+			continue
+		}
+		subdomain, ok := byName[name]
+		if !ok {
+			subdomain = &SubdomainResult{Subdomain: name, Sources: []string{SourceZoneTransfer}}
+			byName[name] = subdomain
+			names = append(names, name)
+		}
+		subdomain.Records = append(subdomain.Records, record)
+		if record.Type == "A" || record.Type == "AAAA" {
+			subdomain.IPs = append(subdomain.IPs, record.Value)
+		}
+	}
+
+	subdomains := make([]*SubdomainResult, 0, len(names))
+	for _, name := range names {
+		subdomain := byName[name]
+		var ips []net.IPAddr
+		for _, ip := range subdomain.IPs {
+			ips = append(ips, net.IPAddr{IP: net.ParseIP(ip)})
+		}
+		subdomain.Resolved = len(ips) > 0
+		subdomain.OutOfScope = !inScope(engagement, name, ips)
+		subdomain.CNAMEChain = dns.Chain(records, name)
+		subdomains = append(subdomains, subdomain)
+	}
+	linkParents(zone, subdomains)
+	return subdomains
+}
+
+// nameserverLabel names a nameserver address, e.g. "ns1.example.com
+// (192.0.2.1:53)"
+func nameserverLabel(nameserver, address string) string {
+	if nameserver == address {
+		return address
+	}
+	return fmt.Sprintf("%s (%s)", nameserver, address)
+}
+
+// splitList splits a comma-separated option value into its trimmed items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
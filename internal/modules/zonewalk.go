@@ -0,0 +1,615 @@
+package modules
+
+import (
+	"GoReconX/internal/config"
+	"GoReconX/internal/dns"
+	"GoReconX/internal/engine"
+	"GoReconX/internal/scope"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func init() {
+	Register(ModuleInfo{
+		ID:             "zone_walk",
+		Name:           "DNSSEC Zone Walk",
+		Category:       CategoryActive,
+		Description:    "Lists the names of DNSSEC-signed zones by walking NSEC chains and cracking NSEC3 hashes",
+		RequiredInputs: []string{InputDomain},
+	}, func(cfg *config.Config, logger *logrus.Logger) ModuleInterface {
+		return NewZoneWalker(cfg, logger)
+	})
+}
+
+const (
+	// maxProbeTries bounds the random names hashed in search of one whose
+	// hash is not covered by the NSEC3 records collected yet
+	maxProbeTries = 50000
+	// maxWalkFailures is the number of failed queries in a row after which
+	// a walk is given up
+	maxWalkFailures = 10
+)
+
+// ZoneWalkResult is a zone whose names can be listed through the records
+// DNSSEC uses to prove that names do not exist
+type ZoneWalkResult struct {
+	Zone string `json:"zone"`
+	// Denial is the kind of records the zone is signed with, NSEC or NSEC3
+	Denial   string `json:"denial"`
+	Names    int    `json:"names"`
+	Hashes   int    `json:"hashes,omitempty"`
+	Cracked  int    `json:"cracked,omitempty"`
+	Complete bool   `json:"complete"`
+}
+
+// String returns a short human readable form of the result
+func (wr *ZoneWalkResult) String() string {
+	complete := ""
+	if wr.Complete {
+		complete = ", complete"
+	}
+	if wr.Denial == "NSEC3" {
+		return fmt.Sprintf("%s is signed with NSEC3: %d hashes collected%s, %d cracked", wr.Zone, wr.Hashes, complete, wr.Cracked)
+	}
+	return fmt.Sprintf("%s can be walked with NSEC: %d names%s", wr.Zone, wr.Names, complete)
+}
+
+// ResultType identifies zone walk findings in the results table
+func (wr *ZoneWalkResult) ResultType() string {
+	return "zone_walk"
+}
+
+// Severity rates an NSEC zone medium, as anyone can list all its names,
+// and an NSEC3 zone low, as its names have to be guessed offline
+func (wr *ZoneWalkResult) Severity() string {
+	if wr.Denial == "NSEC3" {
+		return SeverityLow
+	}
+	return SeverityMedium
+}
+
+// NSEC3HashResult is the hash of a name in a zone signed with NSEC3, with
+// the parameters it was hashed with
+type NSEC3HashResult struct {
+	Zone string `json:"zone"`
+	Hash string `json:"hash"`
+	// Next and Types are only known for hashes whose record was seen
+	Next       string   `json:"next,omitempty"`
+	Types      []string `json:"types,omitempty"`
+	Algorithm  uint8    `json:"algorithm"`
+	Iterations uint16   `json:"iterations"`
+	Salt       string   `json:"salt"`
+	OptOut     bool     `json:"opt_out,omitempty"`
+	// Name is what the hash was cracked to, if it was
+	Name string `json:"name,omitempty"`
+}
+
+// String returns a short human readable form of the result
+func (hr *NSEC3HashResult) String() string {
+	s := fmt.Sprintf("%s.%s", hr.Hash, hr.Zone)
+	if hr.Name != "" {
+		s += " = " + hr.Name
+	}
+	return s
+}
+
+// ResultType identifies NSEC3 hash findings in the results table
+func (hr *NSEC3HashResult) ResultType() string {
+	return "nsec3_hash"
+}
+
+// ZoneWalker lists the names of DNSSEC-signed zones
+type ZoneWalker struct {
+	config *config.Config
+	logger *logrus.Logger
+}
+
+// NewZoneWalker creates a new zone walking module
+func NewZoneWalker(cfg *config.Config, logger *logrus.Logger) *ZoneWalker {
+	return &ZoneWalker{config: cfg, logger: logger}
+}
+
+// GetName returns the module name
+func (zw *ZoneWalker) GetName() string {
+	return "DNSSEC Zone Walk"
+}
+
+// GetDescription returns the module description
+func (zw *ZoneWalker) GetDescription() string {
+	return "Lists the names of DNSSEC-signed zones by walking NSEC chains and cracking NSEC3 hashes"
+}
+
+// Validate validates the target domain
+func (zw *ZoneWalker) Validate(target string) error {
+	if target == "" {
+		return fmt.Errorf("target domain cannot be empty")
+	}
+	if net.ParseIP(target) != nil || !strings.Contains(target, ".") {
+		return fmt.Errorf("invalid domain format")
+	}
+	return nil
+}
+
+// GetOptionSchema returns the options accepted by the module
+func (zw *ZoneWalker) GetOptionSchema() OptionSchema {
+	return OptionSchema{
+		{Name: "direct", Type: OptionBool, Default: true, Description: "Query the domain's nameservers directly rather than through the resolvers"},
+		{Name: "nameservers", Type: OptionString, Default: "", Description: "Nameservers to query instead of the domain's NS records, e.g. ns1.example.com,192.0.2.53:5353"},
+		{Name: "limit", Type: OptionInt, Default: 10000, Range: &OptionRange{Min: 1, Max: 1000000}, Description: "Most NSEC records followed or NSEC3 probes sent"},
+		{Name: "crack", Type: OptionBool, Default: true, Description: "Crack NSEC3 hashes against the subdomain wordlist"},
+		{Name: "wordlist", Type: OptionString, Default: zw.config.Wordlists.Subdomains, Description: "Path to the wordlist NSEC3 hashes are cracked against"},
+		{Name: "threads", Type: OptionInt, Default: 8, Range: &OptionRange{Min: 1, Max: 256}, Description: "Number of concurrent hash computations and address lookups"},
+		{Name: "timeout", Type: OptionInt, Default: 5, Range: &OptionRange{Min: 1, Max: 60}, Description: "DNS query timeout in seconds"},
+	}
+}
+
+// Execute finds out how the target zone proves that names do not exist.
+// An NSEC chain is followed from the apex to its end, listing every name
+// in the zone; for NSEC3, random names are queried until the hashes of all
+// names are collected, and the hashes are cracked against the wordlist.
+func (zw *ZoneWalker) Execute(ctx context.Context, target string, options Options) (*ScanResult, error) {
+	startTime := time.Now()
+	zw.logger.WithField("target", target).Info("Starting zone walk")
+
+	result := &ScanResult{
+		ModuleName: zw.GetName(),
+		Target:     target,
+		Status:     StatusRunning,
+		StartTime:  startTime.Format(time.RFC3339),
+		Metadata:   make(map[string]interface{}),
+	}
+	fail := func(err error) (*ScanResult, error) {
+		result.Status = StatusFailed
+		result.ErrorMessage = err.Error()
+		result.EndTime = time.Now().Format(time.RFC3339)
+		return result, err
+	}
+
+	zone := strings.ToLower(strings.TrimSuffix(target, "."))
+	timeout := time.Duration(options.Int("timeout")) * time.Second
+	limit := options.Int("limit")
+	threads := options.Int("threads")
+	pool, err := recordPool(ctx, zw.config, zw.logger)
+	if err != nil {
+		return fail(err)
+	}
+
+	walkPool := pool
+	if options.Bool("direct") {
+		var addresses []string
+		walkPool, addresses, err = zw.serverPool(ctx, pool, zone, options.String("nameservers"), timeout)
+		if err != nil {
+			return fail(err)
+		}
+		result.Metadata["nameservers"] = addresses
+	}
+
+	// Open the wordlist up front so that a bad path fails early
+	var words engine.Source[string]
+	size := 0
+	if options.Bool("crack") {
+		words, size, err = NewSubdomainEnumerator(zw.config, zw.logger).openWordlist(options.String("wordlist"))
+		if err != nil {
+			return fail(fmt.Errorf("failed to load wordlist: %v", err))
+		}
+	}
+
+	// The apex's own records tell how the zone is signed
+	nsecs, nsec3s, err := walkPool.Denial(ctx, zone, dns.TypeNSEC)
+	if err != nil {
+		return fail(fmt.Errorf("failed to query %s: %v", zone, err))
+	}
+	var apex *dns.NSEC
+	for _, nsec := range nsecs {
+		if nsec.Owner == zone {
+			apex = nsec
+		}
+	}
+
+	progress := newProgressReporter(ctx, zw.GetName(), target, limit)
+	var walk *ZoneWalkResult
+	var names []string
+	source := SourceNSEC
+	switch {
+	case apex != nil:
+		result.Metadata["dnssec"] = "NSEC"
+		records, steps, complete, stop := zw.walkNSEC(ctx, walkPool, zone, apex, limit, progress)
+		result.Metadata["queries"] = steps
+		result.Metadata["stop_reason"] = stop
+
+		// A chain that ends at the apex record leads nowhere
+		if !complete && len(records) < 2 {
+			break
+		}
+		for _, nsec := range records {
+			result.Results = append(result.Results, &DNSRecordResult{Record: dns.Record{Name: nsec.Owner, Type: "NSEC", Value: nsec.String()}})
+			names = append(names, nsec.Owner)
+		}
+		walk = &ZoneWalkResult{Zone: zone, Denial: "NSEC", Complete: complete}
+
+	case len(nsec3s) > 0:
+		result.Metadata["dnssec"] = "NSEC3"
+		source = SourceNSEC3
+		chain, probes, stop := zw.collectNSEC3(ctx, walkPool, zone, nsec3s, limit, progress)
+		result.Metadata["queries"] = probes
+		result.Metadata["stop_reason"] = stop
+		result.Metadata["nsec3_iterations"] = chain.params.Iterations
+		result.Metadata["nsec3_salt"] = hex.EncodeToString(chain.params.Salt)
+
+		cracked := make(map[string]string)
+		if words != nil && ctx.Err() == nil {
+			progress.Update(0, size)
+			cracked, err = zw.crackNSEC3(ctx, chain, zone, words, threads, progress)
+			if err != nil && ctx.Err() == nil {
+				return fail(fmt.Errorf("failed to read wordlist: %v", err))
+			}
+		}
+		hashes := chain.hashes()
+		for _, hash := range hashes {
+			result.Results = append(result.Results, chain.result(zone, hash, cracked[hash]))
+			if name, ok := cracked[hash]; ok {
+				names = append(names, name)
+			}
+		}
+		walk = &ZoneWalkResult{Zone: zone, Denial: "NSEC3", Hashes: len(hashes), Cracked: len(cracked), Complete: chain.complete()}
+		result.Metadata["nsec3_hashes"] = len(hashes)
+		result.Metadata["nsec3_cracked"] = len(cracked)
+
+	default:
+		result.Metadata["dnssec"] = "none"
+	}
+
+	subdomains := zw.subdomains(ctx, pool, zone, names, source, threads, timeout)
+	for _, subdomain := range subdomains {
+		result.Results = append(result.Results, subdomain)
+	}
+	if walk != nil {
+		walk.Names = len(subdomains)
+		result.Results = append([]interface{}{walk}, result.Results...)
+		result.Metadata["complete"] = walk.Complete
+		result.Metadata["severity"] = walk.Severity()
+	}
+
+	endTime := time.Now()
+	result.Status = StatusCompleted
+	result.EndTime = endTime.Format(time.RFC3339)
+	result.Metadata["subdomains"] = len(subdomains)
+	result.Metadata["duration_seconds"] = endTime.Sub(startTime).Seconds()
+
+	if err := ctx.Err(); err != nil {
+		result.Status = StatusCancelled
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+
+	zw.logger.WithFields(logrus.Fields{
+		"target":     target,
+		"dnssec":     result.Metadata["dnssec"],
+		"subdomains": len(subdomains),
+		"duration":   endTime.Sub(startTime),
+	}).Info("Zone walk completed")
+
+	return result, nil
+}
+
+// serverPool returns a pool of the zone's nameservers, or of the ones
+// given, together with their addresses. Nameservers that are out of scope
+// or cannot be resolved are skipped.
+func (zw *ZoneWalker) serverPool(ctx context.Context, pool *dns.Pool, zone, given string, timeout time.Duration) (*dns.Pool, []string, error) {
+	nameservers := splitList(given)
+	if len(nameservers) == 0 {
+		var err error
+		if nameservers, err = lookupNameservers(ctx, pool, zone, timeout); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	engagement := scope.FromContext(ctx)
+	var addresses []string
+	var lastErr error
+	for _, nameserver := range nameservers {
+		found, err := nameserverAddresses(ctx, pool, engagement, nameserver, timeout)
+		if err != nil {
+			zw.logger.WithFields(logrus.Fields{
+				"nameserver": nameserver,
+				"error":      err,
+			}).Warn("Skipping nameserver")
+			lastErr = fmt.Errorf("%s: %v", nameserver, err)
+			continue
+		}
+		addresses = append(addresses, found...)
+	}
+	if len(addresses) == 0 {
+		return nil, nil, fmt.Errorf("no usable nameservers: %v", lastErr)
+	}
+
+	// Nameservers only answer for their own zones, so unlike resolvers they
+	// are not health checked
+	servers, err := dns.NewPool(addresses, zw.config.Network.Retries, zw.logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	servers.Timeout = timeout
+	return servers, addresses, nil
+}
+
+// walkNSEC follows the NSEC chain of zone from the record of its apex to
+// the last record, which points back at the apex. It returns the records
+// found, the number of steps taken, whether the chain was followed to its
+// end and why the walk stopped.
+func (zw *ZoneWalker) walkNSEC(ctx context.Context, pool *dns.Pool, zone string, apex *dns.NSEC, limit int, progress *progressReporter) ([]*dns.NSEC, int, bool, string) {
+	var records []*dns.NSEC
+	seen := make(map[string]bool)
+	position := zone
+	current := apex
+	steps, failures := 0, 0
+	for {
+		if !seen[current.Owner] {
+			seen[current.Owner] = true
+			records = append(records, current)
+		}
+
+		next := current.Next
+		switch {
+		case next == zone || !strings.HasSuffix(next, "."+zone):
+			return records, steps, true, "chain complete"
+		case strings.HasPrefix(next, "\x00."):
+			// Servers that sign on the fly answer with records that only
+			// cover the name asked for
+			return records, steps, false, "zone is signed on the fly with minimally covering NSEC records"
+		case dns.CompareNames(next, position) <= 0:
+			return records, steps, false, fmt.Sprintf("chain does not advance past %s", position)
+		case steps >= limit:
+			return records, steps, false, "limit reached"
+		case ctx.Err() != nil:
+			return records, steps, false, ctx.Err().Error()
+		}
+
+		steps++
+		progress.Advance(1)
+		nsec, err := pool.NextNSEC(ctx, next)
+		if err != nil {
+			failures++
+			if failures >= maxWalkFailures || ctx.Err() != nil {
+				return records, steps, false, err.Error()
+			}
+			continue
+		}
+		failures = 0
+		position = next
+		current = nsec
+
+		zw.logger.WithFields(logrus.Fields{
+			"name": nsec.Owner,
+			"next": nsec.Next,
+		}).Debug("Followed NSEC record")
+	}
+}
+
+// collectNSEC3 queries random names below zone whose hashes fall between
+// the NSEC3 records collected so far, until every gap is closed. Names are
+// hashed locally first, so no query is spent on a gap that is known. It
+// returns the chain, the number of queries sent and why it stopped.
+func (zw *ZoneWalker) collectNSEC3(ctx context.Context, pool *dns.Pool, zone string, first []*dns.NSEC3, limit int, progress *progressReporter) (*nsec3Chain, int, string) {
+	chain := newNSEC3Chain(first[0])
+	for _, record := range first {
+		chain.add(record)
+	}
+
+	probes, failures := 0, 0
+	for !chain.complete() {
+		if probes >= limit {
+			return chain, probes, "limit reached"
+		}
+		if ctx.Err() != nil {
+			return chain, probes, ctx.Err().Error()
+		}
+		name, ok := chain.uncovered(zone)
+		if !ok {
+			return chain, probes, "no uncovered hashes found"
+		}
+
+		probes++
+		progress.Advance(1)
+		_, records, err := pool.Denial(ctx, name, dnsmessage.TypeA)
+		if err != nil {
+			failures++
+			if failures >= maxWalkFailures || ctx.Err() != nil {
+				return chain, probes, err.Error()
+			}
+			continue
+		}
+		failures = 0
+
+		added := 0
+		for _, record := range records {
+			if chain.add(record) {
+				added++
+			}
+		}
+		zw.logger.WithFields(logrus.Fields{
+			"probe":  probes,
+			"added":  added,
+			"hashes": len(chain.records),
+		}).Debug("Collected NSEC3 records")
+	}
+	return chain, probes, "chain complete"
+}
+
+// crackNSEC3 hashes every word of the wordlist as a name below zone, and
+// the zone itself, and returns the names whose hashes were collected, by
+// hash
+func (zw *ZoneWalker) crackNSEC3(ctx context.Context, chain *nsec3Chain, zone string, words engine.Source[string], threads int, progress *progressReporter) (map[string]string, error) {
+	targets := make(map[string]bool)
+	for _, hash := range chain.hashes() {
+		targets[hash] = true
+	}
+
+	cracked := make(map[string]string)
+	if hash := chain.params.HashName(zone); targets[hash] {
+		cracked[hash] = zone
+	}
+
+	job := engine.Run(ctx, threads, words, func(ctx context.Context, word string) (engine.Pair[string, string], bool) {
+		defer progress.Advance(1)
+		name := strings.ToLower(strings.TrimSuffix(word, ".")) + "." + zone
+		hash := chain.params.HashName(name)
+		return engine.Pair[string, string]{First: hash, Second: name}, targets[hash]
+	})
+	err := job.Collect(func(match engine.Pair[string, string]) {
+		cracked[match.First] = match.Second
+		zw.logger.WithFields(logrus.Fields{
+			"hash": match.First,
+			"name": match.Second,
+		}).Debug("Cracked NSEC3 hash")
+	})
+	return cracked, err
+}
+
+// subdomains resolves the names found below zone and returns them as
+// subdomain findings of source. Wildcards, service names and the apex are
+// left out.
+func (zw *ZoneWalker) subdomains(ctx context.Context, pool *dns.Pool, zone string, names []string, source string, threads int, timeout time.Duration) []*SubdomainResult {
+	var hosts []string
+	for _, name := range names {
+		if strings.HasSuffix(name, "."+zone) && validRelativeName(strings.TrimSuffix(name, "."+zone)) {
+			hosts = append(hosts, name)
+		}
+	}
+
+	engagement := scope.FromContext(ctx)
+	job := engine.Run(ctx, threads, engine.Slice(hosts), func(ctx context.Context, name string) (*SubdomainResult, bool) {
+		lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+		ips, _ := pool.LookupIPAddr(lookupCtx, name)
+		cancel()
+
+		var ipStrings []string
+		for _, ip := range ips {
+			ipStrings = append(ipStrings, ip.IP.String())
+		}
+		return &SubdomainResult{
+			Subdomain:  name,
+			IPs:        ipStrings,
+			Resolved:   len(ips) > 0,
+			OutOfScope: !inScope(engagement, name, ips),
+			Sources:    []string{source},
+		}, ctx.Err() == nil
+	})
+
+	var subdomains []*SubdomainResult
+	job.Collect(func(subdomain *SubdomainResult) {
+		subdomains = append(subdomains, subdomain)
+	})
+	sort.Slice(subdomains, func(i, j int) bool {
+		return dns.CompareNames(subdomains[i].Subdomain, subdomains[j].Subdomain) < 0
+	})
+	linkParents(zone, subdomains)
+	return subdomains
+}
+
+// nsec3Chain collects the NSEC3 records of a zone that are hashed with the
+// same parameters
+type nsec3Chain struct {
+	params  *dns.NSEC3
+	records map[string]*dns.NSEC3
+	// owners are the hashes of the records, sorted
+	owners []string
+}
+
+// newNSEC3Chain creates a chain for records hashed like params
+func newNSEC3Chain(params *dns.NSEC3) *nsec3Chain {
+	return &nsec3Chain{params: params, records: make(map[string]*dns.NSEC3)}
+}
+
+// add adds a record and reports whether it was new. Records hashed with
+// other parameters, left over while a zone is being re-salted, are
+// ignored.
+func (c *nsec3Chain) add(record *dns.NSEC3) bool {
+	if _, ok := c.records[record.Hash]; ok || !record.SameParameters(c.params) {
+		return false
+	}
+	c.records[record.Hash] = record
+	i := sort.SearchStrings(c.owners, record.Hash)
+	c.owners = append(c.owners, "")
+	copy(c.owners[i+1:], c.owners[i:])
+	c.owners[i] = record.Hash
+	return true
+}
+
+// covered reports whether a record is known for the gap hash falls into
+func (c *nsec3Chain) covered(hash string) bool {
+	if _, ok := c.records[hash]; ok {
+		return true
+	}
+	if len(c.owners) == 0 {
+		return false
+	}
+	// The record with the closest hash below, or the last one, whose gap
+	// wraps around
+	i := sort.SearchStrings(c.owners, hash) - 1
+	if i < 0 {
+		i = len(c.owners) - 1
+	}
+	return c.records[c.owners[i]].Covers(hash)
+}
+
+// complete reports whether the record of every hash in the chain is known
+func (c *nsec3Chain) complete() bool {
+	for _, record := range c.records {
+		if _, ok := c.records[record.Next]; !ok {
+			return false
+		}
+	}
+	return len(c.records) > 0
+}
+
+// uncovered returns a random name below zone whose hash falls into a gap
+// no record is known for, or false if none was found
+func (c *nsec3Chain) uncovered(zone string) (string, bool) {
+	for i := 0; i < maxProbeTries; i++ {
+		name := randomLabel() + "." + zone
+		if !c.covered(c.params.HashName(name)) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// hashes returns the hashes of all names known, including those only seen
+// as the next hash of a record, sorted
+func (c *nsec3Chain) hashes() []string {
+	hashes := append([]string(nil), c.owners...)
+	for _, record := range c.records {
+		if _, ok := c.records[record.Next]; !ok {
+			hashes = appendUnique(hashes, record.Next)
+		}
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// result returns the finding for a hash, cracked to name if it was
+func (c *nsec3Chain) result(zone, hash, name string) *NSEC3HashResult {
+	hr := &NSEC3HashResult{
+		Zone:       zone,
+		Hash:       hash,
+		Algorithm:  c.params.Algorithm,
+		Iterations: c.params.Iterations,
+		Salt:       hex.EncodeToString(c.params.Salt),
+		Name:       name,
+	}
+	if record, ok := c.records[hash]; ok {
+		hr.Next = record.Next
+		hr.Types = record.Types
+		hr.OptOut = record.OptOut
+	}
+	return hr
+}
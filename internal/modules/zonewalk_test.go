@@ -0,0 +1,229 @@
+package modules
+
+import (
+	"GoReconX/internal/dns"
+	"context"
+	"encoding/base32"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// aRRSIGBitmap is the NSEC type bitmap of A RRSIG
+var aRRSIGBitmap = []byte{0x00, 0x06, 0x40, 0x00, 0x00, 0x00, 0x00, 0x02}
+
+// wireName returns name in uncompressed wire form
+func wireName(name string) []byte {
+	var wire []byte
+	for _, label := range strings.Split(name, ".") {
+		wire = append(wire, byte(len(label)))
+		wire = append(wire, label...)
+	}
+	return append(wire, 0)
+}
+
+// denial builds the answer to query carrying a denial record, in the
+// answer section if it is the record of the name asked for and in the
+// authority section of an NXDOMAIN answer otherwise
+func denial(query *dnsmessage.Message, owner string, qtype dnsmessage.Type, data []byte) *dnsmessage.Message {
+	rr := dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(owner + "."), Type: qtype, Class: dnsmessage.ClassINET, TTL: 60},
+		Body:   &dnsmessage.UnknownResource{Type: qtype, Data: data},
+	}
+	answer := &dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: query.ID, Response: true, RCode: dnsmessage.RCodeNameError},
+		Questions: query.Questions,
+	}
+	if strings.TrimSuffix(query.Questions[0].Name.String(), ".") == owner {
+		answer.RCode = dnsmessage.RCodeSuccess
+		answer.Answers = append(answer.Answers, rr)
+	} else {
+		answer.Authorities = append(answer.Authorities, rr)
+	}
+	return answer
+}
+
+// startNSECZone starts a nameserver answering from the NSEC chain of a
+// zone
+func startNSECZone(t *testing.T, chain []*dns.NSEC) *dns.Pool {
+	return startNameserver(t, func(query *dnsmessage.Message) *dnsmessage.Message {
+		name := strings.TrimSuffix(query.Questions[0].Name.String(), ".")
+		for _, record := range chain {
+			if record.Owner == name || record.Covers(name) {
+				return denial(query, record.Owner, dns.TypeNSEC, append(wireName(record.Next), aRRSIGBitmap...))
+			}
+		}
+		return denial(query, "", dns.TypeNSEC, nil)
+	})
+}
+
+// startNSEC3Zone starts a nameserver answering from the NSEC3 chain of a
+// zone, with the record matching or covering the hash of the name asked
+// for
+func startNSEC3Zone(t *testing.T, chain []*dns.NSEC3) *dns.Pool {
+	return startNameserver(t, func(query *dnsmessage.Message) *dnsmessage.Message {
+		hash := chain[0].HashName(strings.TrimSuffix(query.Questions[0].Name.String(), "."))
+		for _, record := range chain {
+			if record.Hash != hash && !record.Covers(hash) {
+				continue
+			}
+			next, err := base32.HexEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(record.Next))
+			if err != nil {
+				t.Errorf("decode %s: %v", record.Next, err)
+				return nil
+			}
+			data := []byte{record.Algorithm, 0, byte(record.Iterations >> 8), byte(record.Iterations), byte(len(record.Salt))}
+			data = append(data, record.Salt...)
+			data = append(data, byte(len(next)))
+			data = append(data, next...)
+			data = append(data, aRRSIGBitmap...)
+			return denial(query, record.Owner, dns.TypeNSEC3, data)
+		}
+		return nil
+	})
+}
+
+// refusingNameserver starts a nameserver refusing every query
+func refusingNameserver(t *testing.T) *dns.Pool {
+	return startNameserver(t, func(query *dnsmessage.Message) *dnsmessage.Message {
+		return &dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: query.ID, Response: true, RCode: dnsmessage.RCodeRefused},
+			Questions: query.Questions,
+		}
+	})
+}
+
+func TestWalkNSEC(t *testing.T) {
+	chain := []*dns.NSEC{
+		{Owner: "example.com", Next: "a.example.com"},
+		{Owner: "a.example.com", Next: "mail.example.com"},
+		{Owner: "mail.example.com", Next: "x.www.example.com"},
+		{Owner: "x.www.example.com", Next: "example.com"},
+	}
+
+	tests := []struct {
+		name         string
+		pool         *dns.Pool
+		limit        int
+		wantRecords  int
+		wantSteps    int
+		wantComplete bool
+		wantReason   string
+	}{
+		{
+			name:         "complete chain",
+			pool:         startNSECZone(t, chain),
+			limit:        100,
+			wantRecords:  4,
+			wantSteps:    3,
+			wantComplete: true,
+			wantReason:   "chain complete",
+		},
+		{
+			name:        "limit",
+			pool:        startNSECZone(t, chain),
+			limit:       2,
+			wantRecords: 3,
+			wantSteps:   2,
+			wantReason:  "limit reached",
+		},
+		{
+			name: "minimally covering records",
+			pool: startNSECZone(t, []*dns.NSEC{
+				{Owner: "a.example.com", Next: "\x00.a.example.com"},
+			}),
+			limit:       100,
+			wantRecords: 2,
+			wantSteps:   1,
+			wantReason:  "minimally covering",
+		},
+		{
+			name: "chain going back",
+			pool: startNSECZone(t, []*dns.NSEC{
+				{Owner: "a.example.com", Next: "mail.example.com"},
+				{Owner: "mail.example.com", Next: "a.example.com"},
+			}),
+			limit:       100,
+			wantRecords: 3,
+			wantSteps:   2,
+			wantReason:  "does not advance past mail.example.com",
+		},
+		{
+			name:        "failing nameserver",
+			pool:        refusingNameserver(t),
+			limit:       100,
+			wantRecords: 1,
+			wantSteps:   maxWalkFailures,
+			wantReason:  "answered RCodeRefused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zw := NewZoneWalker(nil, quietLogger())
+			progress := newProgressReporter(context.Background(), zw.GetName(), "example.com", 0)
+			records, steps, complete, reason := zw.walkNSEC(context.Background(), tt.pool, "example.com", chain[0], tt.limit, progress)
+
+			if len(records) != tt.wantRecords || steps != tt.wantSteps || complete != tt.wantComplete {
+				t.Errorf("%d records in %d steps, complete %v, want %d in %d, %v", len(records), steps, complete, tt.wantRecords, tt.wantSteps, tt.wantComplete)
+			}
+			if !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("reason = %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCollectNSEC3(t *testing.T) {
+	params := &dns.NSEC3{Algorithm: dns.NSEC3SHA1, Iterations: 2, Salt: []byte{0xab, 0xcd}}
+	var hashes []string
+	for _, name := range []string{"example.com", "a.example.com", "mail.example.com", "www.example.com", "vpn.example.com"} {
+		hashes = append(hashes, params.HashName(name))
+	}
+	sort.Strings(hashes)
+	var chain []*dns.NSEC3
+	for i, hash := range hashes {
+		record := *params
+		record.Owner = hash + ".example.com"
+		record.Hash = hash
+		record.Next = hashes[(i+1)%len(hashes)]
+		chain = append(chain, &record)
+	}
+
+	tests := []struct {
+		name        string
+		pool        *dns.Pool
+		limit       int
+		wantRecords int
+		wantProbes  int
+		wantReason  string
+	}{
+		{name: "complete chain", pool: startNSEC3Zone(t, chain), limit: 1000, wantRecords: len(chain), wantReason: "chain complete"},
+		// Every probe is sent into a gap no record is known for
+		{name: "limit", pool: startNSEC3Zone(t, chain), limit: 2, wantRecords: 3, wantProbes: 2, wantReason: "limit reached"},
+		{name: "failing nameserver", pool: refusingNameserver(t), limit: 1000, wantRecords: 1, wantProbes: maxWalkFailures, wantReason: "answered RCodeRefused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zw := NewZoneWalker(nil, quietLogger())
+			progress := newProgressReporter(context.Background(), zw.GetName(), "example.com", 0)
+			collected, probes, reason := zw.collectNSEC3(context.Background(), tt.pool, "example.com", chain[:1], tt.limit, progress)
+
+			if len(collected.records) != tt.wantRecords {
+				t.Errorf("collected %d records, want %d", len(collected.records), tt.wantRecords)
+			}
+			if tt.wantProbes != 0 && probes != tt.wantProbes {
+				t.Errorf("sent %d probes, want %d", probes, tt.wantProbes)
+			}
+			if !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("reason = %q, want %q", reason, tt.wantReason)
+			}
+			if tt.wantReason == "chain complete" {
+				if !collected.complete() || strings.Join(collected.hashes(), " ") != strings.Join(hashes, " ") {
+					t.Errorf("hashes = %v, want %v", collected.hashes(), hashes)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package pipeline
+
+import (
+	"GoReconX/internal/modules"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// InputTargets is the input name referring to the targets a pipeline is
+// run against
+const InputTargets = "targets"
+
+// Pipeline is a declarative recon workflow. Each stage runs a module
+// against targets extracted from the findings of earlier stages:
+//
+//	name: web-surface
+//	stages:
+//	  - name: subdomains
+//	    module: subdomain_enumeration
+//	  - name: ports
+//	    module: port_scanning
+//	    extract: ips
+//	    filter:
+//	      - field: out_of_scope
+//	        not: true
+type Pipeline struct {
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Stages      []*Stage `yaml:"stages" json:"stages"`
+}
+
+// Stage is a single step of a pipeline
+type Stage struct {
+	Name string `yaml:"name" json:"name"`
+	// Module to run; a stage without a module passes its targets on as
+	// they are, which is useful to select and reshape findings
+	Module string `yaml:"module,omitempty" json:"module,omitempty"`
+	// Input names the stages whose findings become this stage's targets,
+	// or "targets" for the pipeline's own targets. Defaults to the
+	// previous stage, or "targets" for the first stage.
+	Input Inputs `yaml:"input,omitempty" json:"input,omitempty"`
+	// Filter keeps only findings that match every filter
+	Filter []*Filter `yaml:"filter,omitempty" json:"filter,omitempty"`
+	// Extract is the finding field holding the next targets, e.g. "ips",
+	// or a template such as "https://{target}:{port}". Defaults to
+	// "target", the target of the scan that produced the finding.
+	Extract string                 `yaml:"extract,omitempty" json:"extract,omitempty"`
+	Options map[string]interface{} `yaml:"options,omitempty" json:"options,omitempty"`
+}
+
+// Inputs is a list of stage names that may be written as a single name
+type Inputs []string
+
+// UnmarshalYAML accepts both "input: ports" and "input: [http, https]"
+func (in *Inputs) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single string
+	if err := unmarshal(&single); err == nil {
+		*in = Inputs{single}
+		return nil
+	}
+
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*in = list
+	return nil
+}
+
+// Filter matches findings by one of their fields. Nested fields are
+// addressed with dots, e.g. "data.subdomain". Without a condition the
+// field must be set to a value other than false, zero or empty. Fields
+// holding lists match when any element matches.
+type Filter struct {
+	Field   string        `yaml:"field" json:"field"`
+	Equals  interface{}   `yaml:"equals,omitempty" json:"equals,omitempty"`
+	In      []interface{} `yaml:"in,omitempty" json:"in,omitempty"`
+	Matches string        `yaml:"matches,omitempty" json:"matches,omitempty"`
+	// Not inverts the filter
+	Not bool `yaml:"not,omitempty" json:"not,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Load reads a pipeline definition from a YAML file
+func Load(path string) (*Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses a YAML pipeline definition
+func Parse(data []byte) (*Pipeline, error) {
+	p := &Pipeline{}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("invalid pipeline: %v", err)
+	}
+
+	// YAML decodes nested maps with interface{} keys, which the options
+	// cannot be validated or stored as JSON with
+	for _, stage := range p.Stages {
+		for name, value := range stage.Options {
+			stage.Options[name] = normalize(value)
+		}
+	}
+	return p, nil
+}
+
+// Validate checks the pipeline against the modules available in mm and
+// fills in default inputs
+func (p *Pipeline) Validate(mm *modules.ModuleManager) error {
+	if p.Name == "" {
+		return fmt.Errorf("pipeline needs a name")
+	}
+	if len(p.Stages) == 0 {
+		return fmt.Errorf("pipeline %s has no stages", p.Name)
+	}
+
+	seen := map[string]bool{InputTargets: true}
+	for i, stage := range p.Stages {
+		if stage.Name == "" {
+			return fmt.Errorf("stage %d needs a name", i+1)
+		}
+		if seen[stage.Name] {
+			return fmt.Errorf("duplicate stage name %s", stage.Name)
+		}
+
+		if len(stage.Input) == 0 {
+			if i == 0 {
+				stage.Input = Inputs{InputTargets}
+			} else {
+				stage.Input = Inputs{p.Stages[i-1].Name}
+			}
+		}
+		for _, input := range stage.Input {
+			if !seen[input] {
+				return fmt.Errorf("stage %s: input %s is not an earlier stage", stage.Name, input)
+			}
+		}
+		seen[stage.Name] = true
+
+		for _, filter := range stage.Filter {
+			if err := filter.compile(); err != nil {
+				return fmt.Errorf("stage %s: %v", stage.Name, err)
+			}
+		}
+
+		if stage.Module == "" {
+			if len(stage.Options) > 0 {
+				return fmt.Errorf("stage %s: options need a module", stage.Name)
+			}
+			continue
+		}
+		if _, ok := mm.GetModuleInfo(stage.Module); !ok {
+			return fmt.Errorf("stage %s: module not found: %s", stage.Name, stage.Module)
+		}
+		if _, err := mm.OptionSchema(stage.Module).Validate(stage.Options); err != nil {
+			return fmt.Errorf("stage %s: %v", stage.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// compile prepares the filter's pattern
+func (f *Filter) compile() error {
+	if f.Field == "" {
+		return fmt.Errorf("filter needs a field")
+	}
+	if f.Matches != "" {
+		pattern, err := regexp.Compile(f.Matches)
+		if err != nil {
+			return fmt.Errorf("filter on %s: %v", f.Field, err)
+		}
+		f.pattern = pattern
+	}
+	return nil
+}
+
+// match reports whether the finding passes the filter
+func (f *Filter) match(finding map[string]interface{}) bool {
+	matched := false
+	for _, value := range values(lookup(finding, f.Field)) {
+		if f.matchValue(value) {
+			matched = true
+			break
+		}
+	}
+	return matched != f.Not
+}
+
+// matchValue checks a single value against the filter's condition
+func (f *Filter) matchValue(value interface{}) bool {
+	switch {
+	case f.Equals != nil:
+		return format(value) == format(f.Equals)
+	case len(f.In) > 0:
+		for _, candidate := range f.In {
+			if format(value) == format(candidate) {
+				return true
+			}
+		}
+		return false
+	case f.pattern != nil:
+		return f.pattern.MatchString(format(value))
+	default:
+		switch v := value.(type) {
+		case nil:
+			return false
+		case bool:
+			return v
+		case float64:
+			return v != 0
+		case string:
+			return v != ""
+		}
+		return true
+	}
+}
+
+// extract returns the targets a finding yields for the stage
+func (s *Stage) extract(finding map[string]interface{}) []string {
+	extract := s.Extract
+	if extract == "" {
+		extract = "target"
+	}
+
+	if !strings.Contains(extract, "{") {
+		var targets []string
+		for _, value := range values(lookup(finding, extract)) {
+			if target := format(value); target != "" {
+				targets = append(targets, target)
+			}
+		}
+		return targets
+	}
+
+	// Fill in a template; findings missing a field yield nothing
+	missing := false
+	target := placeholder.ReplaceAllStringFunc(extract, func(match string) string {
+		value := format(lookup(finding, match[1:len(match)-1]))
+		if value == "" {
+			missing = true
+		}
+		return value
+	})
+	if missing {
+		return nil
+	}
+	return []string{target}
+}
+
+// placeholder matches the {field} placeholders of extract templates
+var placeholder = regexp.MustCompile(`\{[a-zA-Z0-9_.]+\}`)
+
+// lookup returns a field of a finding. Nested fields are addressed with
+// dots, e.g. "data.subdomain" for the findings of plugins.
+func lookup(finding map[string]interface{}, field string) interface{} {
+	var value interface{} = finding
+	for _, key := range strings.Split(field, ".") {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = fields[key]
+	}
+	return value
+}
+
+// values returns the elements of a list, or the value itself otherwise
+func values(value interface{}) []interface{} {
+	if list, ok := value.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{value}
+}
+
+// format renders a scalar finding value as a target or for comparison.
+// Numbers decoded from JSON are floats, so 80 and 80.0 format the same.
+func format(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64, float32, int, int64, bool:
+		return fmt.Sprint(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// normalize converts maps decoded from YAML to map[string]interface{}
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = normalize(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
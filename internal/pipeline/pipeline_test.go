@@ -0,0 +1,247 @@
+package pipeline
+
+import (
+	"GoReconX/internal/config"
+	"GoReconX/internal/modules"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newModuleManager(t *testing.T) *modules.ModuleManager {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return modules.NewModuleManager(nil, config.DefaultConfig(), logger)
+}
+
+func TestExamplesValidate(t *testing.T) {
+	paths, err := filepath.Glob("../../examples/pipelines/*.yaml")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no example pipelines found: %v", err)
+	}
+
+	mm := newModuleManager(t)
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			p, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if err := p.Validate(mm); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			// Every stage reads from the targets or an earlier stage
+			for i, stage := range p.Stages {
+				if len(stage.Input) == 0 {
+					t.Errorf("stage %d (%s) has no input after validation", i+1, stage.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateInputs(t *testing.T) {
+	p, err := Parse([]byte(`
+name: inputs
+stages:
+  - name: subdomains
+    module: subdomain_enumeration
+  - name: ports
+    module: port_scanning
+    extract: ips
+  - name: http
+    input: ports
+    extract: "http://{target}:{port}"
+  - name: web
+    module: web_analysis
+    input: [ports, http]
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := p.Validate(newModuleManager(t)); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	want := []Inputs{{InputTargets}, {"subdomains"}, {"ports"}, {"ports", "http"}}
+	for i, stage := range p.Stages {
+		if !reflect.DeepEqual(stage.Input, want[i]) {
+			t.Errorf("stage %s: input = %v, want %v", stage.Name, stage.Input, want[i])
+		}
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name:    "no name",
+			yaml:    "stages:\n  - name: a\n    module: subdomain_enumeration\n",
+			wantErr: "pipeline needs a name",
+		},
+		{
+			name:    "no stages",
+			yaml:    "name: empty\n",
+			wantErr: "has no stages",
+		},
+		{
+			name:    "unknown module",
+			yaml:    "name: p\nstages:\n  - name: a\n    module: no_such_module\n",
+			wantErr: "module not found: no_such_module",
+		},
+		{
+			name:    "duplicate stage",
+			yaml:    "name: p\nstages:\n  - name: a\n  - name: a\n",
+			wantErr: "duplicate stage name a",
+		},
+		{
+			name:    "later input",
+			yaml:    "name: p\nstages:\n  - name: a\n    input: b\n  - name: b\n",
+			wantErr: "input b is not an earlier stage",
+		},
+		{
+			name:    "bad filter pattern",
+			yaml:    "name: p\nstages:\n  - name: a\n    filter:\n      - field: subdomain\n        matches: \"(unclosed\"\n",
+			wantErr: "filter on subdomain",
+		},
+		{
+			name:    "filter without a field",
+			yaml:    "name: p\nstages:\n  - name: a\n    filter:\n      - equals: 80\n",
+			wantErr: "filter needs a field",
+		},
+		{
+			name:    "options without a module",
+			yaml:    "name: p\nstages:\n  - name: a\n    options:\n      threads: 5\n",
+			wantErr: "options need a module",
+		},
+		{
+			name:    "invalid option",
+			yaml:    "name: p\nstages:\n  - name: a\n    module: subdomain_enumeration\n    options:\n      threads: many\n",
+			wantErr: "stage a:",
+		},
+	}
+
+	mm := newModuleManager(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			err = p.Validate(mm)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := Parse([]byte("name: [unclosed")); err == nil || !strings.Contains(err.Error(), "invalid pipeline") {
+		t.Errorf("Parse of broken YAML: err = %v", err)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	subdomain := findingItem("example.com", &modules.SubdomainResult{
+		Subdomain:  "shop.example.com",
+		IPs:        []string{"192.0.2.1", "192.0.2.2"},
+		Resolved:   true,
+		CNAMEChain: []string{"shop.example.net"},
+	})
+	outOfScope := findingItem("example.com", &modules.SubdomainResult{
+		Subdomain:  "vpn.example.com",
+		IPs:        []string{"198.51.100.1"},
+		Resolved:   true,
+		OutOfScope: true,
+	})
+	port := findingItem("192.0.2.1", &modules.PortResult{Port: 8080, Protocol: "tcp", State: modules.PortOpen, Service: "http"})
+	takeover := findingItem("shop.example.com", &modules.TakeoverResult{
+		Subdomain:  "shop.example.com",
+		CNAMEChain: []string{"shop.example.net", "gone.herokuapp.com"},
+		Service:    "Heroku",
+		Status:     modules.TakeoverVulnerable,
+		Evidence:   []string{"NXDOMAIN"},
+	})
+
+	tests := []struct {
+		name    string
+		filter  Filter
+		finding map[string]interface{}
+		want    bool
+	}{
+		{"set list", Filter{Field: "cname_chain"}, subdomain, true},
+		{"unset list", Filter{Field: "cname_chain"}, outOfScope, false},
+		{"true flag", Filter{Field: "out_of_scope"}, outOfScope, true},
+		{"omitted flag", Filter{Field: "out_of_scope"}, subdomain, false},
+		{"not omitted flag", Filter{Field: "out_of_scope", Not: true}, subdomain, true},
+		{"not true flag", Filter{Field: "out_of_scope", Not: true}, outOfScope, false},
+		{"missing field", Filter{Field: "no_such_field"}, subdomain, false},
+		{"equals in a list", Filter{Field: "ips", Equals: "192.0.2.2"}, subdomain, true},
+		{"equals nothing in a list", Filter{Field: "ips", Equals: "192.0.2.9"}, subdomain, false},
+		{"number equals", Filter{Field: "port", Equals: 8080}, port, true},
+		{"number in", Filter{Field: "port", In: []interface{}{80, 8000, 8080}}, port, true},
+		{"number not in", Filter{Field: "port", In: []interface{}{443, 8443}}, port, false},
+		{"string in", Filter{Field: "status", In: []interface{}{modules.TakeoverVulnerable, modules.TakeoverLikely}}, takeover, true},
+		{"matches any of a list", Filter{Field: "cname_chain", Matches: `\.herokuapp\.com$`}, takeover, true},
+		{"matches the target", Filter{Field: "target", Matches: `^192\.0\.2\.`}, port, true},
+		{"matches not", Filter{Field: "service", Matches: "^Heroku$", Not: true}, takeover, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			if err := filter.compile(); err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+			if got := filter.match(tt.finding); got != tt.want {
+				t.Errorf("match(%v) = %v, want %v", tt.finding, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStageExtract(t *testing.T) {
+	subdomain := findingItem("example.com", &modules.SubdomainResult{
+		Subdomain: "shop.example.com",
+		IPs:       []string{"192.0.2.1", "192.0.2.2"},
+		Resolved:  true,
+	})
+	port := findingItem("192.0.2.1", &modules.PortResult{Port: 443, Protocol: "tcp", State: modules.PortOpen, Service: "https"})
+	takeover := findingItem("shop.example.com", &modules.TakeoverResult{
+		Subdomain:  "shop.example.com",
+		CNAMEChain: []string{"gone.herokuapp.com"},
+		Status:     modules.TakeoverLikely,
+	})
+	plugin := map[string]interface{}{"target": "example.com", "data": map[string]interface{}{"subdomain": "api.example.com"}}
+
+	tests := []struct {
+		name    string
+		extract string
+		finding map[string]interface{}
+		want    []string
+	}{
+		{"default target", "", port, []string{"192.0.2.1"}},
+		{"field", "subdomain", subdomain, []string{"shop.example.com"}},
+		{"list", "ips", subdomain, []string{"192.0.2.1", "192.0.2.2"}},
+		{"number", "port", port, []string{"443"}},
+		{"missing field", "ips", port, nil},
+		{"template", "https://{target}:{port}", port, []string{"https://192.0.2.1:443"}},
+		{"template missing a field", "https://{subdomain}:{port}", port, nil},
+		{"takeover chain", "cname_chain", takeover, []string{"gone.herokuapp.com"}},
+		{"nested field", "data.subdomain", plugin, []string{"api.example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage := &Stage{Name: "test", Extract: tt.extract}
+			if got := stage.extract(tt.finding); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extract(%v) = %q, want %q", tt.finding, got, tt.want)
+			}
+		})
+	}
+}
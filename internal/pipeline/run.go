@@ -0,0 +1,203 @@
+package pipeline
+
+import (
+	"GoReconX/internal/engine"
+	"GoReconX/internal/modules"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// JobType identifies pipeline runs in the jobs table
+const JobType = "pipeline"
+
+// Result is the outcome of a pipeline run
+type Result struct {
+	JobID     int            `json:"job_id,omitempty"`
+	Pipeline  string         `json:"pipeline"`
+	Status    string         `json:"status"`
+	Stages    []*StageResult `json:"stages"`
+	StartTime string         `json:"start_time"`
+	EndTime   string         `json:"end_time"`
+}
+
+// StageResult is the outcome of a single stage
+type StageResult struct {
+	Name     string   `json:"name"`
+	Module   string   `json:"module,omitempty"`
+	Targets  []string `json:"targets"`
+	Findings int      `json:"findings"`
+	// Results of the stage's scans, stored with each scan rather than
+	// with the job
+	Results []*modules.ScanResult `json:"-"`
+
+	// findings flattened to their JSON fields, for the stages that use
+	// this one as input
+	items []map[string]interface{}
+}
+
+// ScanResults returns the results of every scan of the run, stage by stage
+func (r *Result) ScanResults() []*modules.ScanResult {
+	var results []*modules.ScanResult
+	for _, stage := range r.Stages {
+		results = append(results, stage.Results...)
+	}
+	return results
+}
+
+// Run executes the pipeline against targets through mm and records it as a
+// single job. Stages run one after another; the targets of each stage run
+// in parallel. Cancelling ctx stops the run and keeps what was found.
+func Run(ctx context.Context, mm *modules.ModuleManager, p *Pipeline, targets []string) (*Result, error) {
+	if err := p.Validate(mm); err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Pipeline:  p.Name,
+		Status:    modules.StatusRunning,
+		StartTime: time.Now().Format(time.RFC3339),
+	}
+	logger := mm.Logger.WithField("pipeline", p.Name)
+
+	if mm.DB != nil {
+		definition, _ := yaml.Marshal(p)
+		targetList, _ := json.Marshal(targets)
+		job, err := mm.DB.CreateJob(mm.ProjectID, JobType, p.Name, string(definition), string(targetList))
+		if err != nil {
+			return nil, fmt.Errorf("failed to record job: %v", err)
+		}
+		result.JobID = job.ID
+		ctx = modules.WithJob(ctx, job.ID)
+		saveJob(mm, logger, result, "")
+	}
+
+	logger.WithField("targets", len(targets)).Info("Starting pipeline")
+
+	stages := map[string]*StageResult{
+		InputTargets: {Name: InputTargets, items: targetItems(targets)},
+	}
+
+	var runErr error
+	for _, stage := range p.Stages {
+		stageResult := &StageResult{Name: stage.Name, Module: stage.Module}
+		stageResult.Targets = stage.targets(stages)
+		result.Stages = append(result.Stages, stageResult)
+		stages[stage.Name] = stageResult
+
+		stageLogger := logger.WithFields(logrus.Fields{"stage": stage.Name, "targets": len(stageResult.Targets)})
+		if stage.Module == "" {
+			stageResult.items = targetItems(stageResult.Targets)
+			stageLogger.Debug("Selected pipeline targets")
+			continue
+		}
+		if len(stageResult.Targets) == 0 {
+			stageLogger.Info("Skipping pipeline stage without targets")
+			continue
+		}
+
+		stageLogger.Info("Running pipeline stage")
+		scans, err := mm.ExecuteTargets(ctx, stage.Module, engine.Slice(stageResult.Targets), stage.Options)
+		stageResult.Results = scans.Ordered()
+		for _, scan := range stageResult.Results {
+			for _, finding := range scan.Results {
+				stageResult.items = append(stageResult.items, findingItem(scan.Target, finding))
+			}
+		}
+		stageResult.Findings = len(stageResult.items)
+		saveJob(mm, logger, result, "")
+
+		if err != nil {
+			runErr = err
+			break
+		}
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		result.Status = modules.StatusCancelled
+		logger.Warn("Pipeline cancelled")
+	case runErr != nil:
+		result.Status = modules.StatusFailed
+		logger.WithError(runErr).Error("Pipeline failed")
+	default:
+		result.Status = modules.StatusCompleted
+		logger.Info("Pipeline completed")
+	}
+	result.EndTime = time.Now().Format(time.RFC3339)
+
+	message := ""
+	if runErr != nil {
+		message = runErr.Error()
+	}
+	saveJob(mm, logger, result, message)
+
+	return result, runErr
+}
+
+// targets collects the deduplicated targets of a stage from the findings
+// of its inputs
+func (s *Stage) targets(stages map[string]*StageResult) []string {
+	var targets []string
+	seen := make(map[string]bool)
+
+	for _, input := range s.Input {
+	findings:
+		for _, finding := range stages[input].items {
+			for _, filter := range s.Filter {
+				if !filter.match(finding) {
+					continue findings
+				}
+			}
+			for _, target := range s.extract(finding) {
+				if !seen[target] {
+					seen[target] = true
+					targets = append(targets, target)
+				}
+			}
+		}
+	}
+
+	return targets
+}
+
+// targetItems turns plain targets into findings with a single field
+func targetItems(targets []string) []map[string]interface{} {
+	items := make([]map[string]interface{}, 0, len(targets))
+	for _, target := range targets {
+		items = append(items, map[string]interface{}{"target": target})
+	}
+	return items
+}
+
+// findingItem flattens a finding to its JSON fields and adds the target of
+// the scan that produced it as "target"
+func findingItem(target string, finding interface{}) map[string]interface{} {
+	item := make(map[string]interface{})
+	if data, err := json.Marshal(finding); err == nil {
+		if err := json.Unmarshal(data, &item); err != nil {
+			item = map[string]interface{}{"value": finding}
+		}
+	}
+	item["target"] = target
+	return item
+}
+
+// saveJob stores the current state of the run with its job
+func saveJob(mm *modules.ModuleManager, logger *logrus.Entry, result *Result, message string) {
+	if result.JobID == 0 {
+		return
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to encode pipeline result")
+	}
+	if err := mm.DB.UpdateJobStatus(result.JobID, result.Status, string(data), message); err != nil {
+		logger.WithError(err).Warn("Failed to update job status")
+	}
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"GoReconX/internal/config"
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Limiter paces the requests modules send. Every request counts against the
+// global rate and against the rate of the host or resolver it goes to, and
+// is delayed by a random jitter. A nil *Limiter does not limit anything.
+type Limiter struct {
+	settings config.RateLimitConfig
+	global   *bucket
+
+	mu        sync.Mutex
+	hosts     map[string]*bucket
+	resolvers map[string]*bucket
+}
+
+// New creates a limiter from the rate limit settings
+func New(settings config.RateLimitConfig) *Limiter {
+	return &Limiter{
+		settings:  settings,
+		global:    newBucket(settings.GlobalRPS, settings.Burst),
+		hosts:     make(map[string]*bucket),
+		resolvers: make(map[string]*bucket),
+	}
+}
+
+// Settings returns the settings the limiter was created with
+func (l *Limiter) Settings() config.RateLimitConfig {
+	if l == nil {
+		return config.RateLimitConfig{}
+	}
+	return l.settings
+}
+
+// WaitHost blocks until a request to host may be sent or ctx is done
+func (l *Limiter) WaitHost(ctx context.Context, host string) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	return l.wait(ctx, l.bucketFor(l.hosts, host, l.settings.PerHostRPS))
+}
+
+// WaitResolver blocks until a query to the DNS resolver may be sent or ctx
+// is done
+func (l *Limiter) WaitResolver(ctx context.Context, resolver string) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	return l.wait(ctx, l.bucketFor(l.resolvers, resolver, l.settings.PerResolverRPS))
+}
+
+// bucketFor returns the bucket for key, creating it on first use
+func (l *Limiter) bucketFor(buckets map[string]*bucket, key string, rps float64) *bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := buckets[key]
+	if !ok {
+		b = newBucket(rps, l.settings.Burst)
+		buckets[key] = b
+	}
+	return b
+}
+
+// wait reserves a slot in the global bucket and in b, then sleeps until
+// both allow the request plus a random delay
+func (l *Limiter) wait(ctx context.Context, b *bucket) error {
+	now := time.Now()
+	delay := l.global.reserve(now)
+	if d := b.reserve(now); d > delay {
+		delay = d
+	}
+	delay += l.jitter()
+
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// jitter returns a random delay between the configured minimum and maximum
+func (l *Limiter) jitter() time.Duration {
+	low := time.Duration(l.settings.MinDelay) * time.Millisecond
+	high := time.Duration(l.settings.MaxDelay) * time.Millisecond
+	if high <= low {
+		return low
+	}
+	return low + time.Duration(rand.Int63n(int64(high-low)))
+}
+
+// bucket is a token bucket implemented as a virtual schedule: it tracks
+// when the next request would be due if requests were evenly spaced and
+// allows up to burst requests ahead of that schedule.
+type bucket struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    int
+	next     time.Time
+}
+
+// newBucket creates a bucket for rps requests per second, or nil if rps
+// does not limit anything
+func newBucket(rps float64, burst int) *bucket {
+	if rps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{interval: time.Duration(float64(time.Second) / rps), burst: burst}
+}
+
+// reserve books the next slot and returns how long to wait for it. Slots
+// are not returned when the caller gives up waiting.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	due := b.next
+	if due.Before(now) {
+		due = now
+	}
+	b.next = due.Add(b.interval)
+
+	wait := due.Sub(now) - time.Duration(b.burst-1)*b.interval
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+type contextKey struct{}
+
+// NewContext returns a context that carries l to the modules it is passed to
+func NewContext(ctx context.Context, l *Limiter) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the limiter carried by ctx, or nil if there is none
+func FromContext(ctx context.Context) *Limiter {
+	l, _ := ctx.Value(contextKey{}).(*Limiter)
+	return l
+}
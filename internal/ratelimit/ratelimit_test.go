@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"GoReconX/internal/config"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// slack is how much longer than planned a wait may take on a busy machine.
+// Timers never fire early, so lower bounds are checked exactly.
+const slack = 500 * time.Millisecond
+
+// timeWaits returns how long n calls of wait take
+func timeWaits(t *testing.T, n int, wait func() error) time.Duration {
+	t.Helper()
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		if err := wait(); err != nil {
+			t.Fatalf("wait %d: %v", i, err)
+		}
+	}
+	return time.Since(start)
+}
+
+// checkDuration fails the test unless elapsed is at least want, less the
+// rounding of the bucket's schedule, and at most want plus slack
+func checkDuration(t *testing.T, what string, elapsed, want time.Duration) {
+	t.Helper()
+	if elapsed < want-5*time.Millisecond || elapsed > want+slack {
+		t.Errorf("%s took %v, want about %v", what, elapsed, want)
+	}
+}
+
+func TestBucketReserve(t *testing.T) {
+	// 10 requests per second, 3 of them at once
+	b := newBucket(10, 3)
+	now := time.Now()
+
+	want := []time.Duration{0, 0, 0, 100 * time.Millisecond, 200 * time.Millisecond}
+	for i, w := range want {
+		if got := b.reserve(now); got != w {
+			t.Errorf("reservation %d waits %v, want %v", i, got, w)
+		}
+	}
+
+	// Tokens refill at the rate while nothing is sent, up to the burst
+	later := now.Add(time.Second)
+	for i := 0; i < 3; i++ {
+		if got := b.reserve(later); got != 0 {
+			t.Errorf("reservation %d after a pause waits %v, want none", i, got)
+		}
+	}
+	if got := b.reserve(later); got != 100*time.Millisecond {
+		t.Errorf("reservation beyond the burst waits %v, want 100ms", got)
+	}
+
+	if newBucket(0, 5) != nil || newBucket(-1, 5) != nil {
+		t.Error("a bucket without a rate limits requests")
+	}
+	if got := newBucket(10, 0).burst; got != 1 {
+		t.Errorf("burst 0 taken as %d, want 1", got)
+	}
+}
+
+func TestWaitHostRate(t *testing.T) {
+	const rps, burst, n = 20, 3, 4
+	l := New(config.RateLimitConfig{PerHostRPS: rps, Burst: burst})
+	ctx := context.Background()
+
+	// The burst goes out at once, the n requests after it at the rate
+	elapsed := timeWaits(t, n+burst, func() error { return l.WaitHost(ctx, "192.0.2.1") })
+	checkDuration(t, "n+burst waits", elapsed, n*time.Second/rps)
+}
+
+func TestWaitKeys(t *testing.T) {
+	l := New(config.RateLimitConfig{PerHostRPS: 5, PerResolverRPS: 5, Burst: 2})
+	ctx := context.Background()
+
+	// Each host and each resolver has a bucket of its own, so the burst of
+	// every one of them goes out at once
+	elapsed := timeWaits(t, 2, func() error { return l.WaitHost(ctx, "192.0.2.1") })
+	elapsed += timeWaits(t, 2, func() error { return l.WaitHost(ctx, "192.0.2.2") })
+	elapsed += timeWaits(t, 2, func() error { return l.WaitResolver(ctx, "192.0.2.1:53") })
+	elapsed += timeWaits(t, 2, func() error { return l.WaitResolver(ctx, "192.0.2.2:53") })
+	if elapsed > slack {
+		t.Errorf("bursts to separate hosts and resolvers took %v, want no wait", elapsed)
+	}
+
+	// A third request to the same host waits for its token
+	elapsed = timeWaits(t, 1, func() error { return l.WaitHost(ctx, "192.0.2.1") })
+	checkDuration(t, "a wait beyond the burst", elapsed, 200*time.Millisecond)
+}
+
+func TestWaitGlobalRate(t *testing.T) {
+	l := New(config.RateLimitConfig{GlobalRPS: 20, PerHostRPS: 1000, Burst: 1})
+	ctx := context.Background()
+
+	// The global rate holds across hosts
+	hosts := []string{"192.0.2.1", "192.0.2.2", "192.0.2.3", "192.0.2.4", "192.0.2.5"}
+	i := 0
+	elapsed := timeWaits(t, len(hosts), func() error {
+		i++
+		return l.WaitHost(ctx, hosts[i-1])
+	})
+	checkDuration(t, "waits to different hosts", elapsed, 4*50*time.Millisecond)
+}
+
+func TestJitter(t *testing.T) {
+	l := New(config.RateLimitConfig{MinDelay: 20, MaxDelay: 40})
+	for i := 0; i < 100; i++ {
+		if d := l.jitter(); d < 20*time.Millisecond || d >= 40*time.Millisecond {
+			t.Fatalf("jitter = %v, want between 20ms and 40ms", d)
+		}
+	}
+	if d := New(config.RateLimitConfig{MinDelay: 30, MaxDelay: 10}).jitter(); d != 30*time.Millisecond {
+		t.Errorf("jitter with the maximum below the minimum = %v, want the minimum", d)
+	}
+
+	// Jitter delays requests even without a rate
+	elapsed := timeWaits(t, 3, func() error { return l.WaitHost(context.Background(), "192.0.2.1") })
+	if elapsed < 60*time.Millisecond || elapsed > 120*time.Millisecond+slack {
+		t.Errorf("3 jittered waits took %v, want 60ms to 120ms", elapsed)
+	}
+}
+
+func TestWaitCancelled(t *testing.T) {
+	l := New(config.RateLimitConfig{PerHostRPS: 1, Burst: 1})
+	if err := l.WaitHost(context.Background(), "192.0.2.1"); err != nil {
+		t.Fatalf("first wait: %v", err)
+	}
+
+	// The next token is a second away, but the wait ends with the context
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := l.WaitHost(ctx, "192.0.2.1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the deadline", err)
+	}
+	checkDuration(t, "a cancelled wait", time.Since(start), 50*time.Millisecond)
+
+	// A context that is already done is reported even without a wait
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.WaitHost(done, "192.0.2.2"); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want the cancellation", err)
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	if err := l.WaitHost(context.Background(), "192.0.2.1"); err != nil {
+		t.Errorf("WaitHost: %v", err)
+	}
+	if err := l.WaitResolver(context.Background(), "192.0.2.1:53"); err != nil {
+		t.Errorf("WaitResolver: %v", err)
+	}
+	if l.Settings() != (config.RateLimitConfig{}) {
+		t.Error("a nil limiter has settings")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.WaitHost(ctx, "192.0.2.1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want the cancellation", err)
+	}
+	if FromContext(context.Background()) != nil {
+		t.Error("a context without a limiter carries one")
+	}
+	limiter := New(config.RateLimitConfig{})
+	if FromContext(NewContext(context.Background(), limiter)) != limiter {
+		t.Error("the limiter is not carried by its context")
+	}
+}
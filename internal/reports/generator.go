@@ -8,6 +8,7 @@ import (
 	"html/template"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -29,11 +30,22 @@ type Report struct {
 	GeneratedAt time.Time                 `json:"generated_at"`
 	Summary     string                    `json:"summary"`
 	Results     []*modules.ScanResult     `json:"results"`
+	Alerts      []*Alert                  `json:"alerts,omitempty"`
+	Subdomains  []*modules.SubdomainNode  `json:"subdomains,omitempty"`
 	AIAnalysis  *ai.AnalysisResponse      `json:"ai_analysis,omitempty"`
 	Statistics  map[string]interface{}    `json:"statistics"`
 	Metadata    map[string]interface{}    `json:"metadata"`
 }
 
+// Alert is a finding with a severity, such as a nameserver that allows zone
+// transfers, highlighted at the top of a report
+type Alert struct {
+	Severity string `json:"severity"`
+	Module   string `json:"module"`
+	Target   string `json:"target"`
+	Finding  string `json:"finding"`
+}
+
 // NewReportGenerator creates a new report generator
 func NewReportGenerator(logger *logrus.Logger, aiClient *ai.GeminiClient, outputDir string) *ReportGenerator {
 	// Create output directory if it doesn't exist
@@ -58,6 +70,8 @@ func (rg *ReportGenerator) GenerateReport(target string, results []*modules.Scan
 		Title:       fmt.Sprintf("GoReconX Security Assessment - %s", target),
 		GeneratedAt: time.Now(),
 		Results:     results,
+		Alerts:      collectAlerts(results),
+		Subdomains:  subdomainTrees(results),
 		Statistics:  rg.calculateStatistics(results),
 		Metadata:    make(map[string]interface{}),
 	}
@@ -136,12 +150,13 @@ func (rg *ReportGenerator) ExportCSV(report *Report) (string, error) {
 	defer file.Close()
 
 	// Write CSV header
-	file.WriteString("Module,Target,Status,Start Time,End Time,Results Count\n")
+	file.WriteString("Module,Category,Target,Status,Start Time,End Time,Results Count\n")
 
 	// Write scan results
 	for _, result := range report.Results {
-		file.WriteString(fmt.Sprintf("%s,%s,%s,%s,%s,%d\n",
+		file.WriteString(fmt.Sprintf("%s,%s,%s,%s,%s,%s,%d\n",
 			result.ModuleName,
+			moduleCategory(result),
 			result.Target,
 			result.Status,
 			result.StartTime,
@@ -161,27 +176,42 @@ func (rg *ReportGenerator) calculateStatistics(results []*modules.ScanResult) ma
 	totalScans := len(results)
 	completedScans := 0
 	failedScans := 0
+	skippedScans := 0
 	totalResults := 0
 	
+	highSeverity := 0
 	moduleStats := make(map[string]int)
+	categoryStats := make(map[string]int)
 	
 	for _, result := range results {
+		for _, item := range result.Results {
+			if finding, ok := item.(modules.SeverityFinding); ok && modules.SeverityRank(finding.Severity()) >= modules.SeverityRank(modules.SeverityHigh) {
+				highSeverity++
+			}
+		}
+
 		switch result.Status {
 		case "completed":
 			completedScans++
 		case "failed":
 			failedScans++
+		case "skipped":
+			skippedScans++
 		}
 		
 		totalResults += len(result.Results)
 		moduleStats[result.ModuleName]++
+		categoryStats[moduleCategory(result)]++
 	}
 	
 	stats["total_scans"] = totalScans
 	stats["completed_scans"] = completedScans
 	stats["failed_scans"] = failedScans
+	stats["skipped_scans"] = skippedScans
 	stats["total_results"] = totalResults
+	stats["high_severity_findings"] = highSeverity
 	stats["module_usage"] = moduleStats
+	stats["category_usage"] = categoryStats
 	
 	if totalScans > 0 {
 		stats["success_rate"] = float64(completedScans) / float64(totalScans) * 100
@@ -190,6 +220,60 @@ func (rg *ReportGenerator) calculateStatistics(results []*modules.ScanResult) ma
 	return stats
 }
 
+// collectAlerts returns the findings with a severity among results, the
+// most severe first
+func collectAlerts(results []*modules.ScanResult) []*Alert {
+	var alerts []*Alert
+	for _, result := range results {
+		for _, item := range result.Results {
+			if finding, ok := item.(modules.SeverityFinding); ok {
+				alerts = append(alerts, &Alert{
+					Severity: finding.Severity(),
+					Module:   result.ModuleName,
+					Target:   result.Target,
+					Finding:  finding.String(),
+				})
+			}
+		}
+	}
+	sort.SliceStable(alerts, func(i, j int) bool {
+		return modules.SeverityRank(alerts[i].Severity) > modules.SeverityRank(alerts[j].Severity)
+	})
+	return alerts
+}
+
+// subdomainTrees arranges the subdomains found for each target into a
+// hierarchy rooted at the target
+func subdomainTrees(results []*modules.ScanResult) []*modules.SubdomainNode {
+	var targets []string
+	found := make(map[string][]*modules.SubdomainResult)
+	for _, result := range results {
+		for _, item := range result.Results {
+			if subdomain, ok := item.(*modules.SubdomainResult); ok {
+				if _, ok := found[result.Target]; !ok {
+					targets = append(targets, result.Target)
+				}
+				found[result.Target] = append(found[result.Target], subdomain)
+			}
+		}
+	}
+
+	var trees []*modules.SubdomainNode
+	for _, target := range targets {
+		trees = append(trees, modules.SubdomainTree(target, found[target]))
+	}
+	return trees
+}
+
+// moduleCategory looks up the registry category of the module that
+// produced result
+func moduleCategory(result *modules.ScanResult) string {
+	if info, ok := modules.LookupModule(result.ModuleID); ok {
+		return string(info.Category)
+	}
+	return "unknown"
+}
+
 // generateBasicSummary creates a basic summary when AI analysis is not available
 func (rg *ReportGenerator) generateBasicSummary(results []*modules.ScanResult) string {
 	var summary strings.Builder
@@ -209,6 +293,14 @@ func (rg *ReportGenerator) generateBasicSummary(results []*modules.ScanResult) s
 	summary.WriteString(fmt.Sprintf("Completed %d reconnaissance modules with %d total findings.\n", 
 		completedCount, totalFindings))
 	
+	alerts := collectAlerts(results)
+	if len(alerts) > 0 {
+		summary.WriteString(fmt.Sprintf("%d findings need attention:\n", len(alerts)))
+		for _, alert := range alerts {
+			summary.WriteString(fmt.Sprintf("- [%s] %s: %s\n", strings.ToUpper(alert.Severity), alert.Module, alert.Finding))
+		}
+	}
+	
 	// Module-specific summaries
 	for _, result := range results {
 		if result.Status == "completed" && len(result.Results) > 0 {
@@ -255,6 +347,12 @@ func (rg *ReportGenerator) getHTMLTemplate() *template.Template {
         .threat-medium { background: #fff3cd; color: #856404; }
         .threat-high { background: #f8d7da; color: #721c24; }
         .threat-critical { background: #f5c6cb; color: #721c24; }
+        .alerts { background: #f8d7da; border: 1px solid #f5c6cb; border-radius: 5px; padding: 20px; margin-bottom: 30px; }
+        .alert { padding: 8px 0; border-bottom: 1px solid #f5c6cb; }
+        .alert:last-child { border-bottom: none; }
+        .tree ul { list-style: none; margin: 0; padding-left: 20px; border-left: 1px dashed #adb5bd; }
+        .tree > ul { border-left: none; padding-left: 0; }
+        .tree small { color: #6c757d; }
     </style>
 </head>
 <body>
@@ -292,12 +390,28 @@ func (rg *ReportGenerator) getHTMLTemplate() *template.Template {
                 <h3>{{index .Statistics "total_results"}}</h3>
                 <p>Total Findings</p>
             </div>
+            <div class="stat-card">
+                <h3>{{index .Statistics "high_severity_findings"}}</h3>
+                <p>High Severity</p>
+            </div>
             <div class="stat-card">
                 <h3>{{printf "%.1f%%" (index .Statistics "success_rate")}}</h3>
                 <p>Success Rate</p>
             </div>
         </div>
 
+        {{if .Alerts}}
+        <div class="alerts">
+            <h2>Findings Requiring Attention</h2>
+            {{range .Alerts}}
+            <div class="alert">
+                <span class="threat-level threat-{{.Severity}}">{{.Severity | title}}</span>
+                <strong>{{.Module}}</strong> ({{.Target}}): {{.Finding}}
+            </div>
+            {{end}}
+        </div>
+        {{end}}
+
         {{if .AIAnalysis}}
         <div class="ai-analysis">
             <h2>AI-Powered Analysis</h2>
@@ -332,12 +446,24 @@ func (rg *ReportGenerator) getHTMLTemplate() *template.Template {
         </div>
         {{end}}
 
+        {{if .Subdomains}}
+        <div class="results">
+            <h2>Subdomain Hierarchy</h2>
+            {{range .Subdomains}}
+            <div class="result-card">
+                <div class="result-header">{{.Name}}</div>
+                <div class="result-body tree">{{template "subdomain-tree" .Children}}</div>
+            </div>
+            {{end}}
+        </div>
+        {{end}}
+
         <div class="results">
             <h2>Detailed Results</h2>
             {{range .Results}}
             <div class="result-card">
                 <div class="result-header">
-                    {{.ModuleName}} - <span class="status-{{.Status}}">{{.Status | title}}</span>
+                    {{.ModuleName}} ({{category .}}) - <span class="status-{{.Status}}">{{.Status | title}}</span>
                 </div>
                 <div class="result-body">
                     <p><strong>Target:</strong> {{.Target}}</p>
@@ -365,12 +491,15 @@ func (rg *ReportGenerator) getHTMLTemplate() *template.Template {
     </div>
 </body>
 </html>
+{{define "subdomain-tree"}}<ul>{{range .}}<li>{{.Name}}{{if .IPs}} <small>{{join .IPs ", "}}</small>{{end}}{{if .Children}}{{template "subdomain-tree" .Children}}{{end}}</li>{{end}}</ul>{{end}}
 `
 
 	tmpl := template.New("report")
 	tmpl = tmpl.Funcs(template.FuncMap{
 		"title": strings.Title,
 		"lower": strings.ToLower,
+		"join": strings.Join,
+		"category": moduleCategory,
 		"marshal": func(v interface{}) string {
 			data, _ := json.MarshalIndent(v, "", "  ")
 			return string(data)
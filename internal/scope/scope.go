@@ -0,0 +1,285 @@
+package scope
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Definition is the stored form of an engagement scope. Include and Exclude
+// entries may be domains ("example.com"), wildcards matching any subdomain
+// ("*.example.com"), IP addresses or CIDR ranges. Ports uses the same
+// syntax as the port scanner, e.g. "80,443,8000-8100"; empty allows all.
+type Definition struct {
+	Include []string `json:"include" yaml:"include"`
+	Exclude []string `json:"exclude" yaml:"exclude"`
+	Ports   string   `json:"ports,omitempty" yaml:"ports"`
+}
+
+// Scope decides which hosts and ports may be touched during an engagement.
+// A nil *Scope places no restrictions.
+type Scope struct {
+	def     Definition
+	include rules
+	exclude rules
+	ports   []portRange
+}
+
+// rules is a parsed list of scope entries
+type rules struct {
+	domains   []string // exact matches
+	wildcards []string // suffixes including the leading dot
+	networks  []*net.IPNet
+}
+
+type portRange struct {
+	low, high int
+}
+
+// New parses a scope definition
+func New(def Definition) (*Scope, error) {
+	s := &Scope{def: def}
+
+	var err error
+	if s.include, err = parseRules(def.Include); err != nil {
+		return nil, fmt.Errorf("invalid include entry: %v", err)
+	}
+	if s.exclude, err = parseRules(def.Exclude); err != nil {
+		return nil, fmt.Errorf("invalid exclude entry: %v", err)
+	}
+	if s.ports, err = parsePorts(def.Ports); err != nil {
+		return nil, fmt.Errorf("invalid ports: %v", err)
+	}
+
+	return s, nil
+}
+
+// Load reads a scope definition from a YAML file
+func Load(path string) (*Scope, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var def Definition
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("invalid scope file: %v", err)
+	}
+	return New(def)
+}
+
+// Definition returns the definition the scope was created from
+func (s *Scope) Definition() Definition {
+	if s == nil {
+		return Definition{}
+	}
+	return s.def
+}
+
+// Contains reports whether host, a domain or IP address given directly as
+// a target, is in scope. When the scope has include entries the host must
+// match one of them; it must never match an exclude entry.
+func (s *Scope) Contains(host string) bool {
+	if s == nil {
+		return true
+	}
+
+	host = normalizeHost(host)
+	if s.exclude.match(host) {
+		return false
+	}
+	return s.include.empty() || s.include.match(host)
+}
+
+// Domains returns the domains the scope includes, with wildcard entries
+// such as *.example.com as the domain they cover
+func (s *Scope) Domains() []string {
+	if s == nil {
+		return nil
+	}
+
+	var domains []string
+	for _, domain := range s.include.domains {
+		domains = append(domains, domain)
+	}
+	for _, suffix := range s.include.wildcards {
+		domains = append(domains, strings.TrimPrefix(suffix, "."))
+	}
+	return domains
+}
+
+// ContainsResolved reports whether ip, an address a domain that is itself
+// in scope resolved to, may be probed. Such addresses only have to match an
+// included network when the scope includes any networks at all.
+func (s *Scope) ContainsResolved(ip net.IP) bool {
+	if s == nil {
+		return true
+	}
+
+	if s.exclude.matchIP(ip) {
+		return false
+	}
+	return len(s.include.networks) == 0 || s.include.matchIP(ip)
+}
+
+// ContainsPort reports whether port may be probed
+func (s *Scope) ContainsPort(port int) bool {
+	if s == nil || len(s.ports) == 0 {
+		return true
+	}
+
+	for _, r := range s.ports {
+		if port >= r.low && port <= r.high {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns an error unless the host of target is in scope. Target may
+// be a domain, an IP address, host:port or a URL.
+func (s *Scope) Check(target string) error {
+	host := Host(target)
+	if !s.Contains(host) {
+		return fmt.Errorf("%s is out of scope", host)
+	}
+	return nil
+}
+
+// Host extracts the host name or address from a target such as
+// "https://example.com:8443/path", "10.0.0.1:22" or "example.com"
+func Host(target string) string {
+	target = strings.TrimSpace(target)
+
+	if strings.Contains(target, "://") {
+		if u, err := url.Parse(target); err == nil && u.Hostname() != "" {
+			return normalizeHost(u.Hostname())
+		}
+	}
+	if host, _, err := net.SplitHostPort(target); err == nil {
+		return normalizeHost(host)
+	}
+	return normalizeHost(target)
+}
+
+type contextKey struct{}
+
+// NewContext returns a context that carries s to the modules it is passed to
+func NewContext(ctx context.Context, s *Scope) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the scope carried by ctx, or nil if there is none
+func FromContext(ctx context.Context) *Scope {
+	s, _ := ctx.Value(contextKey{}).(*Scope)
+	return s
+}
+
+// parseRules sorts scope entries into domains, wildcards and networks
+func parseRules(entries []string) (rules, error) {
+	var r rules
+	for _, entry := range entries {
+		entry = normalizeHost(entry)
+		switch {
+		case entry == "":
+			continue
+		case strings.Contains(entry, "/"):
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return r, err
+			}
+			r.networks = append(r.networks, network)
+		case net.ParseIP(entry) != nil:
+			ip := net.ParseIP(entry)
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			r.networks = append(r.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		case strings.HasPrefix(entry, "*."):
+			r.wildcards = append(r.wildcards, entry[1:])
+		case strings.Contains(entry, "*"):
+			return r, fmt.Errorf("%s: wildcards are only allowed as the first label", entry)
+		default:
+			r.domains = append(r.domains, entry)
+		}
+	}
+	return r, nil
+}
+
+func (r rules) empty() bool {
+	return len(r.domains) == 0 && len(r.wildcards) == 0 && len(r.networks) == 0
+}
+
+// match matches a normalized domain or IP address against the rules
+func (r rules) match(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return r.matchIP(ip)
+	}
+
+	for _, domain := range r.domains {
+		if host == domain {
+			return true
+		}
+	}
+	for _, suffix := range r.wildcards {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r rules) matchIP(ip net.IP) bool {
+	for _, network := range r.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePorts parses a port specification into ranges
+func parsePorts(spec string) ([]portRange, error) {
+	var ranges []portRange
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		low, high := part, part
+		if i := strings.Index(part, "-"); i >= 0 {
+			low, high = part[:i], part[i+1:]
+		}
+
+		start, err := strconv.Atoi(strings.TrimSpace(low))
+		if err != nil {
+			return nil, fmt.Errorf("invalid port: %s", part)
+		}
+		end, err := strconv.Atoi(strings.TrimSpace(high))
+		if err != nil {
+			return nil, fmt.Errorf("invalid port: %s", part)
+		}
+		if start < 1 || end > 65535 || start > end {
+			return nil, fmt.Errorf("invalid port range: %s", part)
+		}
+
+		ranges = append(ranges, portRange{low: start, high: end})
+	}
+	return ranges, nil
+}
+
+// normalizeHost lowercases a host and strips a trailing dot and IPv6 brackets
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	host = strings.TrimSuffix(host, ".")
+	return strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+}
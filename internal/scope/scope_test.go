@@ -0,0 +1,199 @@
+package scope
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func mustNew(t *testing.T, def Definition) *Scope {
+	t.Helper()
+	s, err := New(def)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return s
+}
+
+func TestContains(t *testing.T) {
+	s := mustNew(t, Definition{
+		Include: []string{"example.com", "*.example.org", "10.0.0.0/24", "192.0.2.7", "2001:db8::/64", "Shop.Example.NET."},
+		Exclude: []string{"admin.example.org", "*.internal.example.org", "10.0.0.13", "[2001:db8::1]"},
+	})
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		// Exact domains match themselves only
+		{"example.com", true},
+		{"EXAMPLE.com.", true},
+		{"www.example.com", false},
+		{"shop.example.net", true},
+
+		// Wildcards match subdomains at any depth, but not the apex or a
+		// name that merely ends in the same letters
+		{"www.example.org", true},
+		{"a.b.example.org", true},
+		{"example.org", false},
+		{"evilexample.org", false},
+		{"example.org.evil.net", false},
+
+		// Exclude entries override include entries
+		{"admin.example.org", false},
+		{"vpn.internal.example.org", false},
+		{"internal.example.org", true},
+
+		// IP literals and CIDR ranges
+		{"10.0.0.1", true},
+		{"10.0.0.13", false},
+		{"10.0.1.1", false},
+		{"192.0.2.7", true},
+		{"192.0.2.8", false},
+		{"2001:db8::2", true},
+		{"2001:db8::1", false},
+		{"2001:db8:1::1", false},
+		{"[2001:db8::2]", true},
+	}
+	for _, tt := range tests {
+		if got := s.Contains(tt.host); got != tt.want {
+			t.Errorf("Contains(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestContainsWithoutInclude(t *testing.T) {
+	s := mustNew(t, Definition{Exclude: []string{"*.gov", "172.16.0.0/12"}})
+
+	for host, want := range map[string]bool{
+		"example.com":  true,
+		"10.0.0.1":     true,
+		"www.nasa.gov": false,
+		"172.20.1.1":   false,
+	} {
+		if got := s.Contains(host); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", host, got, want)
+		}
+	}
+
+	var unrestricted *Scope
+	if !unrestricted.Contains("anything.example") || !unrestricted.ContainsPort(1) || !unrestricted.ContainsResolved(net.ParseIP("10.0.0.1")) {
+		t.Error("a nil scope restricts something, want nothing")
+	}
+}
+
+func TestContainsResolved(t *testing.T) {
+	tests := []struct {
+		name string
+		def  Definition
+		ip   string
+		want bool
+	}{
+		{"no networks included", Definition{Include: []string{"example.com"}}, "203.0.113.5", true},
+		{"excluded without networks included", Definition{Include: []string{"example.com"}, Exclude: []string{"203.0.113.0/24"}}, "203.0.113.5", false},
+		{"inside an included network", Definition{Include: []string{"example.com", "198.51.100.0/24"}}, "198.51.100.9", true},
+		{"outside the included networks", Definition{Include: []string{"example.com", "198.51.100.0/24"}}, "203.0.113.5", false},
+		{"excluded inside an included network", Definition{Include: []string{"198.51.100.0/24"}, Exclude: []string{"198.51.100.9"}}, "198.51.100.9", false},
+		{"included single address", Definition{Include: []string{"example.com", "2001:db8::5"}}, "2001:db8::5", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustNew(t, tt.def).ContainsResolved(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("ContainsResolved(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHost(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"example.com", "example.com"},
+		{" Example.COM. ", "example.com"},
+		{"https://www.example.com:8443/path?q=1", "www.example.com"},
+		{"http://[2001:db8::1]:8080/", "2001:db8::1"},
+		{"example.com:443", "example.com"},
+		{"10.0.0.1:22", "10.0.0.1"},
+		{"[2001:db8::1]:53", "2001:db8::1"},
+		{"[2001:db8::1]", "2001:db8::1"},
+		{"2001:db8::1", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		if got := Host(tt.target); got != tt.want {
+			t.Errorf("Host(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	s := mustNew(t, Definition{Include: []string{"*.example.com"}})
+	if err := s.Check("https://api.example.com/v1"); err != nil {
+		t.Errorf("Check of an in-scope URL: %v", err)
+	}
+	if err := s.Check("evil.net:443"); err == nil || !strings.Contains(err.Error(), "evil.net is out of scope") {
+		t.Errorf("Check of an out-of-scope host: %v", err)
+	}
+}
+
+func TestPorts(t *testing.T) {
+	tests := []struct {
+		ports   string
+		allowed []int
+		denied  []int
+		wantErr bool
+	}{
+		{ports: "", allowed: []int{1, 80, 65535}},
+		{ports: "80,443, 8000-8100", allowed: []int{80, 443, 8000, 8050, 8100}, denied: []int{22, 7999, 8101}},
+		{ports: "1-1024", allowed: []int{1, 1024}, denied: []int{1025}},
+		{ports: "0", wantErr: true},
+		{ports: "70000", wantErr: true},
+		{ports: "5-1", wantErr: true},
+		{ports: "http", wantErr: true},
+		{ports: "80-", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ports, func(t *testing.T) {
+			s, err := New(Definition{Ports: tt.ports})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("New succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			for _, port := range tt.allowed {
+				if !s.ContainsPort(port) {
+					t.Errorf("port %d denied, want allowed", port)
+				}
+			}
+			for _, port := range tt.denied {
+				if s.ContainsPort(port) {
+					t.Errorf("port %d allowed, want denied", port)
+				}
+			}
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		def  Definition
+		want string
+	}{
+		{"bad CIDR", Definition{Include: []string{"10.0.0.0/33"}}, "invalid include entry"},
+		{"wildcard inside a name", Definition{Include: []string{"www.*.example.com"}}, "wildcards are only allowed as the first label"},
+		{"bad exclude", Definition{Exclude: []string{"a*b"}}, "invalid exclude entry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.def); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
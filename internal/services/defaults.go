@@ -0,0 +1,103 @@
+package services
+
+// Defaults is written to the probe file when it does not exist. It covers
+// common services only; nmap's nmap-service-probes file can be used in its
+// place for far more.
+const Defaults = `# GoReconX service probes, in the format of nmap's nmap-service-probes.
+#
+#   Probe <TCP|UDP> <name> q|<payload>|
+#   match <service> m|<pattern>|[is] [p/product/] [v/version/] [i/info/]
+#         [h/hostname/] [o/os/] [d/device type/] [cpe:/cpe/]
+#   softmatch <service> m|<pattern>|[is]
+#
+# $1 in the version info is replaced by the first group of the pattern.
+
+# Printers print whatever they are sent
+Exclude T:9100-9107
+
+# Services that greet first
+Probe TCP NULL q||
+totalwaitms 6000
+
+match ssh m|^SSH-([\d.]+)-OpenSSH_([\w.]+) Ubuntu-([^\r\n]+)\r?\n| p/OpenSSH/ v/$2 Ubuntu $3/ i/protocol $1/ o/Linux/ cpe:/a:openbsd:openssh:$2/ cpe:/o:canonical:ubuntu_linux/
+match ssh m|^SSH-([\d.]+)-OpenSSH_([\w.]+) Debian-([^\r\n]+)\r?\n| p/OpenSSH/ v/$2 Debian $3/ i/protocol $1/ o/Linux/ cpe:/a:openbsd:openssh:$2/ cpe:/o:debian:debian_linux/
+match ssh m|^SSH-([\d.]+)-OpenSSH_([\w.]+)| p/OpenSSH/ v/$2/ i/protocol $1/ cpe:/a:openbsd:openssh:$2/
+match ssh m|^SSH-([\d.]+)-dropbear_([\w.]+)| p/Dropbear sshd/ v/$2/ i/protocol $1/ cpe:/a:dropbear_ssh_project:dropbear_ssh:$2/
+softmatch ssh m|^SSH-[\d.]+-|
+
+match ftp m|^220 \(vsFTPd ([\w.]+)\)\r\n| p/vsftpd/ v/$1/ cpe:/a:beasts:vsftpd:$1/
+match ftp m|^220 ProFTPD ([\w.]+) Server| p/ProFTPD/ v/$1/ cpe:/a:proftpd:proftpd:$1/
+match ftp m|^220-+ ?Welcome to Pure-FTPd| p/Pure-FTPd/ cpe:/a:pureftpd:pure-ftpd/
+match ftp m|^220-FileZilla Server(?: version)? ([\w. -]+)\r\n| p/FileZilla ftpd/ v/$1/ o/Windows/ cpe:/a:filezilla-project:filezilla_server:$1/
+match ftp m|^220 Microsoft FTP Service\r\n| p/Microsoft ftpd/ o/Windows/ cpe:/a:microsoft:internet_information_services/ cpe:/o:microsoft:windows/
+softmatch ftp m|^220[ -][^\r\n]*FTP|i
+
+match smtp m|^220 ([-\w.]+) ESMTP Postfix| p/Postfix smtpd/ h/$1/ cpe:/a:postfix:postfix/
+match smtp m|^220 ([-\w.]+) ESMTP Exim ([\d.]+)| p/Exim smtpd/ v/$2/ h/$1/ cpe:/a:exim:exim:$2/
+match smtp m|^220 ([-\w.]+) ESMTP Sendmail ([\w.]+)/| p/Sendmail/ v/$2/ h/$1/ cpe:/a:sendmail:sendmail:$2/
+match smtp m|^220 ([-\w.]+) Microsoft ESMTP MAIL Service| p/Microsoft ESMTP/ h/$1/ o/Windows/ cpe:/a:microsoft:exchange_server/ cpe:/o:microsoft:windows/
+softmatch smtp m|^220[ -][^\r\n]*SMTP|i
+
+match pop3 m|^\+OK Dovecot(?: \(([^)]+)\))? ready\.\r\n| p/Dovecot pop3d/ i/$1/ cpe:/a:dovecot:dovecot/
+softmatch pop3 m|^\+OK |
+match imap m|^\* OK (?:\[[^\]]*\] )?Dovecot(?: \(([^)]+)\))? ready\.\r\n| p/Dovecot imapd/ i/$1/ cpe:/a:dovecot:dovecot/
+softmatch imap m|^\* OK |
+
+match mysql m|^.\x00\x00\x00\x0a(?:5\.5\.5-)?([\d.]+)-MariaDB|s p/MariaDB/ v/$1/ cpe:/a:mariadb:mariadb:$1/
+match mysql m|^.\x00\x00\x00\x0a([\d.]+)[^\x00]*\x00|s p/MySQL/ v/$1/ cpe:/a:mysql:mysql:$1/
+match mysql m|^.\x00\x00\x00\xffj\x04Host '[^']*' is not allowed|s p/MySQL/ i/unauthorized/ cpe:/a:mysql:mysql/
+
+match vnc m|^RFB 00(\d)\.00(\d)\n| p/VNC/ i/protocol $1.$2/
+match telnet m|^\xff[\xfb-\xfe]|s p/telnetd/
+
+# TLS, whose services are probed again through a TLS connection
+Probe TCP SSLSessionReq q|\x16\x03\x01\x00\x63\x01\x00\x00\x5f\x03\x03GoReconX service detection probe\x00\x00\x16\xc0\x2f\xc0\x30\xc0\x2b\xc0\x2c\xc0\x13\xc0\x14\x00\x9c\x00\x9d\x00\x2f\x00\x35\x00\x0a\x01\x00\x00\x20\x00\x0a\x00\x08\x00\x06\x00\x1d\x00\x17\x00\x18\x00\x0b\x00\x02\x01\x00\x00\x0d\x00\x0a\x00\x08\x04\x01\x05\x01\x08\x04\x04\x03|
+rarity 1
+ports 443,465,636,853,989,990,992,993,994,995,5061,6697,8443,9443
+
+match ssl m|^\x16\x03[\x00-\x04]..\x02|s p/TLS/
+match ssl m|^\x15\x03[\x00-\x04]\x00\x02\x02|s p/TLS/ i/handshake refused/
+
+Probe TCP GetRequest q|GET / HTTP/1.0\r\n\r\n|
+rarity 1
+ports 80,81,591,2080,3000,5000,8000,8008,8080,8081,8888,9000
+sslports 443,8443,9443
+
+match http m|^HTTP/1\.[01] \d\d\d .*?\r\nServer: Apache/([\d.]+)(?: \(([^)\r\n]+)\))?|s p/Apache httpd/ v/$1/ i/$2/ cpe:/a:apache:http_server:$1/
+match http m|^HTTP/1\.[01] \d\d\d .*?\r\nServer: nginx/([\d.]+)|s p/nginx/ v/$1/ cpe:/a:f5:nginx:$1/
+match http m|^HTTP/1\.[01] \d\d\d .*?\r\nServer: nginx\r\n|s p/nginx/ cpe:/a:f5:nginx/
+match http m|^HTTP/1\.[01] \d\d\d .*?\r\nServer: Microsoft-IIS/([\d.]+)|s p/Microsoft IIS httpd/ v/$1/ o/Windows/ cpe:/a:microsoft:internet_information_services:$1/ cpe:/o:microsoft:windows/
+match http m|^HTTP/1\.[01] \d\d\d .*?\r\nServer: lighttpd/([\d.]+)|s p/lighttpd/ v/$1/ cpe:/a:lighttpd:lighttpd:$1/
+match http m|^HTTP/1\.[01] \d\d\d .*?\r\nServer: Caddy\r\n|s p/Caddy httpd/ cpe:/a:caddyserver:caddy/
+match http m|^HTTP/1\.[01] \d\d\d .*?\r\nServer: Jetty\(([\w.-]+)\)|s p/Jetty/ v/$1/ cpe:/a:eclipse:jetty:$1/
+match http m|^HTTP/1\.[01] \d\d\d .*?\r\nServer: ([^\r\n]+)|s p/$1/
+softmatch http m|^HTTP/1\.[01] \d\d\d |
+match redis m|^-ERR wrong number of arguments for 'get' command\r\n| p/Redis key-value store/ cpe:/a:redis:redis/
+
+Probe TCP RedisInfo q|*1\r\n$4\r\nINFO\r\n|
+rarity 5
+ports 6379
+
+match redis m|redis_version:([\d.]+)\r\n.*?os:([^\r\n]+)|s p/Redis key-value store/ v/$1/ o/$2/ cpe:/a:redis:redis:$1/
+match redis m|^-NOAUTH Authentication required| p/Redis key-value store/ i/authentication required/ cpe:/a:redis:redis/
+
+Probe UDP DNSVersionBindReq q|\x13\x37\x01\x00\x00\x01\x00\x00\x00\x00\x00\x00\x07version\x04bind\x00\x00\x10\x00\x03|
+rarity 1
+ports 53
+
+match dns m|^\x13\x37[\x80-\x87].*\x00\x10\x00\x03.{6}.(\d+\.\d+\.\d+[\w.+-]*)|s p/ISC BIND/ v/$1/ cpe:/a:isc:bind:$1/
+softmatch dns m|^\x13\x37[\x80-\xff]|s
+
+Probe UDP SNMPv2cPublic q|\x30\x29\x02\x01\x01\x04\x06public\xa0\x1c\x02\x04\x71\xb4\xb5\x68\x02\x01\x00\x02\x01\x00\x30\x0e\x30\x0c\x06\x08\x2b\x06\x01\x02\x01\x01\x01\x00\x05\x00|
+rarity 1
+ports 161
+
+match snmp m%^\x30.*\x2b\x06\x01\x02\x01\x01\x01\x00\x04(?:[\x00-\x7f]|\x81.)Cisco IOS Software.*?Version ([\w.()]+)%s p/Cisco SNMP service/ o/IOS $1/ cpe:/o:cisco:ios:$1/
+match snmp m%^\x30.*\x2b\x06\x01\x02\x01\x01\x01\x00\x04(?:[\x00-\x7f]|\x81.)([^\x00]+)%s p/SNMP/ i/$P(1)/
+
+Probe UDP NTPRequest q|\xe3\x00\x04\xfa\x00\x01\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00|
+rarity 1
+ports 123
+
+match ntp m|^[\x1c\x24\x5c\x64\x9c\xa4\xdc\xe4]|s p/NTP/
+`
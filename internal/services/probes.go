@@ -0,0 +1,630 @@
+// Package services identifies the service behind a port from its responses
+// to probes, using a database in the format of nmap's nmap-service-probes
+// file. Probes are payloads sent to a port; their match lines are regular
+// expressions over the response, with templates for the product, version
+// and CPE of the service they recognise.
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Protocols a probe may be sent over
+const (
+	TCP = "TCP"
+	UDP = "UDP"
+)
+
+// Database is a parsed probe file
+type Database struct {
+	Probes []*Probe
+	// Skipped counts match lines whose patterns use features, such as
+	// backreferences or lookarounds, that Go regular expressions lack
+	Skipped int
+
+	exclude map[string][]portRange
+	byName  map[string]*Probe
+}
+
+// Probe is a payload sent to a port together with the patterns matching
+// the answers of the services it makes talk
+type Probe struct {
+	Protocol string
+	Name     string
+	Payload  []byte
+	// Rarity ranks from 1 to 9 how seldom the probe gets an answer; probes
+	// above the scan's intensity are only sent to their own ports
+	Rarity int
+	// TotalWait is how long to wait for an answer, 0 for the scan's
+	// timeout
+	TotalWait time.Duration
+	Matches   []*Match
+	// Fallback names the probes whose matches are tried as well
+	Fallback []string
+
+	ports    []portRange
+	sslPorts []portRange
+}
+
+// Match recognises a service by its response to a probe. Soft matches only
+// name the service, and probing goes on to find out more.
+type Match struct {
+	Service string
+	Soft    bool
+	Pattern *regexp.Regexp
+	// Templates of the service details, where $1 is replaced by the first
+	// group of the pattern, $P(1) by its printable characters,
+	// $SUBST(1,"_",".") by it with every _ replaced by a dot and
+	// $I(1,">") by it read as a big-endian (>) or little-endian (<)
+	// number
+	Product    string
+	Version    string
+	Info       string
+	Hostname   string
+	OS         string
+	DeviceType string
+	CPE        []string
+}
+
+// Service is what a match found out about the service behind a port
+type Service struct {
+	Name       string
+	Product    string
+	Version    string
+	Info       string
+	Hostname   string
+	OS         string
+	DeviceType string
+	CPE        []string
+	// Soft is set when only the name of the service is known
+	Soft bool
+}
+
+// portRange is an inclusive range of ports
+type portRange struct {
+	low, high int
+}
+
+// Load reads a probe file
+func Load(path string) (*Database, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Save writes a probe file, e.g. Defaults
+func Save(path string, data string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(data), 0644)
+}
+
+// Parse parses a probe file in the nmap-service-probes format
+func Parse(data []byte) (*Database, error) {
+	db := &Database{exclude: make(map[string][]portRange), byName: make(map[string]*Probe)}
+	var probe *Probe
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		directive, rest, _ := strings.Cut(text, " ")
+		rest = strings.TrimSpace(rest)
+
+		if directive == "Probe" {
+			var err error
+			if probe, err = parseProbe(rest); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			db.Probes = append(db.Probes, probe)
+			db.byName[probe.Protocol+"/"+probe.Name] = probe
+			continue
+		}
+		if directive == "Exclude" {
+			if err := db.parseExclude(rest); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			continue
+		}
+		if probe == nil {
+			return nil, fmt.Errorf("line %d: %s before the first probe", line, directive)
+		}
+
+		var err error
+		switch directive {
+		case "match", "softmatch":
+			var match *Match
+			match, err = parseMatch(rest, directive == "softmatch")
+			if err == errUnsupported {
+				db.Skipped++
+				err = nil
+			} else if err == nil {
+				probe.Matches = append(probe.Matches, match)
+			}
+		case "ports":
+			probe.ports, err = parsePorts(rest)
+		case "sslports":
+			probe.sslPorts, err = parsePorts(rest)
+		case "rarity":
+			probe.Rarity, err = strconv.Atoi(rest)
+		case "totalwaitms":
+			var ms int
+			ms, err = strconv.Atoi(rest)
+			probe.TotalWait = time.Duration(ms) * time.Millisecond
+		case "fallback":
+			probe.Fallback = strings.Split(rest, ",")
+		}
+		// Other directives, such as tcpwrappedms, are not used
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Excluded reports whether the file asks for port to be left alone, such
+// as printer ports that print whatever they are sent
+func (db *Database) Excluded(protocol string, port int) bool {
+	return inRanges(db.exclude[protocol], port)
+}
+
+// ProbesFor returns the probes to send to port in order: the NULL probe
+// and the probes listing the port first, then the others up to the
+// intensity, rarest last
+func (db *Database) ProbesFor(protocol string, port int, intensity int) []*Probe {
+	var listed, others []*Probe
+	for _, probe := range db.Probes {
+		if probe.Protocol != protocol {
+			continue
+		}
+		switch {
+		case len(probe.Payload) == 0 || probe.HasPort(port):
+			listed = append(listed, probe)
+		case probe.Rarity <= intensity:
+			others = append(others, probe)
+		}
+	}
+	sort.SliceStable(others, func(i, j int) bool {
+		return others[i].Rarity < others[j].Rarity
+	})
+	return append(listed, others...)
+}
+
+// Match returns the service the response of probe belongs to, trying the
+// matches of the probe and then those of its fallbacks. TCP probes without
+// fallbacks fall back to the NULL probe, as a service may send its banner
+// whatever it is sent. The first hard match wins; otherwise the first soft
+// match is returned.
+func (db *Database) Match(probe *Probe, response []byte) *Service {
+	if len(response) == 0 {
+		return nil
+	}
+	subject := latin1(response)
+
+	candidates := []*Probe{probe}
+	for _, name := range probe.Fallback {
+		if fallback := db.byName[probe.Protocol+"/"+strings.TrimSpace(name)]; fallback != nil && fallback != probe {
+			candidates = append(candidates, fallback)
+		}
+	}
+	if null := db.byName[TCP+"/NULL"]; probe.Protocol == TCP && len(probe.Fallback) == 0 && null != nil && null != probe {
+		candidates = append(candidates, null)
+	}
+
+	var soft *Service
+	for _, candidate := range candidates {
+		for _, match := range candidate.Matches {
+			service := match.apply(subject)
+			if service == nil {
+				continue
+			}
+			if !service.Soft {
+				return service
+			}
+			if soft == nil {
+				soft = service
+			}
+		}
+	}
+	return soft
+}
+
+// HasMatchFor reports whether the probe can identify more than the name
+// of service, which makes it worth sending after a soft match
+func (p *Probe) HasMatchFor(service string) bool {
+	for _, match := range p.Matches {
+		if !match.Soft && match.Service == service {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPort reports whether the probe lists port as one of its services'
+func (p *Probe) HasPort(port int) bool {
+	return inRanges(p.ports, port) || inRanges(p.sslPorts, port)
+}
+
+// apply matches the pattern against a response decoded by latin1 and
+// fills in the templates
+func (m *Match) apply(subject string) *Service {
+	groups := m.Pattern.FindStringSubmatch(subject)
+	if groups == nil {
+		return nil
+	}
+	service := &Service{
+		Name:       m.Service,
+		Soft:       m.Soft,
+		Product:    expand(m.Product, groups),
+		Version:    expand(m.Version, groups),
+		Info:       expand(m.Info, groups),
+		Hostname:   expand(m.Hostname, groups),
+		OS:         expand(m.OS, groups),
+		DeviceType: expand(m.DeviceType, groups),
+	}
+	for _, cpe := range m.CPE {
+		service.CPE = append(service.CPE, expand(cpe, groups))
+	}
+	return service
+}
+
+// errUnsupported marks patterns Go cannot compile
+var errUnsupported = fmt.Errorf("unsupported pattern")
+
+// parseProbe parses "TCP GetRequest q|GET / HTTP/1.0\r\n\r\n|"
+func parseProbe(spec string) (*Probe, error) {
+	fields := strings.SplitN(spec, " ", 3)
+	if len(fields) < 3 || (fields[0] != TCP && fields[0] != UDP) {
+		return nil, fmt.Errorf("invalid probe: %s", spec)
+	}
+	payload := strings.TrimSpace(fields[2])
+	if len(payload) < 3 || payload[0] != 'q' {
+		return nil, fmt.Errorf("invalid probe payload: %s", payload)
+	}
+	end := strings.IndexByte(payload[2:], payload[1])
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated probe payload: %s", payload)
+	}
+	data, err := unescape(payload[2 : 2+end])
+	if err != nil {
+		return nil, err
+	}
+	return &Probe{Protocol: fields[0], Name: fields[1], Payload: data, Rarity: 1}, nil
+}
+
+// parseMatch parses the rest of a match line, e.g.
+// "ssh m|^SSH-([\d.]+)-OpenSSH_([\w._-]+)|i p/OpenSSH/ v/$2/"
+func parseMatch(spec string, soft bool) (*Match, error) {
+	service, rest, found := strings.Cut(spec, " ")
+	rest = strings.TrimSpace(rest)
+	if !found || len(rest) < 3 || rest[0] != 'm' {
+		return nil, fmt.Errorf("invalid match: %s", spec)
+	}
+	pattern, flags, rest, err := cutDelimited(rest[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := ""
+	for _, flag := range flags {
+		switch flag {
+		case 'i':
+			prefix += "i"
+		case 's':
+			prefix += "s"
+		}
+	}
+	if prefix != "" {
+		prefix = "(?" + prefix + ")"
+	}
+	compiled, err := regexp.Compile(prefix + latin1([]byte(fixPattern(pattern))))
+	if err != nil {
+		return nil, errUnsupported
+	}
+
+	match := &Match{Service: service, Soft: soft, Pattern: compiled}
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		var field, value string
+		if strings.HasPrefix(rest, "cpe:") {
+			field = "cpe"
+			value, _, rest, err = cutDelimited(rest[4:])
+			if err == nil {
+				value = "cpe:/" + value
+			}
+		} else {
+			field = rest[:1]
+			value, _, rest, err = cutDelimited(rest[1:])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid version info in %s: %v", spec, err)
+		}
+
+		switch field {
+		case "p":
+			match.Product = value
+		case "v":
+			match.Version = value
+		case "i":
+			match.Info = value
+		case "h":
+			match.Hostname = value
+		case "o":
+			match.OS = value
+		case "d":
+			match.DeviceType = value
+		case "cpe":
+			match.CPE = append(match.CPE, value)
+		}
+	}
+	return match, nil
+}
+
+// cutDelimited splits "|value|flags rest", where the first character is
+// the delimiter, into the value, the flags directly after it and the rest
+func cutDelimited(s string) (value, flags, rest string, err error) {
+	if len(s) < 2 {
+		return "", "", "", fmt.Errorf("missing delimiter")
+	}
+	end := strings.IndexByte(s[1:], s[0])
+	if end < 0 {
+		return "", "", "", fmt.Errorf("unterminated %c", s[0])
+	}
+	value, rest = s[1:1+end], s[2+end:]
+	flagEnd := strings.IndexByte(rest, ' ')
+	if flagEnd < 0 {
+		flagEnd = len(rest)
+	}
+	return value, rest[:flagEnd], rest[flagEnd:], nil
+}
+
+// fixPattern rewrites the Perl syntax Go spells differently: \0 for a NUL
+// byte, and $ and \Z, which also match before a final line break
+func fixPattern(pattern string) string {
+	var b strings.Builder
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			next := pattern[i+1]
+			switch {
+			case next == '0' && (i+2 == len(pattern) || pattern[i+2] < '0' || pattern[i+2] > '7'):
+				b.WriteString(`\x00`)
+			case next == 'Z' && !inClass:
+				b.WriteString(`(?:\n?\z)`)
+			default:
+				b.WriteByte(c)
+				b.WriteByte(next)
+			}
+			i++
+		case c == '[' && !inClass:
+			inClass = true
+			b.WriteByte(c)
+		case c == ']' && inClass:
+			inClass = false
+			b.WriteByte(c)
+		case c == '$' && !inClass:
+			b.WriteString(`(?:\n?\z)`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// unescape decodes the C escapes of a probe payload
+func unescape(s string) ([]byte, error) {
+	var data []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			data = append(data, s[i])
+			continue
+		}
+		if i+1 == len(s) {
+			return nil, fmt.Errorf("trailing backslash in payload")
+		}
+		i++
+		switch s[i] {
+		case '0':
+			data = append(data, 0)
+		case 'a':
+			data = append(data, '\a')
+		case 'b':
+			data = append(data, '\b')
+		case 'f':
+			data = append(data, '\f')
+		case 'n':
+			data = append(data, '\n')
+		case 'r':
+			data = append(data, '\r')
+		case 't':
+			data = append(data, '\t')
+		case 'v':
+			data = append(data, '\v')
+		case 'x':
+			if i+2 >= len(s) {
+				return nil, fmt.Errorf("short \\x escape in payload")
+			}
+			b, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid \\x escape in payload")
+			}
+			data = append(data, byte(b))
+			i += 2
+		default:
+			data = append(data, s[i])
+		}
+	}
+	return data, nil
+}
+
+// parseExclude parses "T:9100-9107,U:30000-40000"; ports without a
+// protocol apply to both
+func (db *Database) parseExclude(spec string) error {
+	protocols := []string{TCP, UDP}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case strings.HasPrefix(part, "T:"):
+			protocols, part = []string{TCP}, part[2:]
+		case strings.HasPrefix(part, "U:"):
+			protocols, part = []string{UDP}, part[2:]
+		}
+		ranges, err := parsePorts(part)
+		if err != nil {
+			return err
+		}
+		for _, protocol := range protocols {
+			db.exclude[protocol] = append(db.exclude[protocol], ranges...)
+		}
+	}
+	return nil
+}
+
+// parsePorts parses "21,25,8000-8100"
+func parsePorts(spec string) ([]portRange, error) {
+	var ranges []portRange
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		low, high, isRange := strings.Cut(part, "-")
+		var r portRange
+		var err error
+		if r.low, err = strconv.Atoi(low); err != nil {
+			return nil, fmt.Errorf("invalid port: %s", part)
+		}
+		r.high = r.low
+		if isRange {
+			if r.high, err = strconv.Atoi(high); err != nil {
+				return nil, fmt.Errorf("invalid port: %s", part)
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// inRanges reports whether port is in one of the ranges
+func inRanges(ranges []portRange, port int) bool {
+	for _, r := range ranges {
+		if port >= r.low && port <= r.high {
+			return true
+		}
+	}
+	return false
+}
+
+// latin1 maps every byte to the rune of the same value, so that patterns
+// such as [\x80-\xff] match bytes rather than UTF-8 sequences
+func latin1(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// unlatin1 reverses latin1
+func unlatin1(s string) []byte {
+	data := make([]byte, 0, len(s))
+	for _, r := range s {
+		data = append(data, byte(r))
+	}
+	return data
+}
+
+// templateHelper matches $1, $P(1), $SUBST(1,"a","b") and $I(1,">")
+var templateHelper = regexp.MustCompile(`\$(?:(\d)|P\((\d)\)|SUBST\((\d),"([^"]*)","([^"]*)"\)|I\((\d),"([<>])"\))`)
+
+// expand fills the groups of a pattern match into a template
+func expand(template string, groups []string) string {
+	if template == "" {
+		return ""
+	}
+	group := func(n string) []byte {
+		i, _ := strconv.Atoi(n)
+		if i >= len(groups) {
+			return nil
+		}
+		return unlatin1(groups[i])
+	}
+
+	return templateHelper.ReplaceAllStringFunc(template, func(helper string) string {
+		parts := templateHelper.FindStringSubmatch(helper)
+		switch {
+		case parts[1] != "":
+			return string(group(parts[1]))
+		case parts[2] != "":
+			return printable(group(parts[2]))
+		case parts[3] != "":
+			return strings.ReplaceAll(string(group(parts[3])), parts[4], parts[5])
+		default:
+			value := group(parts[6])
+			n := uint64(0)
+			for i := range value {
+				b := value[i]
+				if parts[7] == "<" {
+					b = value[len(value)-1-i]
+				}
+				n = n<<8 | uint64(b)
+			}
+			return strconv.FormatUint(n, 10)
+		}
+	})
+}
+
+// printable drops the bytes of data that are not printable ASCII
+func printable(data []byte) string {
+	var b strings.Builder
+	for _, c := range data {
+		if c >= 0x20 && c < 0x7f {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// Banner returns the printable form of a response for display: printable
+// ASCII is kept, line breaks and other bytes are escaped, and it is cut to
+// max bytes
+func Banner(response []byte, max int) string {
+	var b strings.Builder
+	for _, c := range response {
+		if b.Len() >= max {
+			break
+		}
+		switch {
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c >= 0x20 && c < 0x7f:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
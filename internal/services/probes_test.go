@@ -0,0 +1,277 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mustParse parses a probe file that must be valid
+func mustParse(t *testing.T, data string) *Database {
+	t.Helper()
+	db, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return db
+}
+
+func TestFixPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`^\0\0`, `^\x00\x00`},
+		{`\0`, `\x00`},
+		{`^\012`, `^\012`},
+		{`\08`, `\x008`},
+		{`^220 ready$`, `^220 ready(?:\n?\z)`},
+		{`^220 ready\Z`, `^220 ready(?:\n?\z)`},
+		{`[$]`, `[$]`},
+		{`[^$\Z]+$`, `[^$\Z]+(?:\n?\z)`},
+		{`\$`, `\$`},
+		{`[\]$]$`, `[\]$](?:\n?\z)`},
+	}
+	for _, tt := range tests {
+		if got := fixPattern(tt.pattern); got != tt.want {
+			t.Errorf("fixPattern(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    string
+		wantErr string
+	}{
+		{payload: `GET / HTTP/1.0\r\n\r\n`, want: "GET / HTTP/1.0\r\n\r\n"},
+		{payload: `\0\x01\xff`, want: "\x00\x01\xff"},
+		{payload: `\x41\x4a`, want: "AJ"},
+		{payload: `a\|b\\`, want: `a|b\`},
+		{payload: `\x4`, wantErr: "short"},
+		{payload: `\x`, wantErr: "short"},
+		{payload: `\xZZ`, wantErr: "invalid"},
+		{payload: `abc\`, wantErr: "trailing backslash"},
+	}
+	for _, tt := range tests {
+		got, err := unescape(tt.payload)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("unescape(%q) error = %v, want %q", tt.payload, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unescape(%q): %v", tt.payload, err)
+		} else if string(got) != tt.want {
+			t.Errorf("unescape(%q) = %q, want %q", tt.payload, got, tt.want)
+		}
+	}
+}
+
+func TestMatchFlags(t *testing.T) {
+	db := mustParse(t, `Probe TCP NULL q||
+match plain m|^hello$|
+match nocase m|^HELO (\w+)$|i p/$1/
+match dotall m|^BEGIN.END|s
+`)
+	null := db.Probes[0]
+
+	tests := []struct {
+		response string
+		want     string
+		product  string
+	}{
+		{"hello", "plain", ""},
+		{"hello\n", "plain", ""},
+		{"hello\n\n", "", ""},
+		{"helo mail", "nocase", "mail"},
+		{"BEGIN\nEND", "dotall", ""},
+		{"begin\nend", "", ""},
+	}
+	for _, tt := range tests {
+		service := db.Match(null, []byte(tt.response))
+		switch {
+		case tt.want == "" && service != nil:
+			t.Errorf("%q matched %s, want no match", tt.response, service.Name)
+		case tt.want != "" && (service == nil || service.Name != tt.want):
+			t.Errorf("%q matched %+v, want %s", tt.response, service, tt.want)
+		case service != nil && service.Product != tt.product:
+			t.Errorf("%q product = %q, want %q", tt.response, service.Product, tt.product)
+		}
+	}
+}
+
+func TestMatchTemplates(t *testing.T) {
+	db := mustParse(t, `Probe TCP NULL q||
+match ssh m|^SSH-([\d.]+)-OpenSSH_([\w.]+)| p/OpenSSH/ v/$2/ i/protocol $1/ cpe:/a:openbsd:openssh:$2/ cpe:|o:linux:linux_kernel|
+match ftp m|^220 ([^\r\n]+)\r\n| p/$P(1)/
+match smtp m|^250 (\S+) ESMTP| v/$SUBST(1,"_",".")/
+match binary m|^\x01(..)|s v/$I(1,">")/ i/$I(1,"<")/
+`)
+	null := db.Probes[0]
+
+	tests := []struct {
+		name     string
+		response string
+		want     Service
+	}{
+		{
+			name:     "groups and CPEs",
+			response: "SSH-2.0-OpenSSH_8.9p1 Ubuntu\r\n",
+			want: Service{
+				Name:    "ssh",
+				Product: "OpenSSH",
+				Version: "8.9p1",
+				Info:    "protocol 2.0",
+				CPE:     []string{"cpe:/a:openbsd:openssh:8.9p1", "cpe:/o:linux:linux_kernel"},
+			},
+		},
+		{
+			name:     "printable",
+			response: "220 Pure\x01FTP\x7f d\r\n",
+			want:     Service{Name: "ftp", Product: "PureFTP d"},
+		},
+		{
+			name:     "substitution",
+			response: "250 mail_2_4 ESMTP",
+			want:     Service{Name: "smtp", Version: "mail.2.4"},
+		},
+		{
+			name:     "integers",
+			response: "\x01\x01\x02",
+			want:     Service{Name: "binary", Version: "258", Info: "513"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := db.Match(null, []byte(tt.response))
+			if service == nil {
+				t.Fatal("no match")
+			}
+			if !reflect.DeepEqual(*service, tt.want) {
+				t.Errorf("service = %+v, want %+v", *service, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSkipsUnsupportedPatterns(t *testing.T) {
+	db := mustParse(t, `Probe TCP NULL q||
+match lookahead m|^(?=x)|
+match backreference m|^(a)\1|
+match fine m|^x|
+`)
+	if db.Skipped != 2 {
+		t.Errorf("Skipped = %d, want 2", db.Skipped)
+	}
+	if len(db.Probes[0].Matches) != 1 {
+		t.Errorf("%d matches kept, want 1", len(db.Probes[0].Matches))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"match before a probe", "match ssh m|^SSH|\n", "line 1: match before the first probe"},
+		{"bad probe", "Probe SCTP x q||\n", "line 1: invalid probe"},
+		{"bad payload", "Probe TCP x q|\\x4|\n", "line 1"},
+		{"unterminated pattern", "Probe TCP NULL q||\nmatch ssh m|^SSH\n", "line 2"},
+		{"bad ports", "Probe TCP x q|x|\nports 80,http\n", "line 2: invalid port: http"},
+		{"bad exclude", "Exclude T:x\n", "line 1: invalid port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.data)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExclude(t *testing.T) {
+	db := mustParse(t, "Exclude 53,T:9100-9107,U:30000-30010,31337\n")
+
+	tests := []struct {
+		protocol string
+		port     int
+		want     bool
+	}{
+		{TCP, 53, true},
+		{UDP, 53, true},
+		{TCP, 9100, true},
+		{TCP, 9107, true},
+		{UDP, 9100, false},
+		{TCP, 9108, false},
+		{UDP, 30005, true},
+		{TCP, 30005, false},
+		// A part without a prefix keeps the protocol of the one before
+		{UDP, 31337, true},
+		{TCP, 31337, false},
+	}
+	for _, tt := range tests {
+		if got := db.Excluded(tt.protocol, tt.port); got != tt.want {
+			t.Errorf("Excluded(%s, %d) = %v, want %v", tt.protocol, tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestProbesFor(t *testing.T) {
+	db := mustParse(t, `Probe TCP NULL q||
+Probe TCP Rare q|rare|
+rarity 8
+ports 8443
+Probe TCP Common q|common|
+rarity 1
+Probe TCP Medium q|medium|
+rarity 5
+Probe UDP DNS q|dns|
+rarity 1
+`)
+
+	names := func(probes []*Probe) []string {
+		var names []string
+		for _, probe := range probes {
+			names = append(names, probe.Name)
+		}
+		return names
+	}
+	if got, want := names(db.ProbesFor(TCP, 80, 7)), []string{"NULL", "Common", "Medium"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ProbesFor(80) = %v, want %v", got, want)
+	}
+	// Probes listing the port are sent whatever their rarity
+	if got, want := names(db.ProbesFor(TCP, 8443, 2)), []string{"NULL", "Rare", "Common"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ProbesFor(8443) = %v, want %v", got, want)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	db := mustParse(t, Defaults)
+	if db.Skipped != 0 {
+		t.Errorf("Skipped = %d, want every default match to compile", db.Skipped)
+	}
+	if len(db.Probes) == 0 || db.Probes[0].Name != "NULL" {
+		t.Fatal("the defaults do not start with the NULL probe")
+	}
+	if !db.Excluded(TCP, 9100) {
+		t.Error("the defaults do not exclude printer ports")
+	}
+
+	service := db.Match(db.Probes[0], []byte("SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.1\r\n"))
+	if service == nil || service.Name != "ssh" || service.Product != "OpenSSH" || service.Version != "8.9p1 Ubuntu 3ubuntu0.1" || service.OS != "Linux" {
+		t.Errorf("SSH banner matched %+v, want OpenSSH 8.9p1 on Ubuntu", service)
+	}
+}
+
+func TestBanner(t *testing.T) {
+	if got, want := Banner([]byte("220 ready\r\n\x00\xff"), 100), `220 ready\r\n\x00\xff`; got != want {
+		t.Errorf("Banner = %q, want %q", got, want)
+	}
+	if got, want := Banner([]byte("abcdef"), 3), "abc"; got != want {
+		t.Errorf("cut Banner = %q, want %q", got, want)
+	}
+}
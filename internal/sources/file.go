@@ -0,0 +1,33 @@
+package sources
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// File reads subdomains from a file exported by another tool, such as a
+// crt.sh JSON answer, a passive DNS export in JSON, JSON lines or CSV, or
+// the text or JSON output of amass and subfinder
+type File struct {
+	path string
+}
+
+// NewFile creates a source reading the file at path
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Name identifies the file by its base name, e.g. file:crtsh.json
+func (f *File) Name() string {
+	return "file:" + filepath.Base(f.path)
+}
+
+// Subdomains returns the subdomains of domain the file mentions
+func (f *File) Subdomains(ctx context.Context, domain string) ([]string, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+	return Extract(data, domain), nil
+}
@@ -0,0 +1,93 @@
+package sources
+
+import (
+	"GoReconX/internal/config"
+	"GoReconX/internal/ratelimit"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxResponse caps how much of a service's answer is read
+const maxResponse = 64 << 20
+
+// HTTP asks a web service for the subdomains it knows of. The answer may be
+// JSON or text in any layout; every subdomain mentioned in it is taken.
+type HTTP struct {
+	name      string
+	url       string
+	headers   map[string]string
+	client    *http.Client
+	userAgent string
+}
+
+// NewHTTP creates a source for the configured service, sending its
+// requests with client
+func NewHTTP(cfg config.SourceConfig, client *http.Client, userAgent string) *HTTP {
+	headers := make(map[string]string, len(cfg.Headers))
+	for key, value := range cfg.Headers {
+		headers[key] = os.ExpandEnv(value)
+	}
+	return &HTTP{
+		name:      cfg.Name,
+		url:       cfg.URL,
+		headers:   headers,
+		client:    client,
+		userAgent: userAgent,
+	}
+}
+
+// NewClient returns an HTTP client for querying sources, going through
+// proxy if one is given and the proxy from the environment otherwise
+func NewClient(timeout time.Duration, proxy string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %v", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// Name returns the configured name of the service
+func (h *HTTP) Name() string {
+	return h.name
+}
+
+// Subdomains queries the service for the subdomains of domain
+func (h *HTTP) Subdomains(ctx context.Context, domain string) ([]string, error) {
+	target := strings.ReplaceAll(h.url, "{domain}", url.PathEscape(domain))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %v", err)
+	}
+	req.Header.Set("User-Agent", h.userAgent)
+	for key, value := range h.headers {
+		req.Header.Set(key, value)
+	}
+
+	if err := ratelimit.FromContext(ctx).WaitHost(ctx, req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s answered %s", req.URL.Host, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	if err != nil {
+		return nil, err
+	}
+	return Extract(body, domain), nil
+}
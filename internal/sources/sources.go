@@ -0,0 +1,145 @@
+// Package sources finds the subdomains of a domain without querying the
+// domain itself: in files exported by other tools and in third-party
+// services that index certificates, DNS answers and crawled URLs.
+package sources
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Source lists the subdomains of a domain that it knows of
+type Source interface {
+	// Name identifies the source in findings, e.g. crtsh
+	Name() string
+	// Subdomains returns the names below domain the source lists
+	Subdomains(ctx context.Context, domain string) ([]string, error)
+}
+
+// maxName is the longest DNS name allowed
+const maxName = 253
+
+// Extract returns the distinct subdomains of domain mentioned in data, in
+// the order they first appear. JSON documents and JSON lines are decoded
+// first so that names in escaped strings, such as the newline-separated
+// names of crt.sh answers, are found; anything else is searched as text.
+func Extract(data []byte, domain string) []string {
+	domain = strings.ToLower(strings.Trim(domain, "."))
+	seen := make(map[string]bool)
+	var names []string
+	add := func(text string) {
+		for _, name := range extractNames(text, domain) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoded := false
+		for {
+			var value interface{}
+			if err := decoder.Decode(&value); err != nil {
+				if err == io.EOF || decoded {
+					return names
+				}
+				break
+			}
+			decoded = true
+			walkStrings(value, add)
+		}
+	}
+
+	add(string(data))
+	return names
+}
+
+// walkStrings calls fn with every string in a decoded JSON value
+func walkStrings(value interface{}, fn func(string)) {
+	switch v := value.(type) {
+	case string:
+		fn(v)
+	case []interface{}:
+		for _, item := range v {
+			walkStrings(item, fn)
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			walkStrings(item, fn)
+		}
+	}
+}
+
+// extractNames returns the subdomains of domain found in text. Leading
+// labels that are not valid host name labels, such as the * of wildcard
+// certificates or service labels like _sip, are dropped; names that merely begin with the domain, like
+// example.com.evil.net, are not matches.
+func extractNames(text, domain string) []string {
+	text = strings.ToLower(text)
+	suffix := "." + domain
+
+	var names []string
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], suffix)
+		if i < 0 {
+			return names
+		}
+		start := offset + i
+		end := start + len(suffix)
+		offset = end
+
+		if end < len(text) && (isLabelChar(text[end]) || text[end] == '.' && end+1 < len(text) && isLabelChar(text[end+1])) {
+			continue
+		}
+
+		begin := start
+		for begin > 0 && start-begin < maxName && (isLabelChar(text[begin-1]) || text[begin-1] == '.') {
+			begin--
+		}
+		if name := validSuffix(text[begin:start]); name != "" {
+			names = append(names, name+suffix)
+		}
+	}
+}
+
+// validSuffix returns the longest run of valid host name labels at the end
+// of name
+func validSuffix(name string) string {
+	labels := strings.Split(name, ".")
+	first := len(labels)
+	for first > 0 && validLabel(labels[first-1]) {
+		first--
+	}
+	return strings.Join(labels[first:], ".")
+}
+
+// validLabel reports whether label is a valid host name label
+func validLabel(label string) bool {
+	if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for i := 0; i < len(label); i++ {
+		if !isNameChar(label[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isNameChar reports whether c may appear in a host name label
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-'
+}
+
+// isLabelChar reports whether c may appear in a DNS label found in text.
+// Underscores, as in _dmarc, are not valid in host names, but taking them
+// as part of the label keeps its tail from being mistaken for a name.
+func isLabelChar(c byte) bool {
+	return isNameChar(c) || c == '_'
+}
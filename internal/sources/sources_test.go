@@ -0,0 +1,166 @@
+package sources
+
+import (
+	"GoReconX/internal/config"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name   string
+		domain string
+		data   string
+		want   []string
+		// sorted is set where the order follows JSON object keys, which
+		// are decoded in no fixed order
+		sorted bool
+	}{
+		{
+			name:   "crt.sh JSON",
+			domain: "example.com",
+			data: `[{"issuer_name":"C=US, O=Let's Encrypt","common_name":"*.example.com","name_value":"*.example.com\nexample.com"},` +
+				`{"common_name":"www.example.com","name_value":"www.example.com\nmail.example.com\nWWW.example.com"}]`,
+			want:   []string{"mail.example.com", "www.example.com"},
+			sorted: true,
+		},
+		{
+			name:   "JSON lines",
+			domain: "example.com",
+			data:   "{\"host\":\"api.example.com\",\"source\":\"x\"}\n{\"host\":\"dev.api.example.com\"}\n",
+			want:   []string{"api.example.com", "dev.api.example.com"},
+			sorted: true,
+		},
+		{
+			name:   "subfinder text",
+			domain: "example.com",
+			data:   "www.example.com\nmail.example.com\n\nwww.example.com\n",
+			want:   []string{"www.example.com", "mail.example.com"},
+		},
+		{
+			name:   "amass text",
+			domain: "example.com",
+			data: "www.example.com (FQDN) --> a_record --> 192.0.2.1 (IPAddress)\n" +
+				"example.com (FQDN) --> ns_record --> ns1.example.com (FQDN)\n",
+			want: []string{"www.example.com", "ns1.example.com"},
+		},
+		{
+			name:   "wildcards",
+			domain: "example.com",
+			data:   "*.dev.example.com *.example.com *.*.test.example.com",
+			want:   []string{"dev.example.com", "test.example.com"},
+		},
+		{
+			name:   "domain inside another name",
+			domain: "example.com",
+			data:   "example.com.evil.net www.example.com.evil.net wwwexample.com www.example.community",
+		},
+		{
+			name:   "end of a sentence",
+			domain: "example.com",
+			data:   "Found vpn.example.com. Then mail.example.com, and shop.example.com.",
+			want:   []string{"vpn.example.com", "mail.example.com", "shop.example.com"},
+		},
+		{
+			name:   "apex excluded",
+			domain: "Example.COM.",
+			data:   "example.com EXAMPLE.COM. www.Example.Com",
+			want:   []string{"www.example.com"},
+		},
+		{
+			name:   "URLs and addresses",
+			domain: "example.com",
+			data:   `<a href="https://api.example.com:8443/v1">x</a> admin@mail.example.com`,
+			want:   []string{"api.example.com", "mail.example.com"},
+		},
+		{
+			name:   "invalid labels",
+			domain: "example.com",
+			data:   "bad-.example.com a_b.example.com ok.-bad.example.com _dmarc.example.com _sip._tcp.example.com good.example.com",
+			want:   []string{"good.example.com"},
+		},
+		{
+			name:   "text that is not JSON",
+			domain: "example.com",
+			data:   "[INF] Found www.example.com",
+			want:   []string{"www.example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Extract([]byte(tt.data), tt.domain)
+			if tt.sorted {
+				sort.Strings(got)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPSubdomains(t *testing.T) {
+	var gotPath, gotKey, gotAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotKey, gotAgent = r.URL.Path, r.Header.Get("X-Api-Key"), r.UserAgent()
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/missing/"):
+			http.NotFound(w, r)
+		case strings.HasPrefix(r.URL.Path, "/limited/"):
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write([]byte(`{"subdomains":["www.example.com","api.example.com"]}`))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("TEST_SOURCE_KEY", "secret")
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		wantErr string
+	}{
+		{name: "answer", path: "/v1/{domain}/subdomains", want: []string{"api.example.com", "www.example.com"}},
+		{name: "not found", path: "/missing/{domain}", wantErr: "404 Not Found"},
+		{name: "rate limited", path: "/limited/{domain}", wantErr: "429 Too Many Requests"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := NewHTTP(config.SourceConfig{
+				Name:    "test",
+				URL:     server.URL + tt.path,
+				Headers: map[string]string{"X-Api-Key": "${TEST_SOURCE_KEY}"},
+			}, server.Client(), "GoReconX-test")
+
+			names, err := source.Subdomains(context.Background(), "example.com")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Subdomains: %v", err)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("names = %v, want %v", names, tt.want)
+			}
+			if gotPath != "/v1/example.com/subdomains" {
+				t.Errorf("path = %q, want the domain filled in", gotPath)
+			}
+			if gotKey != "secret" {
+				t.Errorf("X-Api-Key = %q, want the expanded environment variable", gotKey)
+			}
+			if gotAgent != "GoReconX-test" {
+				t.Errorf("User-Agent = %q", gotAgent)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Package takeover describes the hosted services whose dangling DNS records
+// can be claimed by anyone, and recognises them by their CNAME targets and
+// the responses they send for names no customer has configured.
+package takeover
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Fingerprint describes one takeover-prone service, e.g.
+//
+//	fingerprints:
+//	  - service: GitHub Pages
+//	    cnames: [github.io]
+//	    body: ["There isn't a GitHub Pages site here."]
+//	    status: 404
+type Fingerprint struct {
+	Service string `yaml:"service" json:"service"`
+	// CNAMEs are the domains the service's CNAME targets are below, e.g.
+	// herokuapp.com
+	CNAMEs []string `yaml:"cnames" json:"cnames"`
+	// Body holds texts of the page the service serves for a name that is
+	// not claimed by any customer; any one of them is a match
+	Body []string `yaml:"body,omitempty" json:"body,omitempty"`
+	// Status is the HTTP status of that page, 0 for any
+	Status int `yaml:"status,omitempty" json:"status,omitempty"`
+	// NXDomain marks services whose unclaimed CNAME targets do not resolve
+	// at all, which is enough to claim them
+	NXDomain bool `yaml:"nxdomain,omitempty" json:"nxdomain,omitempty"`
+}
+
+// file is the layout of a fingerprint file
+type file struct {
+	Fingerprints []*Fingerprint `yaml:"fingerprints"`
+}
+
+// Load reads fingerprints from a YAML file
+func Load(path string) ([]*Fingerprint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses and validates YAML fingerprints
+func Parse(data []byte) ([]*Fingerprint, error) {
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid fingerprints: %v", err)
+	}
+
+	for i, fp := range f.Fingerprints {
+		if fp.Service == "" {
+			return nil, fmt.Errorf("fingerprint %d needs a service", i+1)
+		}
+		if len(fp.CNAMEs) == 0 {
+			return nil, fmt.Errorf("fingerprint %s needs cnames", fp.Service)
+		}
+		if len(fp.Body) == 0 && !fp.NXDomain {
+			return nil, fmt.Errorf("fingerprint %s needs a body or nxdomain", fp.Service)
+		}
+		for j, cname := range fp.CNAMEs {
+			fp.CNAMEs[j] = strings.ToLower(strings.Trim(cname, "."))
+		}
+	}
+	return f.Fingerprints, nil
+}
+
+// Save writes fingerprints to a YAML file
+func Save(path string, fingerprints []*Fingerprint) error {
+	data, err := yaml.Marshal(file{Fingerprints: fingerprints})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Match returns the fingerprint of the service one of the names in a CNAME
+// chain belongs to, together with that name, or nil if there is none
+func Match(fingerprints []*Fingerprint, chain []string) (*Fingerprint, string) {
+	for _, name := range chain {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		for _, fp := range fingerprints {
+			for _, cname := range fp.CNAMEs {
+				if name == cname || strings.HasSuffix(name, "."+cname) {
+					return fp, name
+				}
+			}
+		}
+	}
+	return nil, ""
+}
+
+// MatchResponse returns the text of the fingerprint found in an HTTP
+// response, or false if the response does not look like the service's
+// page for unclaimed names
+func (fp *Fingerprint) MatchResponse(status int, body string) (string, bool) {
+	if fp.Status != 0 && status != fp.Status {
+		return "", false
+	}
+	for _, text := range fp.Body {
+		if strings.Contains(body, text) {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// Defaults are written to the fingerprint file when it does not exist
+var Defaults = []*Fingerprint{
+	{Service: "AWS S3", CNAMEs: []string{"s3.amazonaws.com", "s3-website.amazonaws.com"}, Body: []string{"The specified bucket does not exist"}, Status: 404},
+	{Service: "AWS Elastic Beanstalk", CNAMEs: []string{"elasticbeanstalk.com"}, NXDomain: true},
+	{Service: "Microsoft Azure", CNAMEs: []string{"azurewebsites.net", "cloudapp.net", "cloudapp.azure.com", "trafficmanager.net", "blob.core.windows.net", "azureedge.net", "azurecontainer.io", "azure-api.net", "azurefd.net"}, NXDomain: true},
+	{Service: "GitHub Pages", CNAMEs: []string{"github.io"}, Body: []string{"There isn't a GitHub Pages site here."}, Status: 404},
+	{Service: "Heroku", CNAMEs: []string{"herokuapp.com", "herokudns.com"}, Body: []string{"No such app", "herokucdn.com/error-pages/no-such-app.html"}},
+	{Service: "Bitbucket", CNAMEs: []string{"bitbucket.io"}, Body: []string{"Repository not found"}},
+	{Service: "Shopify", CNAMEs: []string{"myshopify.com"}, Body: []string{"Sorry, this shop is currently unavailable."}},
+	{Service: "Pantheon", CNAMEs: []string{"pantheonsite.io"}, Body: []string{"The gods are wise, but do not know of the site which you seek."}},
+	{Service: "Tumblr", CNAMEs: []string{"domains.tumblr.com"}, Body: []string{"Whatever you were looking for doesn't currently exist at this address."}},
+	{Service: "Ghost", CNAMEs: []string{"ghost.io"}, Body: []string{"The thing you were looking for is no longer here, or never was"}},
+	{Service: "Surge.sh", CNAMEs: []string{"surge.sh"}, Body: []string{"project not found"}},
+	{Service: "Help Scout", CNAMEs: []string{"helpscoutdocs.com"}, Body: []string{"No settings were found for this company:"}},
+	{Service: "Helpjuice", CNAMEs: []string{"helpjuice.com"}, Body: []string{"We could not find what you're looking for."}},
+	{Service: "Readme.io", CNAMEs: []string{"readme.io"}, Body: []string{"Project doesnt exist... yet!"}},
+	{Service: "Strikingly", CNAMEs: []string{"s.strikinglydns.com"}, Body: []string{"But if you're looking to build your own website"}},
+	{Service: "Unbounce", CNAMEs: []string{"unbouncepages.com"}, Body: []string{"The requested URL was not found on this server."}},
+	{Service: "Webflow", CNAMEs: []string{"proxy.webflow.com", "proxy-ssl.webflow.com"}, Body: []string{"The page you are looking for doesn't exist or has been moved."}},
+	{Service: "WordPress.com", CNAMEs: []string{"wordpress.com"}, Body: []string{"Do you want to register"}},
+	{Service: "Agile CRM", CNAMEs: []string{"agilecrm.com"}, Body: []string{"Sorry, this page is no longer available."}},
+	{Service: "Launchrock", CNAMEs: []string{"launchrock.com"}, Body: []string{"It looks like you may have taken a wrong turn somewhere."}},
+	{Service: "Uptime Robot", CNAMEs: []string{"stats.uptimerobot.com"}, Body: []string{"page not found"}},
+}
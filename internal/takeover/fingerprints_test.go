@@ -0,0 +1,127 @@
+package takeover
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+		want    []*Fingerprint
+	}{
+		{
+			name: "body and status",
+			data: `
+fingerprints:
+  - service: GitHub Pages
+    cnames: [GitHub.IO.]
+    body: ["There isn't a GitHub Pages site here."]
+    status: 404
+`,
+			want: []*Fingerprint{{Service: "GitHub Pages", CNAMEs: []string{"github.io"}, Body: []string{"There isn't a GitHub Pages site here."}, Status: 404}},
+		},
+		{
+			name: "nxdomain",
+			data: `
+fingerprints:
+  - service: Microsoft Azure
+    cnames: [azurewebsites.net, cloudapp.net]
+    nxdomain: true
+`,
+			want: []*Fingerprint{{Service: "Microsoft Azure", CNAMEs: []string{"azurewebsites.net", "cloudapp.net"}, NXDomain: true}},
+		},
+		{name: "empty", data: "", want: nil},
+		{name: "missing service", data: "fingerprints:\n  - cnames: [github.io]\n    nxdomain: true\n", wantErr: "needs a service"},
+		{name: "missing cnames", data: "fingerprints:\n  - service: X\n    nxdomain: true\n", wantErr: "needs cnames"},
+		{name: "missing evidence", data: "fingerprints:\n  - service: X\n    cnames: [x.com]\n", wantErr: "needs a body or nxdomain"},
+		{name: "invalid yaml", data: "fingerprints: [", wantErr: "invalid fingerprints"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d fingerprints, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				g, w := got[i], tt.want[i]
+				if g.Service != w.Service || strings.Join(g.CNAMEs, ",") != strings.Join(w.CNAMEs, ",") ||
+					strings.Join(g.Body, "|") != strings.Join(w.Body, "|") || g.Status != w.Status || g.NXDomain != w.NXDomain {
+					t.Errorf("fingerprint %d = %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	fingerprints := []*Fingerprint{
+		{Service: "GitHub Pages", CNAMEs: []string{"github.io"}},
+		{Service: "Tumblr", CNAMEs: []string{"domains.tumblr.com"}},
+	}
+	tests := []struct {
+		name        string
+		chain       []string
+		wantService string
+		wantName    string
+	}{
+		{"subdomain of the service", []string{"acme.github.io"}, "GitHub Pages", "acme.github.io"},
+		{"service domain itself", []string{"github.io."}, "GitHub Pages", "github.io"},
+		{"later in the chain", []string{"cdn.example.net", "ACME.GitHub.io."}, "GitHub Pages", "acme.github.io"},
+		{"suffix without a label boundary", []string{"notgithub.io"}, "", ""},
+		{"parent of a service domain", []string{"x.tumblr.com"}, "", ""},
+		{"no service", []string{"www.example.com"}, "", ""},
+		{"empty chain", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp, name := Match(fingerprints, tt.chain)
+			service := ""
+			if fp != nil {
+				service = fp.Service
+			}
+			if service != tt.wantService || name != tt.wantName {
+				t.Errorf("Match = %q, %q, want %q, %q", service, name, tt.wantService, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestMatchResponse(t *testing.T) {
+	fp := &Fingerprint{Service: "Heroku", Body: []string{"No such app", "no-such-app.html"}, Status: 404}
+	anyStatus := &Fingerprint{Service: "Surge.sh", Body: []string{"project not found"}}
+	tests := []struct {
+		name     string
+		fp       *Fingerprint
+		status   int
+		body     string
+		wantText string
+		wantOK   bool
+	}{
+		{"first text", fp, 404, "<h1>No such app</h1>", "No such app", true},
+		{"second text", fp, 404, `<a href="/no-such-app.html">`, "no-such-app.html", true},
+		{"wrong status", fp, 200, "No such app", "", false},
+		{"text missing", fp, 404, "Welcome", "", false},
+		{"any status", anyStatus, 200, "project not found", "project not found", true},
+		{"case sensitive", anyStatus, 200, "Project Not Found", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, ok := tt.fp.MatchResponse(tt.status, tt.body)
+			if text != tt.wantText || ok != tt.wantOK {
+				t.Errorf("MatchResponse = %q, %v, want %q, %v", text, ok, tt.wantText, tt.wantOK)
+			}
+		})
+	}
+}
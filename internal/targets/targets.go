@@ -0,0 +1,350 @@
+package targets
+
+import (
+	"GoReconX/internal/engine"
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// maxHostBits caps the number of addresses a single CIDR or IP range may
+// expand to, so that a typo such as /1 cannot produce billions of targets
+const (
+	maxHostBits  = 24
+	maxExpansion = 1 << maxHostBits
+)
+
+// Expand returns a source producing every target described by specs once,
+// in order. Each spec is a comma-separated list whose entries may be:
+//
+//	example.com, 10.0.0.1, https://example.com   a single target
+//	10.0.0.0/22                                  every address in a CIDR range
+//	10.0.0.1-50, 10.0.0.1-10.0.1.20              every address in an IP range
+//	@targets.txt                                 one entry per line of a file
+//	-                                            one entry per line of stdin
+//
+// Lines read from files and stdin are expanded the same way, except that
+// they cannot refer to further files.
+func Expand(specs []string, stdin io.Reader) engine.Source[string] {
+	return expand(specs, stdin, false)
+}
+
+// Entries is like Expand but keeps CIDR and IP ranges whole, for modules
+// that take a range as a single target. Ranges are still checked.
+func Entries(specs []string, stdin io.Reader) engine.Source[string] {
+	return expand(specs, stdin, true)
+}
+
+func expand(specs []string, stdin io.Reader, keepRanges bool) engine.Source[string] {
+	return func(ctx context.Context, yield func(string) bool) error {
+		e := &expander{ctx: ctx, stdin: stdin, keepRanges: keepRanges, seen: make(map[string]bool), yield: yield}
+		for _, spec := range specs {
+			if err := e.spec(spec, true); err != nil || e.stopped {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// List expands specs into a slice, for callers that need the targets more
+// than once
+func List(specs []string, stdin io.Reader) ([]string, error) {
+	var list []string
+	err := Expand(specs, stdin)(context.Background(), func(target string) bool {
+		list = append(list, target)
+		return true
+	})
+	return list, err
+}
+
+// expander holds the state of a single expansion. Addresses expanded from
+// ranges and single IPs are remembered as the ranges they came from, so
+// that a large range costs no more memory than a small one; only other
+// targets, and whole ranges kept by Entries, are remembered one by one.
+type expander struct {
+	ctx        context.Context
+	stdin      io.Reader
+	keepRanges bool
+	seen       map[string]bool
+	covered    addrRanges
+	yield      func(string) bool
+	stopped    bool
+}
+
+// spec expands a comma-separated list of entries
+func (e *expander) spec(spec string, allowFiles bool) error {
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		var err error
+		switch {
+		case allowFiles && entry == "-":
+			if e.stdin == nil {
+				return fmt.Errorf("reading targets from stdin is not supported here")
+			}
+			err = e.lines(e.stdin)
+		case strings.HasPrefix(entry, "@"):
+			if !allowFiles {
+				return fmt.Errorf("target lists cannot include other files: %s", entry)
+			}
+			err = e.file(entry[1:])
+		default:
+			err = e.entry(entry)
+		}
+		if err != nil || e.stopped {
+			return err
+		}
+	}
+	return nil
+}
+
+// file expands every line of the file at path
+func (e *expander) file(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read targets: %v", err)
+	}
+	defer file.Close()
+	return e.lines(file)
+}
+
+// lines expands every line of r, skipping blank lines and comments
+func (e *expander) lines(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := e.spec(line, false); err != nil || e.stopped {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// entry expands a single CIDR, IP range or plain target
+func (e *expander) entry(entry string) error {
+	first, last, ok, err := parseEntry(entry)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		addr, err := netip.ParseAddr(entry)
+		switch {
+		case err != nil:
+			e.emit(entry)
+		case e.keepRanges:
+			e.emit(addr.String())
+		default:
+			e.addresses(addr, addr)
+		}
+		return nil
+	}
+	if e.keepRanges {
+		e.emit(entry)
+		return nil
+	}
+	e.addresses(first, last)
+	return nil
+}
+
+// Range returns the first and last address of a CIDR range, an IP range
+// such as 10.0.0.1-50, or a single IP address
+func Range(entry string) (first, last netip.Addr, err error) {
+	entry = strings.TrimSpace(entry)
+	first, last, ok, err := parseEntry(entry)
+	if err != nil || ok {
+		return first, last, err
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return first, last, fmt.Errorf("invalid IP range %s", entry)
+	}
+	return addr, addr, nil
+}
+
+// Size returns the number of addresses from first to last of a range
+// returned by Range
+func Size(first, last netip.Addr) int {
+	return rangeSize(first, last)
+}
+
+// parseEntry parses a CIDR or IP range. It reports ok = false when entry
+// is neither.
+func parseEntry(entry string) (first, last netip.Addr, ok bool, err error) {
+	if address, _, found := strings.Cut(entry, "/"); found && isAddr(address) {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return first, last, true, fmt.Errorf("invalid CIDR range %s: %v", entry, err)
+		}
+		prefix = prefix.Masked()
+		if prefix.Addr().BitLen()-prefix.Bits() > maxHostBits {
+			return first, last, true, fmt.Errorf("CIDR range %s is too large", entry)
+		}
+		return prefix.Addr(), lastAddr(prefix), true, nil
+	}
+	return parseRange(entry)
+}
+
+// lastAddr returns the last address of prefix
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	bytes := prefix.Addr().As16()
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	for i := 15; hostBits > 0; i-- {
+		n := hostBits
+		if n > 8 {
+			n = 8
+		}
+		bytes[i] |= byte(1<<n - 1)
+		hostBits -= n
+	}
+	last := netip.AddrFrom16(bytes)
+	if prefix.Addr().Is4() {
+		last = last.Unmap()
+	}
+	return last
+}
+
+// addresses emits the addresses from first to last, skipping those an
+// earlier range covered
+func (e *expander) addresses(first, last netip.Addr) {
+	defer e.covered.add(first, last)
+	for addr := first; addr.IsValid() && addr.Compare(last) <= 0; addr = addr.Next() {
+		if earlier, ok := e.covered.containing(addr); ok {
+			addr = earlier.last
+			continue
+		}
+		if err := e.ctx.Err(); err != nil {
+			e.stopped = true
+			return
+		}
+		if e.send(addr.String()); e.stopped {
+			return
+		}
+	}
+}
+
+// emit yields target unless it was produced before
+func (e *expander) emit(target string) {
+	if e.seen[target] {
+		return
+	}
+	e.seen[target] = true
+	e.send(target)
+}
+
+// send yields target and notes when the consumer wants no more
+func (e *expander) send(target string) {
+	if !e.yield(target) {
+		e.stopped = true
+	}
+}
+
+// addrRange is the addresses from first to last
+type addrRange struct {
+	first, last netip.Addr
+}
+
+// addrRanges is a set of addresses kept as sorted ranges that neither
+// overlap nor adjoin
+type addrRanges []addrRange
+
+// search returns the index of the first range that ends at or after addr
+func (r addrRanges) search(addr netip.Addr) int {
+	return sort.Search(len(r), func(i int) bool { return r[i].last.Compare(addr) >= 0 })
+}
+
+// containing returns the range that contains addr, if any
+func (r addrRanges) containing(addr netip.Addr) (addrRange, bool) {
+	if i := r.search(addr); i < len(r) && r[i].first.Compare(addr) <= 0 {
+		return r[i], true
+	}
+	return addrRange{}, false
+}
+
+// add adds the addresses from first to last, merging the ranges they
+// overlap or adjoin
+func (r *addrRanges) add(first, last netip.Addr) {
+	ranges := *r
+	i := ranges.search(first)
+	if prev := first.Prev(); prev.IsValid() {
+		i = ranges.search(prev)
+	}
+	next := last.Next()
+	j := i
+	for ; j < len(ranges); j++ {
+		if ranges[j].first.Compare(last) > 0 && ranges[j].first != next {
+			break
+		}
+		if ranges[j].first.Less(first) {
+			first = ranges[j].first
+		}
+		if last.Less(ranges[j].last) {
+			last = ranges[j].last
+		}
+	}
+	*r = slices.Replace(ranges, i, j, addrRange{first, last})
+}
+
+// parseRange parses "10.0.0.1-50" and "10.0.0.1-10.0.0.50". It reports
+// ok = false when entry is not an IP range at all, e.g. "my-host.com".
+func parseRange(entry string) (first, last netip.Addr, ok bool, err error) {
+	start, end, found := strings.Cut(entry, "-")
+	if !found {
+		return first, last, false, nil
+	}
+	first, err = netip.ParseAddr(strings.TrimSpace(start))
+	if err != nil {
+		return first, last, false, nil
+	}
+	end = strings.TrimSpace(end)
+
+	last, err = netip.ParseAddr(end)
+	if err != nil && first.Is4() {
+		// Only the last octet is given
+		octets := strings.Split(first.String(), ".")
+		octets[3] = end
+		last, err = netip.ParseAddr(strings.Join(octets, "."))
+	}
+	if err != nil {
+		return first, last, true, fmt.Errorf("invalid IP range %s", entry)
+	}
+	if first.BitLen() != last.BitLen() || last.Compare(first) < 0 {
+		return first, last, true, fmt.Errorf("invalid IP range %s", entry)
+	}
+	if size := rangeSize(first, last); size > maxExpansion {
+		return first, last, true, fmt.Errorf("IP range %s is too large", entry)
+	}
+	return first, last, true, nil
+}
+
+// rangeSize returns the number of addresses from first to last, saturating
+// at maxExpansion+1
+func rangeSize(first, last netip.Addr) int {
+	a, b := first.As16(), last.As16()
+	size := 0
+	for i := 0; i < 16; i++ {
+		size = size<<8 + int(b[i]) - int(a[i])
+		if size > maxExpansion {
+			return maxExpansion + 1
+		}
+	}
+	return size + 1
+}
+
+// isAddr reports whether s is an IP address
+func isAddr(s string) bool {
+	_, err := netip.ParseAddr(s)
+	return err == nil
+}
@@ -0,0 +1,95 @@
+package targets
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestExpandDeduplicates(t *testing.T) {
+	tests := []struct {
+		name  string
+		specs []string
+		want  []string
+	}{
+		{
+			name:  "overlapping ranges",
+			specs: []string{"10.0.0.2-4", "10.0.0.0/30", "10.0.0.3-6"},
+			want:  []string{"10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.0", "10.0.0.1", "10.0.0.5", "10.0.0.6"},
+		},
+		{
+			name:  "single addresses and ranges",
+			specs: []string{"10.0.0.1", "10.0.0.0-2", "10.0.0.2"},
+			want:  []string{"10.0.0.1", "10.0.0.0", "10.0.0.2"},
+		},
+		{
+			name:  "range inside an earlier one",
+			specs: []string{"10.0.0.0/29", "10.0.0.2-5", "10.0.0.8"},
+			want:  []string{"10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6", "10.0.0.7", "10.0.0.8"},
+		},
+		{
+			name:  "ranges at the ends of the address families",
+			specs: []string{"255.255.255.254-255", "::-::1", "255.255.255.255", "::"},
+			want:  []string{"255.255.255.254", "255.255.255.255", "::", "::1"},
+		},
+		{
+			name:  "other targets",
+			specs: []string{"example.com,Example.com", "example.com", "2001:db8::1", "2001:DB8:0::1"},
+			want:  []string{"example.com", "Example.com", "2001:db8::1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := List(tt.specs, nil)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("targets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntriesKeepsRanges(t *testing.T) {
+	var got []string
+	err := Entries([]string{"10.0.0.0/30, 10.0.0.1, 10.0.0.0/30", "10.0.0.1-2"}, nil)(context.Background(), func(target string) bool {
+		got = append(got, target)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Entries: %v", err)
+	}
+	if want := "10.0.0.0/30 10.0.0.1 10.0.0.1-2"; strings.Join(got, " ") != want {
+		t.Errorf("entries = %v, want %s", got, want)
+	}
+}
+
+func TestAddrRangesMerge(t *testing.T) {
+	var ranges addrRanges
+	for _, r := range [][2]string{
+		{"10.0.0.10", "10.0.0.20"},
+		{"10.0.0.30", "10.0.0.40"},
+		{"10.0.0.0", "10.0.0.5"},
+		{"10.0.0.21", "10.0.0.29"},
+		{"10.0.0.6", "10.0.0.6"},
+		{"::1", "::2"},
+	} {
+		ranges.add(netip.MustParseAddr(r[0]), netip.MustParseAddr(r[1]))
+	}
+
+	var got []string
+	for _, r := range ranges {
+		got = append(got, r.first.String()+"-"+r.last.String())
+	}
+	if want := "10.0.0.0-10.0.0.6 10.0.0.10-10.0.0.40 ::1-::2"; strings.Join(got, " ") != want {
+		t.Errorf("ranges = %v, want %s", got, want)
+	}
+	if _, ok := ranges.containing(netip.MustParseAddr("10.0.0.8")); ok {
+		t.Error("10.0.0.8 is covered, want it left out")
+	}
+	if r, ok := ranges.containing(netip.MustParseAddr("10.0.0.25")); !ok || r.last != netip.MustParseAddr("10.0.0.40") {
+		t.Errorf("range of 10.0.0.25 = %v, %v, want the merged 10.0.0.10-40", r, ok)
+	}
+}